@@ -0,0 +1,289 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/base64"
+	"github.com/jmcvetta/restclient"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// credentials authenticate the requests made to a server with authentication
+// enabled.  They are held by pointer, so copies of a Database made by
+// WithPriority, WithHeaders etc share them.
+type credentials struct {
+	mu          sync.RWMutex
+	user        string
+	pass        string
+	token       string // Set if the server uses token authentication
+	tokenChange string // URL at which the token is rotated
+}
+
+// An authResponse is returned by the authentication endpoint of the Neo4j
+// 2.2 milestone releases.
+type authResponse struct {
+	Username    string `json:"username"`
+	Token       string `json:"authorization_token"`
+	TokenChange string `json:"authorization_token_change"`
+}
+
+// An AuthStatus describes the user authenticating with the server.
+type AuthStatus struct {
+	Username               string `json:"username"`
+	PasswordChangeRequired bool   `json:"password_change_required"`
+	HrefPasswordChange     string `json:"password_change"`
+}
+
+// authorization returns the value of the Authorization header sent with
+// every request.  Servers using token authentication expect the token as the
+// password of an otherwise empty basic auth header.  It is empty if there
+// are no credentials.
+func (c *credentials) authorization() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	switch {
+	case c.token != "":
+		return `Basic realm="Neo4j" ` + base64.StdEncoding.EncodeToString([]byte(":"+c.token))
+	case c.user == "" && c.pass == "":
+		return ""
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.user+":"+c.pass))
+}
+
+// authorize adds db's credentials, if any, to rr.  An Authorization header
+// set by the request itself, or by WithHeaders, takes precedence.
+func (db *Database) authorize(rr *restclient.RequestResponse) {
+	if db.auth == nil {
+		return
+	}
+	v := db.auth.authorization()
+	if v == "" {
+		return
+	}
+	h := http.Header{}
+	if rr.Header != nil {
+		for k, vs := range *rr.Header {
+			h[k] = vs
+		}
+	}
+	if _, ok := h["Authorization"]; !ok {
+		h.Set("Authorization", v)
+	}
+	rr.Header = &h
+}
+
+// splitUserinfo removes the username and password, if any, from uri.
+func splitUserinfo(uri string) (stripped, user, pass string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.User == nil {
+		return uri, "", "", nil
+	}
+	user = u.User.Username()
+	pass, _ = u.User.Password()
+	u.User = nil
+	return u.String(), user, pass, nil
+}
+
+// authRoot returns the URL below which the server's authentication and
+// management endpoints live - that of the REST API without its /db/data path.
+func (db *Database) authRoot() string {
+	u := strings.TrimRight(db.Url, "/")
+	if strings.HasSuffix(u, "/db/data") {
+		return strings.TrimSuffix(u, "/db/data")
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// negotiateAuth selects the authentication scheme used with db's server,
+// after it has refused basic auth.  The Neo4j 2.2 milestone releases accepted
+// only tokens, issued from an /authentication endpoint which later versions
+// do not have; if the server offers one, a token is obtained and used in
+// place of basic auth.  Otherwise the credentials are wrong, and Unauthorized
+// is returned.
+func (db *Database) negotiateAuth() error {
+	if db.auth.authorization() == "" {
+		return Unauthorized
+	}
+	db.auth.mu.RLock()
+	data := map[string]string{"username": db.auth.user, "password": db.auth.pass}
+	db.auth.mu.RUnlock()
+	res := authResponse{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    db.authRoot() + "/authentication",
+		Method: "POST",
+		Data:   data,
+		Result: &res,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return err
+	}
+	if status != 200 || res.Token == "" {
+		return Unauthorized
+	}
+	db.auth.mu.Lock()
+	db.auth.token = res.Token
+	db.auth.tokenChange = res.TokenChange
+	db.auth.mu.Unlock()
+	return nil
+}
+
+// SetCredentials replaces the username and password used by db and every
+// copy of it, so that a long-running service can rotate its password without
+// reconnecting.  Requests already in flight are unaffected.  If the server
+// uses token authentication the token stays in use, and the new password is
+// used when it is next rotated.
+func (db *Database) SetCredentials(user, pass string) {
+	if db.auth == nil {
+		db.auth = new(credentials)
+	}
+	db.auth.mu.Lock()
+	db.auth.user = user
+	db.auth.pass = pass
+	db.auth.mu.Unlock()
+}
+
+// TokenAuth reports whether db authenticates with a token, rather than with
+// basic auth.
+func (db *Database) TokenAuth() bool {
+	if db.auth == nil {
+		return false
+	}
+	db.auth.mu.RLock()
+	defer db.auth.mu.RUnlock()
+	return db.auth.token != ""
+}
+
+// RotateToken replaces the authentication token in use with a new one issued
+// by the server, invalidating the old.  It fails with NoToken unless db uses
+// token authentication.
+func (db *Database) RotateToken() error {
+	if !db.TokenAuth() {
+		return NoToken
+	}
+	db.auth.mu.RLock()
+	href := db.auth.tokenChange
+	if href == "" {
+		href = db.authRoot() + "/user/" + PathEscape(db.auth.user) + "/authorization_token"
+	}
+	data := map[string]string{"password": db.auth.pass}
+	db.auth.mu.RUnlock()
+	res := authResponse{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    href,
+		Method: "POST",
+		Data:   data,
+		Result: &res,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == 401 || status == 422:
+		return Unauthorized
+	case status != 200 || res.Token == "":
+		logPretty(ne)
+		return ne
+	}
+	db.auth.mu.Lock()
+	db.auth.token = res.Token
+	if res.TokenChange != "" {
+		db.auth.tokenChange = res.TokenChange
+	}
+	db.auth.mu.Unlock()
+	return nil
+}
+
+// user returns the username db authenticates as.
+func (db *Database) user() string {
+	if db.auth == nil {
+		return ""
+	}
+	db.auth.mu.RLock()
+	defer db.auth.mu.RUnlock()
+	return db.auth.user
+}
+
+// AuthStatus returns the status of the user db authenticates as - notably
+// whether the server requires their password to be changed before any other
+// request is allowed, as Neo4j 2.2 does on first use of the default password.
+func (db *Database) AuthStatus() (*AuthStatus, error) {
+	as := AuthStatus{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    db.authRoot() + "/user/" + PathEscape(db.user()),
+		Method: "GET",
+		Result: &as,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case 200:
+		return &as, nil
+	case 401:
+		return nil, Unauthorized
+	case 404:
+		return nil, NotFound
+	}
+	logPretty(ne)
+	return nil, ne
+}
+
+// ChangePassword changes the password of the user db authenticates as from
+// old to new, then uses new for subsequent requests as SetCredentials does.
+// After connecting to a server which requires a password change, call
+// Refresh once the password has been changed.
+func (db *Database) ChangePassword(old, new string) error {
+	user := db.user()
+	ne := NeoError{}
+	h := http.Header{}
+	h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+old)))
+	rr := restclient.RequestResponse{
+		Url:    db.authRoot() + "/user/" + PathEscape(user) + "/password",
+		Method: "POST",
+		Header: &h,
+		Data:   map[string]string{"password": new},
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 200:
+	case 401:
+		return Unauthorized
+	default:
+		logPretty(ne)
+		return ne
+	}
+	db.SetCredentials(user, new)
+	return nil
+}
+
+// passwordChangeRequired reports whether the server refuses requests until
+// the user's password has been changed.
+func (db *Database) passwordChangeRequired() bool {
+	as, err := db.AuthStatus()
+	return err == nil && as.PasswordChangeRequired
+}
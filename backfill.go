@@ -0,0 +1,124 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// DefaultBackfillBatch is the number of nodes migrated per batch by a
+// Backfill whose BatchSize is not set.
+const DefaultBackfillBatch = 500
+
+// A BackfillProgress reports how far a Backfill has got.
+type BackfillProgress struct {
+	Checkpoint int // ID of the last node processed, or -1 if none
+	Processed  int // Nodes read
+	Changed    int // Nodes updated, or which would have been in a dry run
+}
+
+// A Backfill migrates the properties of every node with a label, BatchSize
+// nodes at a time in order of ID.  Each node is either passed to Transform
+// or, if Transform is nil, updated by the Cypher SET clause Set, in which the
+// node is n - for example "n.fullName = n.first + ' ' + n.last".
+//
+// After each batch, Progress is called and After is advanced to the ID of the
+// last node in the batch.  A Backfill interrupted by an error can therefore
+// be resumed by calling Run again, or by a new Backfill whose After is set to
+// the last checkpoint reported.
+type Backfill struct {
+	db        *Database
+	Label     string
+	Set       string
+	Transform func(p Props) (Props, error) // Returns the new properties, or nil to leave the node unchanged
+	BatchSize int
+	After     int  // Only nodes with greater IDs are processed
+	DryRun    bool // Read and transform, but do not write
+	Progress  func(BackfillProgress)
+}
+
+// Backfill returns a Backfill of the nodes with label, starting from the
+// first.  Either Set or Transform must be assigned before it is run.
+func (db *Database) Backfill(label string) *Backfill {
+	return &Backfill{db: db, Label: label, BatchSize: DefaultBackfillBatch, After: -1}
+}
+
+// Run migrates the remaining nodes, returning the progress made.
+func (b *Backfill) Run() (*BackfillProgress, error) {
+	p := &BackfillProgress{Checkpoint: b.After}
+	for {
+		done, err := b.step(p)
+		if err != nil || done {
+			return p, err
+		}
+	}
+}
+
+// step migrates the next batch of nodes, adding to p, and reports whether no
+// nodes remain.
+func (b *Backfill) step(p *BackfillProgress) (bool, error) {
+	if b.Transform == nil && b.Set == "" {
+		return false, NoTransform
+	}
+	size := b.BatchSize
+	if size < 1 {
+		size = DefaultBackfillBatch
+	}
+	stmt := "MATCH (n:" + quoteIdent(b.Label) + ") WHERE id(n) > {after} RETURN n ORDER BY id(n) LIMIT {limit}"
+	nodes, err := b.db.cypherNodes(stmt, Props{"after": b.After, "limit": size})
+	if err != nil || len(nodes) == 0 {
+		return err == nil, err
+	}
+	changed, err := b.apply(nodes)
+	if err != nil {
+		return false, err
+	}
+	b.After = nodes[len(nodes)-1].Id()
+	p.Checkpoint = b.After
+	p.Processed += len(nodes)
+	p.Changed += changed
+	if b.Progress != nil {
+		b.Progress(*p)
+	}
+	return len(nodes) < size, nil
+}
+
+// apply migrates a batch of nodes in a single request, returning how many
+// were changed.
+func (b *Backfill) apply(nodes []*Node) (int, error) {
+	qs := []*CypherQuery{}
+	if b.Transform == nil {
+		ids := make([]int, len(nodes))
+		for i, n := range nodes {
+			ids[i] = n.Id()
+		}
+		qs = append(qs, &CypherQuery{
+			Statement:  "START n=node({ids}) SET " + b.Set,
+			Parameters: Props{"ids": ids},
+		})
+	} else {
+		for _, n := range nodes {
+			props := Props{}
+			for k, v := range n.Data {
+				props[k] = v
+			}
+			np, err := b.Transform(props)
+			if err != nil {
+				return 0, err
+			}
+			if np == nil {
+				continue
+			}
+			qs = append(qs, &CypherQuery{
+				Statement:  "START n=node({id}) SET n = {props}",
+				Parameters: Props{"id": n.Id(), "props": np},
+			})
+		}
+	}
+	changed := len(qs)
+	if b.Transform == nil {
+		changed = len(nodes)
+	}
+	if b.DryRun || len(qs) == 0 {
+		return changed, nil
+	}
+	return changed, b.db.CypherBatch(qs)
+}
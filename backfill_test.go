@@ -0,0 +1,80 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"errors"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestBackfillNoTransform(t *testing.T) {
+	db := &Database{}
+	_, err := db.Backfill("Person").Run()
+	assert.Equal(t, NoTransform, err)
+}
+
+func TestBackfill(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	nodes := []*Node{}
+	for i := 0; i < 5; i++ {
+		n, _ := db.CreateNode(Props{"i": i})
+		n.AddLabel(label)
+		nodes = append(nodes, n)
+	}
+	//
+	// Dry run
+	//
+	b := db.Backfill(label)
+	b.BatchSize = 2
+	b.DryRun = true
+	b.Set = "n.j = n.i * 2"
+	p, err := b.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, BackfillProgress{Checkpoint: nodes[4].Id(), Processed: 5, Changed: 5}, *p)
+	props, _ := nodes[0].Properties()
+	assert.Equal(t, Props{"i": 0.0}, props)
+	//
+	// Interrupted transform, then resumed
+	//
+	fail := errors.New("fail")
+	b = db.Backfill(label)
+	b.BatchSize = 2
+	b.Transform = func(p Props) (Props, error) {
+		if p["i"] == 3.0 {
+			return nil, fail
+		}
+		if p["i"] == 1.0 {
+			return nil, nil
+		}
+		p["j"] = p["i"].(float64) * 2
+		return p, nil
+	}
+	checkpoints := []int{}
+	b.Progress = func(p BackfillProgress) {
+		checkpoints = append(checkpoints, p.Checkpoint)
+	}
+	p, err = b.Run()
+	assert.Equal(t, fail, err)
+	assert.Equal(t, BackfillProgress{Checkpoint: nodes[1].Id(), Processed: 2, Changed: 1}, *p)
+	assert.Equal(t, []int{nodes[1].Id()}, checkpoints)
+	b.Transform = func(p Props) (Props, error) {
+		p["j"] = p["i"].(float64) * 2
+		return p, nil
+	}
+	p, err = b.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, BackfillProgress{Checkpoint: nodes[4].Id(), Processed: 3, Changed: 3}, *p)
+	props, _ = nodes[1].Properties()
+	assert.Equal(t, Props{"i": 1.0}, props)
+	props, _ = nodes[4].Properties()
+	assert.Equal(t, Props{"i": 4.0, "j": 8.0}, props)
+}
@@ -0,0 +1,379 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jmcvetta/restclient"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A batchJob is a single operation submitted to the batch endpoint.  To is
+// relative to the REST API root, and may refer to resources created by earlier
+// jobs in the same batch using the {[JOB ID]} syntax.
+type batchJob struct {
+	Method string      `json:"method"`
+	To     string      `json:"to"`
+	Id     int         `json:"id"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// A batchResponse is the server's response to a single batchJob.
+type batchResponse struct {
+	Id       int             `json:"id"`
+	Location string          `json:"location"`
+	Body     json.RawMessage `json:"body"`
+	From     string          `json:"from"`
+	Status   int             `json:"status"`
+}
+
+// batch executes jobs in a single request to the batch endpoint.  Jobs are
+// executed by the server in one transaction, so either all succeed or none
+// do.  Responses are returned in job order.
+//
+// If db.BatchMaxJobs or db.BatchMaxBytes is set and jobs exceed it, they are
+// instead sent as several sequential requests, each its own transaction.
+// Jobs referring to one another by {N} are always kept in the same request;
+// if that makes a request too large, BatchReferenceSplit is returned and
+// nothing is sent.  If a later request fails, the responses of those already
+// committed are returned along with the error.  Callers which promise that
+// either all their jobs succeed or none do must use sendBatch instead.
+func (db *Database) batch(jobs []*batchJob) ([]batchResponse, error) {
+	return db.batchWith(jobs, nil)
+}
+
+// batchWith is batch, sending header with each request.
+func (db *Database) batchWith(jobs []*batchJob, header *http.Header) ([]batchResponse, error) {
+	chunks, err := db.chunkBatch(jobs)
+	if err != nil {
+		return []batchResponse{}, err
+	}
+	res := []batchResponse{}
+	for _, c := range chunks {
+		r, err := db.sendBatchWith(c, header)
+		res = append(res, r...)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// sendBatch executes jobs in a single request to the batch endpoint, and so
+// in one transaction, however large they are.
+func (db *Database) sendBatch(jobs []*batchJob) ([]batchResponse, error) {
+	return db.sendBatchWith(jobs, nil)
+}
+
+// sendBatchWith is sendBatch, sending header with the request.
+func (db *Database) sendBatchWith(jobs []*batchJob, header *http.Header) ([]batchResponse, error) {
+	res := []batchResponse{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    db.HrefBatch,
+		Method: "POST",
+		Data:   jobs,
+		Result: &res,
+		Error:  &ne,
+		Header: header,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return res, err
+	}
+	if status != 200 {
+		logPretty(ne)
+		return res, ne
+	}
+	if len(res) != len(jobs) {
+		return res, errors.New("Result count does not match job count")
+	}
+	return res, nil
+}
+
+// relPath converts an absolute href into a path relative to the REST API
+// root, suitable for use as the To field of a batchJob.
+func (db *Database) relPath(href string) string {
+	root := strings.TrimRight(db.Url, "/")
+	for _, r := range []string{root, db.serverRoot()} {
+		if r != "" && strings.HasPrefix(href, r) {
+			return "/" + strings.Trim(href[len(r):], "/")
+		}
+	}
+	// The server may advertise a different host name than the one we
+	// connected with, so fall back to comparing paths.
+	h, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	r, err := url.Parse(root)
+	if err != nil {
+		return href
+	}
+	return "/" + strings.Trim(strings.TrimPrefix(h.Path, r.Path), "/")
+}
+
+// batchRef matches a reference to the result of another job in the batch.
+var batchRef = regexp.MustCompile(`\{(\d+)\}`)
+
+// batchRefFields are the fields of a job's body in which it may refer to the
+// results of other jobs, as its To may.
+var batchRefFields = []string{"to", "uri"}
+
+// batchRefs returns the ids of the jobs to which job j, marshalled as b,
+// refers: in its To, its body's reference fields or the string values of its
+// Cypher parameters.  Other strings in the body, such as Cypher statements,
+// are not references, even if they look like them.
+func batchRefs(j *batchJob, b []byte) []int {
+	fields := []string{j.To}
+	var m struct {
+		Body interface{} `json:"body"`
+	}
+	if json.Unmarshal(b, &m) == nil {
+		if body, ok := m.Body.(map[string]interface{}); ok {
+			for _, f := range batchRefFields {
+				if s, ok := body[f].(string); ok {
+					fields = append(fields, s)
+				}
+			}
+			params, _ := body["params"].(map[string]interface{})
+			for _, p := range params {
+				if s, ok := p.(string); ok {
+					fields = append(fields, s)
+				}
+			}
+		}
+	}
+	ids := []int{}
+	for _, f := range fields {
+		for _, m := range batchRef.FindAllStringSubmatch(f, -1) {
+			id, _ := strconv.Atoi(m[1])
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// chunkBatch splits jobs into chunks no larger than db.BatchMaxJobs and
+// db.BatchMaxBytes, without separating jobs which refer to one another.  A job
+// too large to be sent alone gives BatchJobTooLarge.
+func (db *Database) chunkBatch(jobs []*batchJob) ([][]*batchJob, error) {
+	if (db.BatchMaxJobs <= 0 && db.BatchMaxBytes <= 0) || len(jobs) == 0 {
+		return [][]*batchJob{jobs}, nil
+	}
+	index := make(map[int]int, len(jobs)) // Job id to position
+	for i, j := range jobs {
+		index[j.Id] = i
+	}
+	// end[i] is the last job which must share a chunk with job i.
+	size := make([]int, len(jobs))
+	end := make([]int, len(jobs))
+	for i, j := range jobs {
+		b, err := db.codec().Marshal(j)
+		if err != nil {
+			return nil, err
+		}
+		size[i] = len(b)
+		if db.BatchMaxBytes > 0 && size[i]+2 > db.BatchMaxBytes {
+			return nil, BatchJobTooLarge
+		}
+		end[i] = i
+		for _, id := range batchRefs(j, b) {
+			k, ok := index[id]
+			if !ok {
+				continue
+			}
+			lo, hi := k, i
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if hi > end[lo] {
+				end[lo] = hi
+			}
+		}
+	}
+	fits := func(count, bytes int) bool {
+		if db.BatchMaxJobs > 0 && count > db.BatchMaxJobs {
+			return false
+		}
+		if db.BatchMaxBytes > 0 && bytes > db.BatchMaxBytes {
+			return false
+		}
+		return true
+	}
+	chunks := [][]*batchJob{}
+	var chunk []*batchJob
+	chunkBytes := 1 // Opening bracket
+	start, far, segBytes := 0, 0, 0
+	for i := range jobs {
+		segBytes += size[i] + 1 // Job plus comma or closing bracket
+		if end[i] > far {
+			far = end[i]
+		}
+		if i < far {
+			continue
+		}
+		// Jobs start..i form the smallest unit which can be sent alone.
+		seg := jobs[start : i+1]
+		if !fits(len(chunk)+len(seg), chunkBytes+segBytes) && len(chunk) > 0 {
+			chunks = append(chunks, chunk)
+			chunk, chunkBytes = nil, 1
+		}
+		if len(seg) > 1 && !fits(len(seg), 1+segBytes) {
+			return nil, BatchReferenceSplit
+		}
+		chunk = append(chunk, seg...)
+		chunkBytes += segBytes
+		start, far, segBytes = i+1, i+1, 0
+	}
+	return append(chunks, chunk), nil
+}
+
+// A Batch accumulates operations to be executed together by Execute, in a
+// single request to the batch endpoint.  Operations return placeholders,
+// which may be passed to later operations in the same Batch, and which are
+// populated with the created entities once the Batch has been executed.
+type Batch struct {
+	db       *Database
+	jobs     []*batchJob
+	nodes    []*BatchNode
+	rels     []*BatchRelationship
+	executed bool
+}
+
+// A BatchNode is a placeholder for a node created by a Batch.
+type BatchNode struct {
+	job  int
+	node *Node
+}
+
+// Node returns the created node, or nil if the Batch has not been
+// successfully executed.
+func (b *BatchNode) Node() *Node {
+	return b.node
+}
+
+// A BatchRelationship is a placeholder for a relationship created by a Batch.
+type BatchRelationship struct {
+	job int
+	rel *Relationship
+}
+
+// Relationship returns the created relationship, or nil if the Batch has not
+// been successfully executed.
+func (b *BatchRelationship) Relationship() *Relationship {
+	return b.rel
+}
+
+// NewBatch returns an empty Batch of operations on db.
+func (db *Database) NewBatch() *Batch {
+	return &Batch{db: db}
+}
+
+func (b *Batch) add(method, to string, body interface{}) int {
+	id := len(b.jobs)
+	b.jobs = append(b.jobs, &batchJob{Method: method, To: to, Id: id, Body: body})
+	return id
+}
+
+func batchHref(job int) string {
+	return "{" + strconv.Itoa(job) + "}"
+}
+
+// CreateNode adds the creation of a node with properties p to the batch.
+func (b *Batch) CreateNode(p Props) *BatchNode {
+	if p == nil {
+		p = Props{}
+	}
+	n := &BatchNode{job: b.add("POST", b.db.relPath(b.db.HrefNode), p)}
+	b.nodes = append(b.nodes, n)
+	return n
+}
+
+// AddLabel adds labels, with their ancestors in the Database's Vocabulary, to
+// a node created earlier in the batch.
+func (b *Batch) AddLabel(n *BatchNode, labels ...string) {
+	b.add("POST", batchHref(n.job)+"/labels", b.db.Vocabulary.withAncestors(labels))
+}
+
+// CreateRelationship adds the creation of a relationship of type relType
+// between two nodes created earlier in the batch.  Properties p may be nil.
+func (b *Batch) CreateRelationship(start, end *BatchNode, relType string, p Props) *BatchRelationship {
+	body := map[string]interface{}{
+		"to":   batchHref(end.job),
+		"type": relType,
+	}
+	if p != nil {
+		body["data"] = p
+	}
+	r := &BatchRelationship{job: b.add("POST", batchHref(start.job)+"/relationships", body)}
+	b.rels = append(b.rels, r)
+	return r
+}
+
+// Execute sends the batch to the server in a single request, whatever the
+// Database's batch limits, then populates its placeholders.  Either all
+// operations succeed or none do.  Once it has succeeded, a Batch may not be
+// executed again.
+func (b *Batch) Execute() error {
+	if b.executed {
+		return BatchExecuted
+	}
+	for _, j := range b.jobs {
+		var err error
+		switch body := j.Body.(type) {
+		case []string:
+			err = b.db.Vocabulary.CheckLabels(body...)
+		case map[string]interface{}:
+			err = b.db.Vocabulary.CheckRelTypes(body["type"].(string))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(b.jobs) == 0 {
+		b.executed = true
+		return nil
+	}
+	res, err := b.db.sendBatch(b.jobs)
+	if err != nil {
+		return err
+	}
+	b.executed = true
+	byId := make(map[int]json.RawMessage, len(res))
+	for _, r := range res {
+		byId[r.Id] = r.Body
+	}
+	for _, bn := range b.nodes {
+		n := Node{}
+		err := b.db.codec().Unmarshal(byId[bn.job], &n)
+		if err != nil {
+			return err
+		}
+		n.Db = b.db
+		bn.node = &n
+	}
+	for _, br := range b.rels {
+		r := Relationship{}
+		err := b.db.codec().Unmarshal(byId[br.job], &r)
+		if err != nil {
+			return err
+		}
+		r.Db = b.db
+		br.rel = &r
+	}
+	for _, bn := range b.nodes {
+		b.db.nodeCreated(bn.node)
+	}
+	for _, br := range b.rels {
+		b.db.relCreated(br.rel)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRelPath(t *testing.T) {
+	db := &Database{Url: "http://localhost:7474/db/data/"}
+	assert.Equal(t, "/node/7/relationships", db.relPath("http://localhost:7474/db/data/node/7/relationships"))
+	assert.Equal(t, "/cypher", db.relPath("http://127.0.0.1:7474/db/data/cypher"))
+}
+
+func chunkIds(chunks [][]*batchJob) [][]int {
+	ids := [][]int{}
+	for _, c := range chunks {
+		cids := []int{}
+		for _, j := range c {
+			cids = append(cids, j.Id)
+		}
+		ids = append(ids, cids)
+	}
+	return ids
+}
+
+func TestChunkBatch(t *testing.T) {
+	jobs := []*batchJob{
+		&batchJob{Method: "POST", To: "/node", Id: 0},
+		&batchJob{Method: "POST", To: "/node", Id: 1},
+		&batchJob{Method: "POST", To: "{1}/labels", Id: 2, Body: "Person"},
+		&batchJob{Method: "POST", To: "/node", Id: 3},
+		&batchJob{Method: "POST", To: "{0}/relationships", Id: 4, Body: Props{"to": "{3}", "type": "knows"}},
+		&batchJob{Method: "POST", To: "/node", Id: 5},
+	}
+	db := &Database{}
+	chunks, err := db.chunkBatch(jobs)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]int{{0, 1, 2, 3, 4, 5}}, chunkIds(chunks))
+	// Job 4 refers to jobs 0 and 3, so 0..4 cannot be split.
+	db.BatchMaxJobs = 5
+	chunks, err = db.chunkBatch(jobs)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]int{{0, 1, 2, 3, 4}, {5}}, chunkIds(chunks))
+	db.BatchMaxJobs = 2
+	_, err = db.chunkBatch(jobs)
+	assert.Equal(t, BatchReferenceSplit, err)
+	// Without the relationship, only jobs 1 and 2 must stay together.
+	jobs = append(jobs[:4], jobs[5])
+	chunks, err = db.chunkBatch(jobs)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]int{{0}, {1, 2}, {3, 5}}, chunkIds(chunks))
+	// Each job marshals to 37 bytes, plus brackets and commas.
+	db.BatchMaxJobs = 0
+	db.BatchMaxBytes = 77
+	chunks, err = db.chunkBatch([]*batchJob{jobs[0], jobs[3], jobs[4]})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]int{{0, 3}, {5}}, chunkIds(chunks))
+	db.BatchMaxBytes = 38
+	_, err = db.chunkBatch([]*batchJob{jobs[0]})
+	assert.Equal(t, BatchJobTooLarge, err)
+	// Only To and the body's reference fields refer to other jobs.
+	db.BatchMaxBytes = 0
+	db.BatchMaxJobs = 1
+	jobs = []*batchJob{
+		&batchJob{Method: "POST", To: "/node", Id: 0},
+		&batchJob{Method: "POST", To: "/cypher", Id: 1, Body: cypherRequest{Query: "MATCH (n) WHERE id(n) = {0} RETURN n", Parameters: Props{"n": 1}}},
+		&batchJob{Method: "POST", To: "/index/node/people", Id: 2, Body: Props{"uri": "{0}", "key": "k", "value": "{1}"}},
+	}
+	_, err = db.chunkBatch(jobs)
+	assert.Equal(t, BatchReferenceSplit, err)
+	chunks, err = db.chunkBatch(jobs[:2])
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]int{{0}, {1}}, chunkIds(chunks))
+	jobs[1].Body = cypherRequest{Query: "START n=node({n}) RETURN n", Parameters: Props{"n": "{0}"}}
+	_, err = db.chunkBatch(jobs[:2])
+	assert.Equal(t, BatchReferenceSplit, err)
+}
+
+func TestBatchJobs(t *testing.T) {
+	db := &Database{Url: "http://localhost:7474/db/data", HrefNode: "http://localhost:7474/db/data/node"}
+	b := db.NewBatch()
+	n0 := b.CreateNode(nil)
+	n1 := b.CreateNode(Props{"name": "bob"})
+	b.AddLabel(n1, "Person")
+	b.CreateRelationship(n0, n1, "knows", nil)
+	assert.Equal(t, 4, len(b.jobs))
+	assert.Equal(t, "/node", b.jobs[1].To)
+	assert.Equal(t, "{1}/labels", b.jobs[2].To)
+	assert.Equal(t, "{0}/relationships", b.jobs[3].To)
+	assert.Equal(t, map[string]interface{}{"to": "{1}", "type": "knows"}, b.jobs[3].Body)
+	assert.Equal(t, (*Node)(nil), n0.Node())
+}
+
+func TestBatch(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	b := db.NewBatch()
+	alice := b.CreateNode(Props{"name": "alice"})
+	bob := b.CreateNode(Props{"name": "bob"})
+	b.AddLabel(bob, "Person")
+	knows := b.CreateRelationship(alice, bob, "knows", Props{"since": 1999})
+	err := b.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "alice", alice.Node().Data["name"])
+	labels, _ := bob.Node().Labels()
+	assert.Equal(t, []string{"Person"}, labels)
+	r := knows.Relationship()
+	assert.Equal(t, "knows", r.Type)
+	assert.Equal(t, alice.Node().Id(), r.StartId())
+	assert.Equal(t, bob.Node().Id(), r.EndId())
+	assert.Equal(t, BatchExecuted, b.Execute())
+}
+
+func TestBatchExecuteAtomic(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if requests == 1 {
+			w.WriteHeader(500)
+			io.WriteString(w, `{"message": "Unavailable"}`)
+			return
+		}
+		jobs := []batchJob{}
+		json.NewDecoder(r.Body).Decode(&jobs)
+		res := []batchResponse{}
+		for _, j := range jobs {
+			res = append(res, batchResponse{Id: j.Id, Status: 201, Body: json.RawMessage(`{}`)})
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"batch": srv.URL + "/db/data/batch", "node": srv.URL + "/db/data/node"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.BatchMaxJobs = 1
+	b := db.NewBatch()
+	b.CreateNode(nil)
+	b.CreateNode(nil)
+	b.CreateNode(nil)
+	// A failed batch may be retried
+	assert.NotEqual(t, nil, b.Execute())
+	assert.Equal(t, nil, b.Execute())
+	// Atomic batches are never split
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, BatchExecuted, b.Execute())
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+// Package bench holds reproducible benchmarks of package neo4j against a
+// local server - node creation, batch insert, Cypher reads and legacy index
+// queries - plus helpers for spotting regressions between two runs.
+//
+// Run the benchmarks with:
+//
+//	go test -run NONE -bench . -benchmem github.com/jmcvetta/neo4j/bench > new.txt
+//
+// and compare them with a previous run using Compare.  The server is taken
+// from NEO4J_BENCH_URL, defaulting to http://localhost:7474/db/data.
+package bench
+
+import (
+	"bufio"
+	"github.com/jmcvetta/neo4j"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UrlEnv names the environment variable holding the URL of the server to
+// benchmark against.
+const UrlEnv = "NEO4J_BENCH_URL"
+
+// Url returns the URL of the server to benchmark against.
+func Url() string {
+	if u := os.Getenv(UrlEnv); u != "" {
+		return u
+	}
+	return "http://localhost:7474/db/data"
+}
+
+// Connect connects to the benchmark server with opts.
+func Connect(opts *neo4j.ConnectOptions) (*neo4j.Database, error) {
+	return neo4j.ConnectWithOptions(Url(), opts)
+}
+
+// Cleanup deletes every node with label, and their relationships.
+func Cleanup(db *neo4j.Database, label string) error {
+	cq := neo4j.CypherQuery{
+		Statement: "MATCH (n:`" + label + "`) OPTIONAL MATCH (n)-[r]-() DELETE r, n",
+	}
+	return db.Cypher(&cq)
+}
+
+// A Result is the outcome of one benchmark, as reported by go test -bench.
+type Result struct {
+	Name        string
+	NsPerOp     float64
+	BytesPerOp  float64 // Zero unless -benchmem was given
+	AllocsPerOp float64 // Zero unless -benchmem was given
+}
+
+// Parse reads go test -bench output, returning the results keyed by
+// benchmark name.  Lines which are not benchmark results are ignored.
+func Parse(r io.Reader) (map[string]Result, error) {
+	results := map[string]Result{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		res := Result{Name: fields[0]}
+		// Fields after the iteration count come in value/unit pairs.
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				res.NsPerOp = v
+			case "B/op":
+				res.BytesPerOp = v
+			case "allocs/op":
+				res.AllocsPerOp = v
+			}
+		}
+		results[res.Name] = res
+	}
+	return results, scanner.Err()
+}
+
+// A Regression is a benchmark which got slower, or allocated more, between
+// two runs.
+type Regression struct {
+	Name   string
+	Metric string  // "ns/op", "B/op" or "allocs/op"
+	Old    float64 // Value in the old run
+	New    float64 // Value in the new run
+}
+
+// Ratio returns how many times worse the new value is than the old.
+func (r Regression) Ratio() float64 {
+	if r.Old == 0 {
+		return 0
+	}
+	return r.New / r.Old
+}
+
+// Compare returns the metrics of benchmarks present in both runs which are
+// worse in the new run by more than tolerance - e.g. 0.1 for 10% - sorted by
+// benchmark name.
+func Compare(old, new map[string]Result, tolerance float64) []Regression {
+	regs := []Regression{}
+	names := make([]string, 0, len(new))
+	for name := range new {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o, ok := old[name]
+		if !ok {
+			continue
+		}
+		n := new[name]
+		metrics := []struct {
+			unit     string
+			old, new float64
+		}{
+			{"ns/op", o.NsPerOp, n.NsPerOp},
+			{"B/op", o.BytesPerOp, n.BytesPerOp},
+			{"allocs/op", o.AllocsPerOp, n.AllocsPerOp},
+		}
+		for _, m := range metrics {
+			if m.old > 0 && m.new > m.old*(1+tolerance) {
+				regs = append(regs, Regression{Name: name, Metric: m.unit, Old: m.old, New: m.new})
+			}
+		}
+	}
+	return regs
+}
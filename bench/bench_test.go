@@ -0,0 +1,181 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package bench
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/neo4j"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const benchLabel = "BenchmarkNode"
+
+func connect(b *testing.B, opts *neo4j.ConnectOptions) *neo4j.Database {
+	db, err := Connect(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func cleanup(b *testing.B, db *neo4j.Database) {
+	b.StopTimer()
+	err := Cleanup(db, benchLabel)
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+// seed creates count labelled nodes, indexed by name in a legacy index.
+func seed(b *testing.B, db *neo4j.Database, count int) *neo4j.LegacyNodeIndex {
+	qs := make([]*neo4j.CypherQuery, count)
+	for i := range qs {
+		qs[i] = &neo4j.CypherQuery{
+			Statement:  "CREATE (n:" + benchLabel + " {name: {name}})",
+			Parameters: neo4j.Props{"name": "n" + strconv.Itoa(i)},
+		}
+	}
+	err := db.CypherBatch(qs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	idx, err := db.CreateLegacyNodeIndex("bench_idx", "", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	nodes, err := db.NodesByLabel(benchLabel)
+	if err != nil {
+		b.Fatal(err)
+	}
+	entries := make([]neo4j.IndexEntry, len(nodes))
+	for i, n := range nodes {
+		entries[i] = neo4j.IndexEntry{Id: n.Id(), Key: "name", Value: n.Data["name"].(string)}
+	}
+	err = idx.AddMany(entries)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return idx
+}
+
+func BenchmarkCreateNode(b *testing.B) {
+	db := connect(b, nil)
+	defer cleanup(b, db)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n, err := db.CreateNode(neo4j.Props{"i": i})
+		if err != nil {
+			b.Fatal(err)
+		}
+		n.AddLabel(benchLabel)
+	}
+}
+
+func benchmarkCreateNodeParallel(b *testing.B, idle int) {
+	db := connect(b, &neo4j.ConnectOptions{MaxIdleConns: idle})
+	defer cleanup(b, db)
+	b.SetParallelism(4)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cq := neo4j.CypherQuery{Statement: "CREATE (n:" + benchLabel + ")"}
+			err := db.Cypher(&cq)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// The parallel node creation benchmarks show the effect of
+// ConnectOptions.MaxIdleConns.
+
+func BenchmarkCreateNodeParallel(b *testing.B)       { benchmarkCreateNodeParallel(b, 0) }
+func BenchmarkCreateNodeParallelIdle32(b *testing.B) { benchmarkCreateNodeParallel(b, 32) }
+
+func BenchmarkBatchInsert100(b *testing.B) {
+	db := connect(b, nil)
+	defer cleanup(b, db)
+	qs := make([]*neo4j.CypherQuery, 100)
+	for i := range qs {
+		qs[i] = &neo4j.CypherQuery{
+			Statement:  "CREATE (n:" + benchLabel + " {i: {i}})",
+			Parameters: neo4j.Props{"i": i},
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := db.CypherBatch(qs)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCypherRead100(b *testing.B) {
+	db := connect(b, nil)
+	defer cleanup(b, db)
+	seed(b, db, 100).Delete()
+	type row struct {
+		Name string `json:"name"`
+	}
+	res := []row{}
+	cq := neo4j.CypherQuery{
+		Statement: "MATCH (n:" + benchLabel + ") RETURN n.name AS name LIMIT 100",
+		Result:    &res,
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := db.Cypher(&cq)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIndexQuery(b *testing.B) {
+	db := connect(b, nil)
+	defer cleanup(b, db)
+	idx := seed(b, db, 100)
+	defer idx.Delete()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := idx.Find("name", "n"+strconv.Itoa(i%100))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := `goos: linux
+BenchmarkCreateNode	    1000	   1000000 ns/op	    4000 B/op	      50 allocs/op
+BenchmarkIndexQuery	    1000	   2000000 ns/op
+BenchmarkGone	    1000	   1 ns/op
+PASS`
+	new := `BenchmarkCreateNode	    1000	   1050000 ns/op	    6000 B/op	      50 allocs/op
+BenchmarkIndexQuery	    1000	   3000000 ns/op
+BenchmarkAdded	    1000	   1 ns/op`
+	o, err := Parse(strings.NewReader(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Parse(strings.NewReader(new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(o))
+	assert.Equal(t, Result{"BenchmarkCreateNode", 1000000, 4000, 50}, o["BenchmarkCreateNode"])
+	regs := Compare(o, n, 0.1)
+	exp := []Regression{
+		Regression{"BenchmarkCreateNode", "B/op", 4000, 6000},
+		Regression{"BenchmarkIndexQuery", "ns/op", 2000000, 3000000},
+	}
+	assert.Equal(t, exp, regs)
+	assert.Equal(t, 1.5, regs[1].Ratio())
+}
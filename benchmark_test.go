@@ -155,3 +155,33 @@ func BenchmarkNodeChainTx(b *testing.B) {
 		b.Fatal(err)
 	}
 }
+
+// The unmarshal benchmarks decode a 100,000 row result without a server.
+
+func BenchmarkCypherUnmarshal(b *testing.B) {
+	cq := CypherQuery{cr: syntheticResult(100000)}
+	res := []syntheticRow{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := cq.Unmarshal(&res)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCypherUnmarshalRoundTrip measures the previous decoding strategy,
+// which re-encoded the whole result before decoding it.
+func BenchmarkCypherUnmarshalRoundTrip(b *testing.B) {
+	cq := CypherQuery{cr: syntheticResult(100000)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res := []syntheticRow{}
+		err := cq.unmarshalRoundTrip(stdCodec{}, &res)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
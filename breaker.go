@@ -0,0 +1,137 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sync"
+	"time"
+)
+
+// A CircuitBreaker stops a Database sending requests to a server which is
+// down or overloaded, so that callers fail fast with CircuitOpen instead of
+// piling up hanging connections.
+//
+// The breaker starts closed, passing every request.  After threshold
+// consecutive failures - transport errors or 5xx responses - it opens, and
+// rejects every request for the open duration.  It then becomes half-open,
+// letting up to probes requests through at once: if that many succeed it
+// closes again, but a single failure re-opens it.
+//
+// A CircuitBreaker is safe for concurrent use, and may be shared by several
+// Databases addressing the same server.
+type CircuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	probes    int
+	now       func() time.Time
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int       // Consecutive failures while closed
+	openedAt  time.Time // When the breaker last opened
+	inFlight  int       // Probes sent while half-open
+	passed    int       // Successful probes while half-open
+}
+
+// A BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests pass
+	BreakerOpen                         // Requests fail fast
+	BreakerHalfOpen                     // Probe requests pass
+)
+
+// NewCircuitBreaker returns a closed CircuitBreaker which opens after
+// threshold consecutive failures, stays open for openFor, and then closes once
+// probes requests have succeeded.  Values below 1 are treated as 1.
+func NewCircuitBreaker(threshold int, openFor time.Duration, probes int) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	if probes < 1 {
+		probes = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		openFor:   openFor,
+		probes:    probes,
+		now:       time.Now,
+	}
+}
+
+// State returns the breaker's current state.
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.expire()
+	return cb.state
+}
+
+// expire moves an open breaker whose open duration has passed to half-open.
+// The caller must hold cb.mu.
+func (cb *CircuitBreaker) expire() {
+	if cb.state == BreakerOpen && cb.now().Sub(cb.openedAt) >= cb.openFor {
+		cb.state = BreakerHalfOpen
+		cb.inFlight = 0
+		cb.passed = 0
+	}
+}
+
+// allow reports whether a request may be sent, and if so whether it is a
+// half-open probe.
+func (cb *CircuitBreaker) allow() (ok, probe bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.expire()
+	switch cb.state {
+	case BreakerOpen:
+		return false, false
+	case BreakerHalfOpen:
+		if cb.inFlight+cb.passed >= cb.probes {
+			return false, false
+		}
+		cb.inFlight++
+		return true, true
+	}
+	return true, false
+}
+
+// record notes the outcome of a request admitted by allow.
+func (cb *CircuitBreaker) record(probe, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch {
+	case probe:
+		if cb.state != BreakerHalfOpen {
+			return // Another probe has already decided
+		}
+		cb.inFlight--
+		if failed {
+			cb.trip()
+			return
+		}
+		cb.passed++
+		if cb.passed >= cb.probes {
+			cb.state = BreakerClosed
+			cb.failures = 0
+		}
+	case cb.state == BreakerClosed:
+		if !failed {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.threshold {
+			cb.trip()
+		}
+	}
+}
+
+// trip opens the breaker.  The caller must hold cb.mu.
+func (cb *CircuitBreaker) trip() {
+	cb.state = BreakerOpen
+	cb.openedAt = cb.now()
+	cb.failures = 0
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb := NewCircuitBreaker(2, time.Minute, 2)
+	cb.now = func() time.Time { return now }
+	request := func(failed bool) bool {
+		ok, probe := cb.allow()
+		if ok {
+			cb.record(probe, failed)
+		}
+		return ok
+	}
+	//
+	// Closed: a success resets the failure count
+	//
+	assert.T(t, request(true))
+	assert.T(t, request(false))
+	assert.T(t, request(true))
+	assert.Equal(t, BreakerClosed, cb.State())
+	assert.T(t, request(true))
+	assert.Equal(t, BreakerOpen, cb.State())
+	//
+	// Open: fail fast until the open duration passes
+	//
+	assert.T(t, !request(false))
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, cb.State())
+	//
+	// Half-open: a failed probe re-opens
+	//
+	assert.T(t, request(true))
+	assert.Equal(t, BreakerOpen, cb.State())
+	now = now.Add(time.Minute)
+	//
+	// Half-open: at most probes requests are admitted
+	//
+	ok0, probe0 := cb.allow()
+	ok1, probe1 := cb.allow()
+	ok2, _ := cb.allow()
+	assert.T(t, ok0 && ok1 && probe0 && probe1)
+	assert.T(t, !ok2)
+	cb.record(true, false)
+	assert.Equal(t, BreakerHalfOpen, cb.State())
+	cb.record(true, false)
+	assert.Equal(t, BreakerClosed, cb.State())
+}
+
+func TestCircuitBreakerDatabase(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	// Nothing listens on port 1
+	down := *db
+	down.HrefNode = "http://127.0.0.1:1/db/data/node"
+	down.Breaker = NewCircuitBreaker(2, time.Minute, 1)
+	for i := 0; i < 2; i++ {
+		_, err := down.Node(1)
+		assert.NotEqual(t, CircuitOpen, err)
+	}
+	_, err := down.Node(1)
+	assert.Equal(t, CircuitOpen, err)
+}
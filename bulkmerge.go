@@ -0,0 +1,111 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"fmt"
+)
+
+// DefaultMergeChunk is the number of rows merged per statement by BulkMerge
+// when no chunk size is given.
+const DefaultMergeChunk = 1000
+
+// A MergeReport counts the nodes created and matched by BulkMerge.
+type MergeReport struct {
+	Created int
+	Matched int
+}
+
+// bulkMergeStatement returns the statement merging a chunk of rows into nodes
+// with labels[0], keyed on keyProp, and giving them the rest of labels.
+func bulkMergeStatement(labels []string, keyProp string) string {
+	key := quoteIdent(keyProp)
+	setLabels := ""
+	if len(labels) > 1 {
+		setLabels = "SET n" + labelExpr(labels[1:]) + " "
+	}
+	return "UNWIND {rows} AS row " +
+		"OPTIONAL MATCH (e:" + quoteIdent(labels[0]) + " {" + key + ": row." + key + "}) " +
+		"WITH row, e IS NULL AS created " +
+		"MERGE (n:" + quoteIdent(labels[0]) + " {" + key + ": row." + key + "}) " +
+		"SET n += row " + setLabels +
+		"RETURN sum(CASE WHEN created THEN 1 ELSE 0 END) AS created, count(*) AS count"
+}
+
+// dedupeRows combines rows with the same value of keyProp, later rows'
+// properties taking precedence, keeping the order of first appearance.
+func dedupeRows(keyProp string, rows []Props) ([]Props, error) {
+	res := []Props{}
+	seen := map[string]int{}
+	for _, r := range rows {
+		n, err := normalizeProps(r)
+		if err != nil {
+			return nil, err
+		}
+		k, ok := n[keyProp]
+		if !ok {
+			return nil, MissingKey
+		}
+		ks := fmt.Sprintf("%T %v", k, k)
+		if i, ok := seen[ks]; ok {
+			for p, v := range n {
+				res[i][p] = v
+			}
+			continue
+		}
+		seen[ks] = len(res)
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// BulkMerge upserts rows as nodes with label, and its ancestors in the
+// Database's Vocabulary, identified by their value of keyProp: a node is
+// created for each key not yet in the database, and the properties of each
+// row are added to its node, overwriting those of the same name.  Rows
+// sharing a key are combined before merging.  Each statement merges up to
+// chunkSize rows, or DefaultMergeChunk if chunkSize is less than one, and
+// commits on its own; on error the report counts the chunks already merged.  Concurrent writers may create a node between its lookup and its
+// merge, in which case it is counted as created; keyProp should have a
+// uniqueness constraint.
+func (db *Database) BulkMerge(label, keyProp string, rows []Props, chunkSize int) (*MergeReport, error) {
+	rep := &MergeReport{}
+	labels, err := db.Vocabulary.expandLabels(label)
+	if err != nil {
+		return rep, err
+	}
+	rows, err = dedupeRows(keyProp, rows)
+	if err != nil {
+		return rep, err
+	}
+	if chunkSize < 1 {
+		chunkSize = DefaultMergeChunk
+	}
+	stmt := bulkMergeStatement(labels, keyProp)
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		res := []struct {
+			Created int `json:"created"`
+			Count   int `json:"count"`
+		}{}
+		cq := CypherQuery{
+			Statement:  stmt,
+			Parameters: map[string]interface{}{"rows": rows[start:end]},
+			Result:     &res,
+		}
+		err := db.Cypher(&cq)
+		if err != nil {
+			return rep, err
+		}
+		if len(res) == 1 {
+			rep.Created += res[0].Created
+			rep.Matched += res[0].Count - res[0].Created
+		}
+	}
+	return rep, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestDedupeRows(t *testing.T) {
+	rows, err := dedupeRows("id", []Props{
+		{"id": 1, "name": "a"},
+		{"id": "1", "name": "b"},
+		{"id": 1, "age": 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Props{
+		{"id": 1.0, "name": "a", "age": 3.0},
+		{"id": "1", "name": "b"},
+	}, rows)
+	_, err = dedupeRows("id", []Props{{"name": "a"}})
+	assert.Equal(t, MissingKey, err)
+}
+
+func TestBulkMerge(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	n, _ := db.CreateNode(Props{"key": "a", "old": true})
+	n.AddLabel(label)
+	rows := []Props{
+		{"key": "a", "v": 1},
+		{"key": "b", "v": 2},
+		{"key": "c", "v": 3},
+	}
+	rep, err := db.BulkMerge(label, "key", rows, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, MergeReport{Created: 2, Matched: 1}, *rep)
+	props, _ := n.Properties()
+	assert.Equal(t, Props{"key": "a", "old": true, "v": 1.0}, props)
+	rep, err = db.BulkMerge(label, "key", rows, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, MergeReport{Created: 0, Matched: 3}, *rep)
+	nodes, _ := db.NodesByLabel(label)
+	assert.Equal(t, 3, len(nodes))
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"math/rand"
+)
+
+// PageRank computes the PageRank of every node in g, with damping factor
+// damping - typically 0.85 - over the given number of iterations.  The rank
+// of nodes without outgoing edges is shared evenly among all nodes.  Ranks
+// sum to 1.
+func (g *Graph) PageRank(damping float64, iterations int) map[int]float64 {
+	ids := g.Nodes()
+	n := float64(len(ids))
+	rank := make(map[int]float64, len(ids))
+	for _, id := range ids {
+		rank[id] = 1 / n
+	}
+	for i := 0; i < iterations; i++ {
+		dangling := 0.0
+		for _, id := range ids {
+			if len(g.out[id]) == 0 {
+				dangling += rank[id]
+			}
+		}
+		next := make(map[int]float64, len(ids))
+		base := (1-damping)/n + damping*dangling/n
+		for _, id := range ids {
+			next[id] += base
+			out := g.out[id]
+			for _, m := range out {
+				next[m] += damping * rank[id] / float64(len(out))
+			}
+		}
+		rank = next
+	}
+	return rank
+}
+
+// Degree returns the number of edges, incoming plus outgoing, of every node
+// in g.
+func (g *Graph) Degree() map[int]int {
+	deg := make(map[int]int, len(g.out))
+	for id, out := range g.out {
+		deg[id] = len(out) + len(g.in[id])
+	}
+	return deg
+}
+
+// Betweenness computes the betweenness centrality of every node in g,
+// following edge direction, using Brandes' algorithm.  If samples is
+// positive and less than the number of nodes, only that many randomly chosen
+// source nodes are used and the result is scaled up, giving an approximation
+// in proportionally less time; r supplies the randomness.
+func (g *Graph) Betweenness(samples int, r *rand.Rand) map[int]float64 {
+	ids := g.Nodes()
+	sources := ids
+	scale := 1.0
+	if samples > 0 && samples < len(ids) {
+		sources = make([]int, samples)
+		for i, j := range r.Perm(len(ids))[:samples] {
+			sources[i] = ids[j]
+		}
+		scale = float64(len(ids)) / float64(samples)
+	}
+	bc := make(map[int]float64, len(ids))
+	for _, id := range ids {
+		bc[id] = 0
+	}
+	for _, s := range sources {
+		// Breadth first search counting shortest paths from s.
+		stack := []int{}
+		preds := map[int][]int{}
+		sigma := map[int]float64{s: 1}
+		dist := map[int]int{s: 0}
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range g.out[v] {
+				if _, seen := dist[w]; !seen {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+		// Accumulate dependencies in order of decreasing distance.
+		delta := map[int]float64{}
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				bc[w] += delta[w] * scale
+			}
+		}
+	}
+	return bc
+}
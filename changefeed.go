@@ -0,0 +1,119 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// changeFeedBatch is the maximum number of nodes fetched by a single poll.
+const changeFeedBatch = 100
+
+// ChangeFeedMaxBackoff caps the delay between retries of a ChangeFeed whose
+// polls are failing.
+var ChangeFeedMaxBackoff = time.Minute
+
+// A ChangeFeed polls for nodes with a given label whose sequence property - a
+// timestamp, transaction counter or similar, updated whenever the node is
+// written - has advanced past the greatest value already seen, and delivers
+// them in sequence order on a channel.  Values of the sequence property
+// should be unique; nodes sharing a value may be missed if they straddle two
+// polls.
+//
+// A failed poll is logged and retried, backing off exponentially from the
+// polling interval up to ChangeFeedMaxBackoff, so a feed survives transient
+// outages of the server.
+type ChangeFeed struct {
+	db       *Database
+	stmt     string
+	prop     string
+	since    int64
+	interval time.Duration
+	changes  chan *Node
+	stop     chan bool
+	done     chan bool
+	once     sync.Once
+	err      error
+}
+
+// NewChangeFeed starts a ChangeFeed delivering nodes with label whose
+// property prop is greater than since, polling the database every interval.
+func (db *Database) NewChangeFeed(label, prop string, since int64, interval time.Duration) *ChangeFeed {
+	p := "n." + quoteIdent(prop)
+	f := &ChangeFeed{
+		db: db,
+		stmt: "MATCH (n:" + quoteIdent(label) + ") WHERE " + p + " > {since} " +
+			"RETURN n ORDER BY " + p + " LIMIT {limit}",
+		prop:     prop,
+		since:    since,
+		interval: interval,
+		changes:  make(chan *Node),
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+	go f.run()
+	return f
+}
+
+// Changes returns the channel on which changed nodes are delivered.  It is
+// closed when the feed is closed.
+func (f *ChangeFeed) Changes() <-chan *Node {
+	return f.changes
+}
+
+// Close stops the feed.  If the most recent poll failed, its error is
+// returned.  Close may be called more than once.
+func (f *ChangeFeed) Close() error {
+	f.once.Do(func() { close(f.stop) })
+	<-f.done
+	return f.err
+}
+
+func (f *ChangeFeed) run() {
+	defer close(f.done)
+	defer close(f.changes)
+	backoff := f.interval
+	for {
+		nodes, err := f.db.cypherNodes(f.stmt, Props{"since": f.since, "limit": changeFeedBatch})
+		f.err = err
+		if err != nil {
+			log.Printf("neo4j: change feed poll failed, retrying in %s: %s", backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-f.stop:
+				return
+			}
+			backoff *= 2
+			if backoff > ChangeFeedMaxBackoff {
+				backoff = ChangeFeedMaxBackoff
+			}
+			if backoff <= 0 {
+				backoff = time.Millisecond
+			}
+			continue
+		}
+		backoff = f.interval
+		for _, n := range nodes {
+			select {
+			case f.changes <- n:
+			case <-f.stop:
+				return
+			}
+			if v, ok := n.Data[f.prop].(float64); ok {
+				f.since = int64(v)
+			}
+		}
+		if len(nodes) == changeFeedBatch {
+			continue // There may be more changes waiting
+		}
+		select {
+		case <-time.After(f.interval):
+		case <-f.stop:
+			return
+		}
+	}
+}
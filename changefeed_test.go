@@ -0,0 +1,53 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/restclient"
+	"testing"
+	"time"
+)
+
+func TestChangeFeed(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	create := func(seq int) {
+		n, _ := db.CreateNode(Props{"seq": seq})
+		n.AddLabel(label)
+	}
+	create(1)
+	create(2)
+	f := db.NewChangeFeed(label, "seq", 1, 50*time.Millisecond)
+	n := <-f.Changes()
+	assert.Equal(t, float64(2), n.Data["seq"])
+	create(3)
+	n = <-f.Changes()
+	assert.Equal(t, float64(3), n.Data["seq"])
+	err := f.Close()
+	assert.Equal(t, nil, err)
+	_, ok := <-f.Changes()
+	assert.T(t, !ok)
+}
+
+func TestChangeFeedRetry(t *testing.T) {
+	// Nothing listens on port 1, so every poll fails; the feed keeps retrying
+	db := &Database{
+		Rc:         restclient.New(),
+		HrefCypher: "http://127.0.0.1:1/db/data/cypher",
+	}
+	f := db.NewChangeFeed("Thing", "seq", 0, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case _, ok := <-f.Changes():
+		assert.Tf(t, ok, "Feed stopped after a failed poll")
+	default:
+	}
+	err := f.Close()
+	assert.NotEqual(t, nil, err)
+	// Closing again is harmless
+	assert.Equal(t, err, f.Close())
+}
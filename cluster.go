@@ -0,0 +1,318 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/jmcvetta/restclient"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles of the members of an HA cluster.
+const (
+	RoleMaster      = "master"
+	RoleSlave       = "slave"
+	RoleUnavailable = "unavailable"
+)
+
+// A Cluster is a Neo4j HA cluster.  Writes must be sent to the master; reads
+// may be spread across the slaves, which apply the master's writes after a
+// short delay.
+type Cluster struct {
+	// ReadYourWrites, if positive, is how long after a Session writes its
+	// reads go to the master rather than to a slave, which may not yet have
+	// seen the write.
+	ReadYourWrites time.Duration
+	opts           *ConnectOptions
+	mu             sync.Mutex
+	members        []*member
+	next           int // Round robin position among slaves
+	now            func() time.Time
+}
+
+// A member is a server in a Cluster.
+type member struct {
+	url  string
+	db   *Database // Nil until connected
+	role string
+}
+
+// ConnectCluster connects to the members of an HA cluster at urls, each
+// configured by opts, and discovers their roles.  Members which cannot be
+// reached are retried by Discover; it is an error if none can be reached.
+func ConnectCluster(urls []string, opts *ConnectOptions) (*Cluster, error) {
+	c := &Cluster{opts: opts, now: time.Now}
+	for _, u := range urls {
+		c.members = append(c.members, &member{url: u, role: RoleUnavailable})
+	}
+	err := c.Discover()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Discover asks every member its current role, connecting to those not yet
+// reached.  It fails with NoMembers if no member can be reached.
+func (c *Cluster) Discover() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reached := false
+	for _, m := range c.members {
+		m.role = RoleUnavailable
+		if m.db == nil {
+			db, err := ConnectWithOptions(m.url, c.opts)
+			if err != nil {
+				continue
+			}
+			m.db = db
+		}
+		role, err := m.db.haRole()
+		if err != nil {
+			continue
+		}
+		m.role = role
+		reached = true
+	}
+	if !reached {
+		return NoMembers
+	}
+	return nil
+}
+
+// haRole asks the server its role in an HA cluster.  The HA endpoints answer
+// true with status 200 if the server has the role asked about, and false with
+// status 404 otherwise.
+func (db *Database) haRole() (string, error) {
+	for _, role := range []string{RoleMaster, RoleSlave} {
+		var ok bool
+		rr := restclient.RequestResponse{
+			Url:    join(db.authRoot(), "db/manage/server/ha", role),
+			Method: "GET",
+			Result: &ok,
+			Error:  &ok,
+		}
+		status, err := db.do(&rr)
+		if err != nil {
+			return "", err
+		}
+		if status == 200 && ok {
+			return role, nil
+		}
+	}
+	return RoleUnavailable, nil
+}
+
+// Master returns the master, as last discovered.  It fails with NoMaster if
+// there is none, as during a failover.
+func (c *Cluster) Master() (*Database, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range c.members {
+		if m.role == RoleMaster {
+			return m.db, nil
+		}
+	}
+	return nil, NoMaster
+}
+
+// Reader returns a member from which to read: each of the slaves in turn, or
+// the master if no slave is available.
+func (c *Cluster) Reader() (*Database, error) {
+	c.mu.Lock()
+	slaves := []*Database{}
+	for _, m := range c.members {
+		if m.role == RoleSlave {
+			slaves = append(slaves, m.db)
+		}
+	}
+	if len(slaves) > 0 {
+		db := slaves[c.next%len(slaves)]
+		c.next++
+		c.mu.Unlock()
+		return db, nil
+	}
+	c.mu.Unlock()
+	return c.Master()
+}
+
+// A Session is a series of requests made to a Cluster on behalf of one
+// client.  If the Cluster's ReadYourWrites is set, the session's reads see its
+// own recent writes.  A Session is safe for concurrent use.
+type Session struct {
+	c         *Cluster
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+// Session starts a Session.
+func (c *Cluster) Session() *Session {
+	return &Session{c: c}
+}
+
+// Write calls f with the master.  If f fails because the member it was given
+// is no longer the master, or there is no master, as during a failover, the
+// master is rediscovered and f called once more.  f should therefore make its
+// writes in a single transaction, so that a failed attempt leaves nothing
+// behind.
+func (s *Session) Write(f func(db *Database) error) error {
+	err := s.write(f)
+	if notMaster(err) && s.c.Discover() == nil {
+		err = s.write(f)
+	}
+	s.mu.Lock()
+	s.lastWrite = s.c.now()
+	s.mu.Unlock()
+	return err
+}
+
+func (s *Session) write(f func(db *Database) error) error {
+	db, err := s.c.Master()
+	if err != nil {
+		return err
+	}
+	return f(db)
+}
+
+// notMasterExceptions are the simple class names of the exceptions with which
+// an HA member refuses a write because it is not, or is no longer, the master.
+var notMasterExceptions = map[string]bool{
+	"InvalidEpochException": true, // The master has changed since the write began
+	"UnavailableException":  true, // The member is switching roles
+}
+
+// notMaster reports whether err shows that a write was sent to a member which
+// is not the master: the member refused it, or could not be connected to.
+// Other network errors are not included, since the write may have been made.
+func notMaster(err error) bool {
+	switch e := err.(type) {
+	case NeoError:
+		return notMasterExceptions[e.Exception[strings.LastIndex(e.Exception, ".")+1:]]
+	case *NeoError:
+		return notMaster(*e)
+	case *url.Error:
+		return notMaster(e.Err)
+	case *net.OpError:
+		return e.Op == "dial"
+	}
+	return err == NoMaster || err == CircuitOpen
+}
+
+// Read calls f with a slave - or with the master, if the session wrote within
+// the Cluster's ReadYourWrites window.
+func (s *Session) Read(f func(db *Database) error) error {
+	db, err := s.reader()
+	if err != nil {
+		return err
+	}
+	return f(db)
+}
+
+// reader chooses the member from which the session reads.
+func (s *Session) reader() (*Database, error) {
+	s.mu.Lock()
+	recent := !s.lastWrite.IsZero() && s.c.now().Sub(s.lastWrite) < s.c.ReadYourWrites
+	s.mu.Unlock()
+	if recent {
+		return s.c.Master()
+	}
+	return s.c.Reader()
+}
+
+// A ClusterMember describes a member of an HA cluster.
+type ClusterMember struct {
+	InstanceId string
+	Role       string // RoleMaster, RoleSlave, or "UNKNOWN" if unavailable
+	Available  bool   // Serving requests
+	Alive      bool   // Reachable by the other members
+	Uris       []string
+}
+
+// A ClusterOverview is the state of an HA cluster as seen by one member.
+type ClusterOverview struct {
+	Role    string // Of the member queried
+	Members []ClusterMember
+}
+
+// jmxAttribute is a named value in the server's JMX representation.
+type jmxAttribute struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ClusterOverview lists the members of the HA cluster to which the server
+// belongs, with their roles and availability, as reported by the server's
+// High Availability management bean.  It fails with NotFound if the server is
+// not a member of an HA cluster.
+func (db *Database) ClusterOverview() (*ClusterOverview, error) {
+	beans := []struct {
+		Attributes []jmxAttribute `json:"attributes"`
+	}{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    join(db.authRoot(), "db/manage/server/jmx/domain/org.neo4j/instance%3Dkernel%230%2Cname%3DHigh%20Availability"),
+		Method: "GET",
+		Result: &beans,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return nil, err
+	}
+	if status == 404 || status == 204 {
+		return nil, NotFound
+	}
+	if status != 200 {
+		logPretty(ne)
+		return nil, ne
+	}
+	if len(beans) == 0 {
+		return nil, NotFound
+	}
+	ov := &ClusterOverview{Members: []ClusterMember{}}
+	for _, a := range beans[0].Attributes {
+		switch a.Name {
+		case "Role":
+			json.Unmarshal(a.Value, &ov.Role)
+		case "InstancesInCluster":
+			composites := []struct {
+				Value []jmxAttribute `json:"value"`
+			}{}
+			err := json.Unmarshal(a.Value, &composites)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range composites {
+				ov.Members = append(ov.Members, clusterMember(c.Value))
+			}
+		}
+	}
+	return ov, nil
+}
+
+// clusterMember decodes the JMX representation of a cluster member.
+// Unexpected values are left unset.
+func clusterMember(attrs []jmxAttribute) ClusterMember {
+	m := ClusterMember{}
+	for _, a := range attrs {
+		switch a.Name {
+		case "instanceId":
+			json.Unmarshal(a.Value, &m.InstanceId)
+		case "haRole":
+			json.Unmarshal(a.Value, &m.Role)
+		case "available":
+			json.Unmarshal(a.Value, &m.Available)
+		case "alive":
+			json.Unmarshal(a.Value, &m.Alive)
+		case "uris":
+			json.Unmarshal(a.Value, &m.Uris)
+		}
+	}
+	return m
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"errors"
+	"github.com/bmizerany/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// haServer fakes an HA cluster member whose role is *role.
+func haServer(role *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/db/data", "/db/data/":
+			io.WriteString(w, `{"neo4j_version": "2.3.0", "node": "http://`+r.Host+`/db/data/node", "cypher": "http://`+r.Host+`/db/data/cypher"}`)
+		case "/db/data/cypher":
+			if *role != RoleMaster {
+				w.WriteHeader(400)
+				io.WriteString(w, `{"message": "No longer the master", "exception": "InvalidEpochException"}`)
+				return
+			}
+			io.WriteString(w, `{"columns": ["n"], "data": [[1]]}`)
+		case "/db/manage/server/ha/master", "/db/manage/server/ha/slave":
+			if r.URL.Path == "/db/manage/server/ha/"+*role {
+				io.WriteString(w, "true")
+				return
+			}
+			w.WriteHeader(404)
+			io.WriteString(w, "false")
+		case "/db/manage/server/jmx/domain/org.neo4j/instance=kernel#0,name=High Availability":
+			io.WriteString(w, `[{"name": "org.neo4j:instance=kernel#0,name=High Availability", "attributes": [
+				{"name": "Role", "value": "`+*role+`"},
+				{"name": "InstancesInCluster", "value": [
+					{"type": "org.neo4j.management.ClusterMemberInfo", "value": [
+						{"name": "instanceId", "value": "1"},
+						{"name": "haRole", "value": "master"},
+						{"name": "available", "value": true},
+						{"name": "alive", "value": true},
+						{"name": "uris", "value": ["ha://10.0.0.1:6001", "backup://10.0.0.1:6362"]}]},
+					{"type": "org.neo4j.management.ClusterMemberInfo", "value": [
+						{"name": "instanceId", "value": "2"},
+						{"name": "haRole", "value": "UNKNOWN"},
+						{"name": "available", "value": false},
+						{"name": "alive", "value": false},
+						{"name": "uris", "value": []}]}]}]}]`)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+}
+
+func TestClusterSession(t *testing.T) {
+	r0, r1, r2 := RoleMaster, RoleSlave, RoleSlave
+	s0, s1, s2 := haServer(&r0), haServer(&r1), haServer(&r2)
+	defer s0.Close()
+	defer s1.Close()
+	defer s2.Close()
+	c, err := ConnectCluster([]string{s0.URL + "/db/data", s1.URL + "/db/data", s2.URL + "/db/data"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	c.ReadYourWrites = time.Second
+	master, err := c.Master()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, s0.URL+"/db/data", master.Url)
+	s := c.Session()
+	readFrom := func() string {
+		var url string
+		s.Read(func(db *Database) error {
+			url = db.Url
+			return nil
+		})
+		return url
+	}
+	assert.Equal(t, s1.URL+"/db/data", readFrom())
+	assert.Equal(t, s2.URL+"/db/data", readFrom())
+	s.Write(func(db *Database) error {
+		assert.Equal(t, master, db)
+		return nil
+	})
+	assert.Equal(t, master.Url, readFrom())
+	now = now.Add(2 * time.Second)
+	assert.NotEqual(t, master.Url, readFrom())
+	// Without slaves, reads go to the master.
+	r1, r2 = "", ""
+	c.Discover()
+	assert.Equal(t, master.Url, readFrom())
+	r0 = ""
+	c.Discover()
+	_, err = c.Master()
+	assert.Equal(t, NoMaster, err)
+	s1.Close()
+	_, err = ConnectCluster([]string{s1.URL + "/db/data"}, nil)
+	assert.Equal(t, NoMembers, err)
+}
+
+func TestClusterOverview(t *testing.T) {
+	role := RoleSlave
+	srv := haServer(&role)
+	defer srv.Close()
+	db, err := Connect(srv.URL + "/db/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ov, err := db.ClusterOverview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ClusterOverview{
+		Role: RoleSlave,
+		Members: []ClusterMember{
+			{InstanceId: "1", Role: RoleMaster, Available: true, Alive: true, Uris: []string{"ha://10.0.0.1:6001", "backup://10.0.0.1:6362"}},
+			{InstanceId: "2", Role: "UNKNOWN", Uris: []string{}},
+		},
+	}, *ov)
+}
+
+func TestWriteRediscoversMaster(t *testing.T) {
+	r0, r1 := RoleMaster, RoleSlave
+	s0, s1 := haServer(&r0), haServer(&r1)
+	defer s0.Close()
+	defer s1.Close()
+	c, err := ConnectCluster([]string{s0.URL + "/db/data", s1.URL + "/db/data"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r0, r1 = RoleSlave, RoleMaster // Failover
+	calls := []string{}
+	err = c.Session().Write(func(db *Database) error {
+		calls = append(calls, db.Url)
+		return db.Cypher(&CypherQuery{Statement: "CREATE (n) RETURN 1"})
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{s0.URL + "/db/data", s1.URL + "/db/data"}, calls)
+	// Other failures are not retried.
+	calls = calls[:0]
+	err = c.Session().Write(func(db *Database) error {
+		calls = append(calls, db.Url)
+		return NotFound
+	})
+	assert.Equal(t, NotFound, err)
+	assert.Equal(t, 1, len(calls))
+	assert.T(t, notMaster(NoMaster))
+	assert.T(t, !notMaster(nil))
+	assert.T(t, notMaster(NeoError{Exception: "org.neo4j.kernel.ha.com.master.InvalidEpochException"}))
+	assert.T(t, !notMaster(NeoError{Message: "Node with name Master already exists", Exception: "ConstraintViolationException"}))
+	dial := &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	assert.T(t, notMaster(dial))
+	read := &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "read", Err: errors.New("connection reset")}}
+	assert.T(t, !notMaster(read))
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+// Command neo4j-cli runs Cypher statements against, and manages, a Neo4j
+// server from the shell.
+//
+// Usage:
+//
+//	neo4j-cli [flags] command [arguments]
+//
+// Commands:
+//
+//	cypher [statement]                     run statement, or read it from stdin
+//	index list|create|drop label [prop]    manage schema indexes
+//	constraint list|create|drop label [prop]
+//	                                       manage uniqueness constraints
+//	import label keyprop file.csv          merge nodes from a CSV file
+//	export statement                       write statement's results as CSV
+//	fixture statement                      write the nodes returned by
+//	                                       statement, and the relationships
+//	                                       among them, as a JSON fixture
+//	wipe                                   delete every node and relationship
+//	doctor [label.prop ...]                report dangling legacy index entries,
+//	                                       duplicate unique values and
+//	                                       unindexed labels
+//	shell                                  start an interactive Cypher shell
+//
+// The CSV file read by import must have a header row naming the properties;
+// column keyprop identifies each node, so importing a file twice updates the
+// nodes rather than duplicating them.
+//
+// Doctor checks for duplicates every property with a uniqueness constraint,
+// and also each label.prop given, and fails if it finds any problem.
+//
+// Fixture replaces the values of the properties named by -anonymize with
+// pseudonyms derived from the values and -salt, and omits those named by
+// -drop, so that fixtures can be made from production data.  A salt is
+// required with -anonymize, and should be kept secret.
+//
+// The shell runs statements over the transactional endpoint, with
+// multi-line statements, parameters, explicit transactions and a history;
+// type :help at its prompt for details.
+//
+// Flags:
+//
+//	-url string   server URL (default $NEO4J_URL, or http://localhost:7474/db/data)
+//	-csv          write cypher results as CSV rather than as a table
+//	-yes          confirm wipe
+//	-anonymize k1,k2,...
+//	              properties to anonymize in fixtures
+//	-drop k1,k2,...
+//	              properties to omit from fixtures
+//	-salt string  secret used to anonymize (default $NEO4J_FIXTURE_SALT)
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jmcvetta/neo4j"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// A cli holds the state shared by all commands.
+type cli struct {
+	db     *neo4j.Database
+	stdin  io.Reader
+	stdout io.Writer
+	csv    bool
+	yes    bool
+	anon   neo4j.Anonymizer
+}
+
+// A command is run with the arguments following its name.
+type command struct {
+	minArgs, maxArgs int // maxArgs < 0 for no limit
+	run              func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"cypher":     {0, 1, (*cli).cypher},
+	"index":      {2, 3, (*cli).index},
+	"constraint": {2, 3, (*cli).constraint},
+	"import":     {3, 3, (*cli).importCSV},
+	"export":     {1, 1, (*cli).export},
+	"fixture":    {1, 1, (*cli).fixture},
+	"wipe":       {0, 0, (*cli).wipe},
+	"shell":      {0, 0, (*cli).shell},
+	"doctor":     {0, -1, (*cli).doctor},
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "neo4j-cli:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args and executes the command they name.  Arguments are checked
+// before connecting to the server.
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("neo4j-cli", flag.ContinueOnError)
+	url := fs.String("url", neo4j.DefaultUrl(), "server URL")
+	c := &cli{stdin: stdin, stdout: stdout}
+	fs.BoolVar(&c.csv, "csv", false, "write cypher results as CSV")
+	fs.BoolVar(&c.yes, "yes", false, "confirm wipe")
+	anonymize := fs.String("anonymize", "", "properties to anonymize in fixtures")
+	drop := fs.String("drop", "", "properties to omit from fixtures")
+	fs.StringVar(&c.anon.Salt, "salt", os.Getenv("NEO4J_FIXTURE_SALT"), "secret used to anonymize")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("no command given")
+	}
+	c.anon.Keys = splitList(*anonymize)
+	c.anon.Drop = splitList(*drop)
+	if len(c.anon.Keys) > 0 && c.anon.Salt == "" {
+		return errors.New("-anonymize requires -salt or $NEO4J_FIXTURE_SALT")
+	}
+	name, rest := fs.Arg(0), fs.Args()[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	if len(rest) < cmd.minArgs || (cmd.maxArgs >= 0 && len(rest) > cmd.maxArgs) {
+		return fmt.Errorf("wrong number of arguments to %s", name)
+	}
+	c.db, err = neo4j.Connect(*url)
+	if err != nil {
+		return err
+	}
+	return cmd.run(c, rest)
+}
+
+// query executes a statement and writes its results.
+func (c *cli) query(stmt string, asCSV bool) error {
+	cq := neo4j.CypherQuery{Statement: stmt}
+	err := c.db.Cypher(&cq)
+	if err != nil {
+		return err
+	}
+	if asCSV {
+		return cq.WriteCSV(c.stdout)
+	}
+	return cq.WriteTable(c.stdout)
+}
+
+func (c *cli) cypher(args []string) error {
+	if len(args) == 1 {
+		return c.query(args[0], c.csv)
+	}
+	b, err := ioutil.ReadAll(c.stdin)
+	if err != nil {
+		return err
+	}
+	return c.query(string(b), c.csv)
+}
+
+func (c *cli) export(args []string) error {
+	return c.query(args[0], true)
+}
+
+// splitList splits a comma-separated list, ignoring empty items.
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (c *cli) fixture(args []string) error {
+	f, err := c.db.ExportFixture(args[0], nil, &c.anon)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.stdout, "%s\n", b)
+	return err
+}
+
+func (c *cli) index(args []string) error {
+	action, label := args[0], args[1]
+	if action == "list" {
+		indexes, err := c.db.Indexes(label)
+		if err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			fmt.Fprintf(c.stdout, "%s(%s)\n", idx.Label, strings.Join(idx.PropertyKeys, ", "))
+		}
+		return nil
+	}
+	if len(args) != 3 {
+		return fmt.Errorf("index %s needs a property", action)
+	}
+	prop := args[2]
+	switch action {
+	case "create":
+		_, err := c.db.CreateIndex(label, prop)
+		return err
+	case "drop":
+		indexes, err := c.db.Indexes(label)
+		if err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			if idx.PropertyKeys[0] == prop {
+				return idx.Drop()
+			}
+		}
+		return neo4j.NotFound
+	}
+	return fmt.Errorf("unknown index action %q", action)
+}
+
+func (c *cli) constraint(args []string) error {
+	action, label := args[0], args[1]
+	if action == "list" {
+		cs, err := c.db.Constraints(label)
+		if err != nil {
+			return err
+		}
+		for _, con := range cs {
+			fmt.Fprintf(c.stdout, "%s(%s) %s\n", con.Label, strings.Join(con.PropertyKeys, ", "), con.Type)
+		}
+		return nil
+	}
+	if len(args) != 3 {
+		return fmt.Errorf("constraint %s needs a property", action)
+	}
+	prop := args[2]
+	switch action {
+	case "create":
+		_, err := c.db.CreateUniqueConstraint(label, prop)
+		return err
+	case "drop":
+		cs, err := c.db.Constraints(label)
+		if err != nil {
+			return err
+		}
+		for _, con := range cs {
+			if con.PropertyKeys[0] == prop {
+				return con.Drop()
+			}
+		}
+		return neo4j.NotFound
+	}
+	return fmt.Errorf("unknown constraint action %q", action)
+}
+
+// readNodes reads nodes from CSV with a header row of property names.  Each
+// node's key is its value in column keyProp.  Empty cells are omitted.
+func readNodes(r io.Reader, keyProp string) ([]neo4j.ImportNode, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing header row")
+	}
+	header := records[0]
+	key := -1
+	for i, h := range header {
+		if h == keyProp {
+			key = i
+		}
+	}
+	if key < 0 {
+		return nil, fmt.Errorf("no column named %q", keyProp)
+	}
+	nodes := make([]neo4j.ImportNode, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		n := neo4j.ImportNode{Key: rec[key], Props: neo4j.Props{}}
+		for i, v := range rec {
+			if v != "" {
+				n.Props[header[i]] = v
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+func (c *cli) importCSV(args []string) error {
+	label, keyProp, file := args[0], args[1], args[2]
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	nodes, err := readNodes(f, keyProp)
+	if err != nil {
+		return err
+	}
+	res, err := c.db.Import(label, keyProp, nodes, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "imported %d nodes\n", len(res.Nodes))
+	return nil
+}
+
+func (c *cli) wipe(args []string) error {
+	if !c.yes {
+		return errors.New("wipe deletes everything in the database; confirm with -yes")
+	}
+	cq := neo4j.CypherQuery{
+		Statement: "MATCH (n) OPTIONAL MATCH (n)-[r]-() DELETE r, n",
+	}
+	return c.db.Cypher(&cq)
+}
+
+// uniqueChecks parses arguments of the form label.prop.
+func uniqueChecks(args []string) ([]neo4j.UniqueCheck, error) {
+	checks := []neo4j.UniqueCheck{}
+	for _, a := range args {
+		i := strings.LastIndex(a, ".")
+		if i < 1 || i == len(a)-1 {
+			return nil, fmt.Errorf("bad property %q: want label.prop", a)
+		}
+		checks = append(checks, neo4j.UniqueCheck{Label: a[:i], Property: a[i+1:]})
+	}
+	return checks, nil
+}
+
+func (c *cli) doctor(args []string) error {
+	checks, err := uniqueChecks(args)
+	if err != nil {
+		return err
+	}
+	rep, err := c.db.Doctor(checks...)
+	if err != nil {
+		return err
+	}
+	err = rep.WriteText(c.stdout)
+	if err != nil {
+		return err
+	}
+	if !rep.Healthy() {
+		return errors.New("problems found")
+	}
+	return nil
+}
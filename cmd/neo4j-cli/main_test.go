@@ -0,0 +1,69 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package main
+
+import (
+	"bytes"
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/neo4j"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunArgs(t *testing.T) {
+	var out bytes.Buffer
+	for _, args := range [][]string{
+		{},
+		{"frobnicate"},
+		{"index", "list"},
+		{"import", "Person", "name"},
+		{"wipe", "now"},
+		{"fixture"},
+		{"-nosuchflag", "wipe"},
+	} {
+		assert.NotEqual(t, nil, run(args, nil, &out), args)
+	}
+}
+
+func TestReadNodes(t *testing.T) {
+	in := "name,age,city\nalice,30,\nbob,,Leeds\n"
+	nodes, err := readNodes(strings.NewReader(in), "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []neo4j.ImportNode{
+		{Key: "alice", Props: neo4j.Props{"name": "alice", "age": "30"}},
+		{Key: "bob", Props: neo4j.Props{"name": "bob", "city": "Leeds"}},
+	}
+	assert.Equal(t, exp, nodes)
+	_, err = readNodes(strings.NewReader(in), "id")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestUniqueChecks(t *testing.T) {
+	checks, err := uniqueChecks([]string{"Person.name", "a.b.c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []neo4j.UniqueCheck{{Label: "Person", Property: "name"}, {Label: "a.b", Property: "c"}}
+	assert.Equal(t, exp, checks)
+	for _, bad := range []string{"Person", ".name", "Person."} {
+		_, err := uniqueChecks([]string{bad})
+		assert.NotEqual(t, nil, err, bad)
+	}
+}
+
+func TestFixtureRequiresSalt(t *testing.T) {
+	var out bytes.Buffer
+	os.Setenv("NEO4J_FIXTURE_SALT", "")
+	err := run([]string{"-anonymize", "email", "fixture", "MATCH (n) RETURN n"}, nil, &out)
+	assert.Equal(t, "-anonymize requires -salt or $NEO4J_FIXTURE_SALT", err.Error())
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"email", "phone"}, splitList(" email,,phone "))
+	assert.Equal(t, []string{}, splitList(""))
+}
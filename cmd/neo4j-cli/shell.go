@@ -0,0 +1,218 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/jmcvetta/neo4j"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const shellHelp = `Statements may span several lines, and end with a semicolon.
+Commands:
+  :param name value   set parameter {name}; value is JSON, or else a string
+  :unparam name       remove a parameter
+  :params             list parameters
+  :begin              open a transaction
+  :commit             commit the open transaction
+  :rollback           roll back the open transaction
+  :history            list previous statements
+  !n                  run statement n from the history again
+  :help               show this help
+  :quit               leave the shell
+Outside an explicit transaction, each statement is committed on its own.
+`
+
+// A shell is an interactive session over the transactional endpoint.
+type shell struct {
+	c       *cli
+	params  neo4j.Props
+	history []string
+	tx      *neo4j.Tx
+	exec    func(q *neo4j.CypherQuery) error // Replaceable for testing
+}
+
+func newShell(c *cli) *shell {
+	s := &shell{c: c, params: neo4j.Props{}}
+	s.exec = s.execute
+	return s
+}
+
+func (c *cli) shell(args []string) error {
+	return newShell(c).loop()
+}
+
+func (s *shell) printf(format string, a ...interface{}) {
+	fmt.Fprintf(s.c.stdout, format, a...)
+}
+
+// loop reads and runs statements and commands until EOF or :quit.  An open
+// transaction is rolled back on leaving.
+func (s *shell) loop() error {
+	in := bufio.NewScanner(s.c.stdin)
+	pending := []string{}
+	s.printf("neo4j> ")
+	for in.Scan() {
+		line := strings.TrimSpace(in.Text())
+		switch {
+		case len(pending) == 0 && strings.HasPrefix(line, ":"):
+			if !s.command(line) {
+				return s.leave()
+			}
+		case len(pending) == 0 && strings.HasPrefix(line, "!"):
+			n, err := strconv.Atoi(line[1:])
+			if err != nil || n < 1 || n > len(s.history) {
+				s.printf("no statement %s in history\n", line[1:])
+				break
+			}
+			s.run(s.history[n-1])
+		case line != "":
+			pending = append(pending, line)
+			if strings.HasSuffix(line, ";") {
+				stmt := strings.TrimSuffix(strings.Join(pending, "\n"), ";")
+				pending = pending[:0]
+				s.run(stmt)
+			}
+		}
+		if len(pending) > 0 {
+			s.printf("  ...> ")
+		} else {
+			s.printf("neo4j> ")
+		}
+	}
+	s.printf("\n")
+	err := in.Err()
+	if lerr := s.leave(); err == nil {
+		err = lerr
+	}
+	return err
+}
+
+// leave rolls back any open transaction.
+func (s *shell) leave() error {
+	if s.tx == nil {
+		return nil
+	}
+	s.printf("rolling back open transaction\n")
+	err := s.tx.Rollback()
+	s.tx = nil
+	return err
+}
+
+// command runs a shell command, returning false if the shell should exit.
+func (s *shell) command(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case ":quit", ":exit":
+		return false
+	case ":help":
+		s.printf(shellHelp)
+	case ":param":
+		if len(fields) < 3 {
+			s.printf("usage: :param name value\n")
+			break
+		}
+		raw := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[len(":param"):]), fields[1]))
+		var v interface{}
+		if json.Unmarshal([]byte(raw), &v) != nil {
+			v = raw
+		}
+		s.params[fields[1]] = v
+	case ":unparam":
+		for _, name := range fields[1:] {
+			delete(s.params, name)
+		}
+	case ":params":
+		names := make([]string, 0, len(s.params))
+		for name := range s.params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b, _ := json.Marshal(s.params[name])
+			s.printf("%s = %s\n", name, b)
+		}
+	case ":history":
+		for i, stmt := range s.history {
+			s.printf("%d  %s\n", i+1, strings.Replace(stmt, "\n", " ", -1))
+		}
+	case ":begin":
+		if s.tx != nil {
+			s.printf("a transaction is already open\n")
+			break
+		}
+		tx, err := s.c.db.Begin([]*neo4j.CypherQuery{})
+		if err != nil {
+			s.printf("error: %v\n", err)
+			break
+		}
+		s.tx = tx
+	case ":commit", ":rollback":
+		if s.tx == nil {
+			s.printf("no transaction is open\n")
+			break
+		}
+		var err error
+		if fields[0] == ":commit" {
+			err = s.tx.Commit()
+		} else {
+			err = s.tx.Rollback()
+		}
+		s.tx = nil
+		if err != nil {
+			s.printf("error: %v\n", err)
+		}
+	default:
+		s.printf("unknown command %s; try :help\n", fields[0])
+	}
+	return true
+}
+
+// run executes a statement with the current parameters, recording it in the
+// history.
+func (s *shell) run(stmt string) {
+	s.history = append(s.history, stmt)
+	params := neo4j.Props{}
+	for k, v := range s.params {
+		params[k] = v
+	}
+	q := &neo4j.CypherQuery{Statement: stmt, Parameters: params}
+	err := s.exec(q)
+	if err != nil {
+		s.printf("error: %v\n", err)
+	}
+}
+
+// execute runs q in the open transaction, or else in a transaction of its own,
+// and prints the results.
+func (s *shell) execute(q *neo4j.CypherQuery) error {
+	qs := []*neo4j.CypherQuery{q}
+	tx := s.tx
+	var err error
+	if tx != nil {
+		err = tx.Query(qs)
+	} else {
+		tx, err = s.c.db.Begin(qs)
+		if err == nil {
+			err = tx.Commit()
+		}
+	}
+	if err == neo4j.TxQueryError {
+		for _, e := range tx.Errors {
+			s.printf("%s: %s\n", e.Status, e.Message)
+		}
+		if s.tx != nil {
+			s.printf("the transaction can no longer be committed\n")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return q.WriteTable(s.c.stdout)
+}
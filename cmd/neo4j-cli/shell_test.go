@@ -0,0 +1,54 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package main
+
+import (
+	"bytes"
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/neo4j"
+	"strings"
+	"testing"
+)
+
+func TestShell(t *testing.T) {
+	in := `:param name "alice"
+:param age 30
+:param nick Al the Great
+MATCH (n {name: {name}})
+RETURN n;
+:unparam nick
+:params
+RETURN 1;
+!1
+!9
+:history
+:frob
+:quit
+RETURN 2;
+`
+	var out bytes.Buffer
+	s := newShell(&cli{stdin: strings.NewReader(in), stdout: &out})
+	ran := []*neo4j.CypherQuery{}
+	s.exec = func(q *neo4j.CypherQuery) error {
+		ran = append(ran, q)
+		return nil
+	}
+	err := s.loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(ran))
+	assert.Equal(t, "MATCH (n {name: {name}})\nRETURN n", ran[0].Statement)
+	assert.Equal(t, neo4j.Props{"name": "alice", "age": float64(30), "nick": "Al the Great"}, neo4j.Props(ran[0].Parameters))
+	assert.Equal(t, "RETURN 1", ran[1].Statement)
+	assert.Equal(t, ran[0].Statement, ran[2].Statement)
+	assert.Equal(t, neo4j.Props{"name": "alice", "age": float64(30)}, neo4j.Props(ran[2].Parameters))
+	o := out.String()
+	assert.T(t, strings.Contains(o, "  ...> "), o)
+	assert.T(t, strings.Contains(o, "age = 30\nname = \"alice\"\n"), o)
+	assert.T(t, strings.Contains(o, "no statement 9 in history"), o)
+	assert.T(t, strings.Contains(o, "1  MATCH (n {name: {name}}) RETURN n\n2  RETURN 1\n3  MATCH"), o)
+	assert.T(t, strings.Contains(o, "unknown command :frob"), o)
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A schema is the structure of a database, as reflected in generated code.
+type schema struct {
+	Labels       []string
+	RelTypes     []string
+	PropertyKeys []string
+	Props        map[string]map[string]string // Go type of each property, by label
+}
+
+func newSchema() *schema {
+	return &schema{Props: map[string]map[string]string{}}
+}
+
+// observe records the types of the properties of a node with label.
+func (s *schema) observe(label string, props map[string]interface{}) {
+	types := s.Props[label]
+	if types == nil {
+		types = map[string]string{}
+		s.Props[label] = types
+	}
+	for k, v := range props {
+		types[k] = mergeTypes(types[k], goType(v))
+	}
+}
+
+// goType returns the Go type of a property value decoded from JSON.  Neo4j
+// properties are numbers, strings, booleans, or homogeneous arrays of them.
+// A number is int64 only if decoded as a json.Number written without a
+// fraction or exponent; the server writes floating point properties with
+// one, even when they are whole.
+func goType(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return "float64"
+		}
+		return "int64"
+	case float64:
+		return "float64"
+	case []interface{}:
+		t := ""
+		for _, e := range v {
+			t = mergeTypes(t, goType(e))
+		}
+		if t == "" || strings.HasPrefix(t, "[]") {
+			t = "interface{}"
+		}
+		return "[]" + t
+	}
+	return "interface{}"
+}
+
+// mergeTypes returns a Go type which can hold values of both types a and b.
+// The empty string stands for no type yet observed.
+func mergeTypes(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	case a == "int64" && b == "float64", a == "float64" && b == "int64":
+		return "float64"
+	case a == "[]int64" && b == "[]float64", a == "[]float64" && b == "[]int64":
+		return "[]float64"
+	case a == "[]interface{}" && strings.HasPrefix(b, "[]"):
+		return b // An empty array says nothing about element type
+	case b == "[]interface{}" && strings.HasPrefix(a, "[]"):
+		return a
+	}
+	return "interface{}"
+}
+
+// nonZero returns an expression testing whether expr, of Go type t, holds
+// other than its zero value.
+func nonZero(expr, t string) string {
+	switch {
+	case t == "bool":
+		return expr
+	case t == "string":
+		return expr + ` != ""`
+	case t == "int64" || t == "float64":
+		return expr + " != 0"
+	}
+	return expr + " != nil"
+}
+
+// goName converts a label, relationship type or property key to an exported
+// Go identifier: "FRIEND_OF" becomes FriendOf and "created-at" CreatedAt.
+func goName(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b bytes.Buffer
+	for _, p := range parts {
+		if strings.ToUpper(p) == p {
+			p = strings.ToLower(p)
+		}
+		r, n := utf8.DecodeRuneInString(p)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(p[n:])
+	}
+	name := b.String()
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) {
+		name = "X" + name
+	}
+	return name
+}
+
+// goNames returns prefix plus the Go name of each of names, numbering any
+// which would otherwise collide.
+func goNames(prefix string, names []string) []string {
+	seen := map[string]bool{}
+	res := make([]string, len(names))
+	for i, s := range names {
+		name := prefix + goName(s)
+		for n := 2; seen[name]; n++ {
+			name = prefix + goName(s) + strconv.Itoa(n)
+		}
+		seen[name] = true
+		res[i] = name
+	}
+	return res
+}
+
+// consts writes a block of string constants.
+func consts(b *bytes.Buffer, doc, prefix string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "// %s\nconst (\n", doc)
+	for i, name := range goNames(prefix, values) {
+		fmt.Fprintf(b, "%s = %q\n", name, values[i])
+	}
+	b.WriteString(")\n\n")
+}
+
+// generate returns the formatted source of package pkg, declaring constants
+// for every label, relationship type and property key of s, and a struct for
+// the properties of each label.
+func generate(pkg string, s *schema) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by neo4jgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	labels := []string{}
+	for _, l := range s.Labels {
+		if len(s.Props[l]) > 0 {
+			labels = append(labels, l)
+		}
+	}
+	if len(labels) > 0 {
+		b.WriteString("import (\n\"encoding/json\"\n\"github.com/jmcvetta/neo4j\"\n)\n\n")
+	}
+	consts(&b, "Node labels.", "Label", s.Labels)
+	consts(&b, "Relationship types.", "Rel", s.RelTypes)
+	consts(&b, "Property keys.", "Prop", s.PropertyKeys)
+	structs := goNames("", labels)
+	for i, l := range labels {
+		name := structs[i]
+		keys := []string{}
+		for k := range s.Props[l] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fields := goNames("", keys)
+		fmt.Fprintf(&b, "// %s holds the properties of a node labelled %s.\n", name, l)
+		fmt.Fprintf(&b, "type %s struct {\n", name)
+		for j, k := range keys {
+			fmt.Fprintf(&b, "%s %s `json:%q`\n", fields[j], s.Props[l][k], k)
+		}
+		b.WriteString("}\n\n")
+		fmt.Fprintf(&b, "// Props returns the properties of x, for creating or updating a node.\n")
+		fmt.Fprintf(&b, "// Fields holding their zero value are omitted.\n")
+		fmt.Fprintf(&b, "func (x *%s) Props() neo4j.Props {\np := neo4j.Props{}\n", name)
+		for j, k := range keys {
+			fmt.Fprintf(&b, "if %s {\np[%q] = x.%s\n}\n", nonZero("x."+fields[j], s.Props[l][k]), k, fields[j])
+		}
+		b.WriteString("return p\n}\n\n")
+		fmt.Fprintf(&b, "// Load%s reads the properties of n.\n", name)
+		fmt.Fprintf(&b, "func Load%s(n *neo4j.Node) (*%s, error) {\n", name, name)
+		fmt.Fprintf(&b, "x := &%s{}\n", name)
+		b.WriteString("data, err := json.Marshal(n.Data)\nif err != nil {\nreturn nil, err\n}\n")
+		b.WriteString("err = json.Unmarshal(data, x)\nif err != nil {\nreturn nil, err\n}\nreturn x, nil\n}\n\n")
+	}
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package main
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"strings"
+	"testing"
+)
+
+func TestGoName(t *testing.T) {
+	for in, exp := range map[string]string{
+		"Person":     "Person",
+		"FRIEND_OF":  "FriendOf",
+		"created-at": "CreatedAt",
+		"firstName":  "FirstName",
+		"2fa":        "X2fa",
+		"":           "X",
+	} {
+		assert.Equal(t, exp, goName(in), in)
+	}
+	assert.Equal(t, []string{"PropAB", "PropAB2"}, goNames("Prop", []string{"a_b", "a-b"}))
+}
+
+func TestGoType(t *testing.T) {
+	assert.Equal(t, "int64", goType(json.Number("3")))
+	assert.Equal(t, "float64", goType(json.Number("3.0")))
+	assert.Equal(t, "float64", goType(json.Number("3e2")))
+	assert.Equal(t, "float64", goType(float64(3)))
+	assert.Equal(t, "float64", goType(3.5))
+	assert.Equal(t, "[]int64", goType([]interface{}{json.Number("1"), json.Number("2")}))
+	assert.Equal(t, "[]string", goType([]interface{}{"a", "b"}))
+	assert.Equal(t, "[]interface{}", goType([]interface{}{}))
+	assert.Equal(t, "float64", mergeTypes("int64", "float64"))
+	assert.Equal(t, "[]string", mergeTypes("[]interface{}", "[]string"))
+	assert.Equal(t, "interface{}", mergeTypes("string", "bool"))
+}
+
+func TestGenerate(t *testing.T) {
+	s := newSchema()
+	s.Labels = []string{"Person", "Tag"}
+	s.RelTypes = []string{"FRIEND_OF"}
+	s.PropertyKeys = []string{"age", "name"}
+	s.observe("Person", map[string]interface{}{"name": "alice", "age": json.Number("30")})
+	s.observe("Person", map[string]interface{}{"name": "bob", "age": json.Number("30.5")})
+	src, err := generate("model", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package model\n",
+		"\tLabelPerson = \"Person\"\n",
+		"\tLabelTag    = \"Tag\"\n",
+		"\tRelFriendOf = \"FRIEND_OF\"\n",
+		"\tPropAge  = \"age\"\n",
+		"type Person struct {\n\tAge  float64 `json:\"age\"`\n\tName string  `json:\"name\"`\n}\n",
+		"\tp := neo4j.Props{}\n\tif x.Age != 0 {\n\t\tp[\"age\"] = x.Age\n\t}\n",
+		"\tif x.Name != \"\" {\n\t\tp[\"name\"] = x.Name\n\t}\n",
+		"func LoadPerson(n *neo4j.Node) (*Person, error) {\n",
+	} {
+		assert.Tf(t, strings.Contains(string(src), want), "missing %q in\n%s", want, src)
+	}
+	assert.T(t, !strings.Contains(string(src), "type Tag "))
+}
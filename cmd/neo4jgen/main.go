@@ -0,0 +1,135 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+// Command neo4jgen generates Go code from the schema of a live Neo4j
+// database, so that application code can refer to its labels, relationship
+// types and property keys by name rather than by string literal.
+//
+// Usage:
+//
+//	neo4jgen [flags]
+//
+// The generated package declares a constant for every label (LabelPerson),
+// relationship type (RelFriendOf) and property key (PropName) in use.  For
+// each label it also declares a struct holding the properties found on nodes
+// with that label, whose Props method returns them for CreateNode or
+// SetProperties, and a function such as LoadPerson which reads them from a
+// node, omitting fields which hold their zero value.  Property types are
+// inferred from a sample of each label's nodes: numbers become int64 only if
+// every sampled value is an integer, and properties whose values differ in
+// type become interface{}.
+//
+// Flags:
+//
+//	-url string   server URL (default $NEO4J_URL, or http://localhost:7474/db/data)
+//	-pkg string   name of the generated package (default "schema")
+//	-o string     file to write (default stdout)
+//	-sample int   nodes of each label examined for property types (default 100)
+//
+// Run it from a go:generate directive to keep the code in step with the
+// database:
+//
+//	//go:generate neo4jgen -pkg model -o schema.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/jmcvetta/neo4j"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "neo4jgen:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args, introspects the database and writes the generated code.
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("neo4jgen", flag.ContinueOnError)
+	url := fs.String("url", neo4j.DefaultUrl(), "server URL")
+	pkg := fs.String("pkg", "schema", "name of the generated package")
+	out := fs.String("o", "", "file to write")
+	sample := fs.Int("sample", 100, "nodes of each label examined for property types")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	db, err := neo4j.Connect(*url)
+	if err != nil {
+		return err
+	}
+	s, err := introspect(db, *sample)
+	if err != nil {
+		return err
+	}
+	src, err := generate(*pkg, s)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(*out, src, 0644)
+}
+
+// introspect reads the schema of db, examining up to sample nodes of each
+// label for the types of their properties.
+func introspect(db *neo4j.Database, sample int) (*schema, error) {
+	s := newSchema()
+	var err error
+	s.Labels, err = db.Labels()
+	if err != nil {
+		return nil, err
+	}
+	s.RelTypes, err = db.RelTypes()
+	if err != nil {
+		return nil, err
+	}
+	s.PropertyKeys, err = db.PropertyKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range s.Labels {
+		res := []struct {
+			N struct {
+				Data json.RawMessage `json:"data"`
+			} `json:"n"`
+		}{}
+		cq := neo4j.CypherQuery{
+			Statement:  "MATCH (n:`" + strings.Replace(l, "`", "``", -1) + "`) WITH n LIMIT {sample} RETURN n",
+			Parameters: map[string]interface{}{"sample": sample},
+			Result:     &res,
+		}
+		err := db.Cypher(&cq)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			// Decode numbers as written, so integers can be told
+			// from whole floats.
+			props := map[string]interface{}{}
+			dec := json.NewDecoder(bytes.NewReader(r.N.Data))
+			dec.UseNumber()
+			err := dec.Decode(&props)
+			if err != nil {
+				return nil, err
+			}
+			s.observe(l, props)
+		}
+	}
+	return s, nil
+}
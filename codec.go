@@ -0,0 +1,62 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/jmcvetta/restclient"
+)
+
+// A Codec encodes and decodes JSON.  Assigning a Codec to Database.Codec
+// replaces encoding/json for request and response bodies and Cypher results,
+// for applications whose profiles show JSON handling dominating CPU.  Any
+// codec compatible with encoding/json - honouring its struct tags and
+// json.RawMessage - may be used, such as jsoniter's
+// ConfigCompatibleWithStandardLibrary.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// stdCodec is the default Codec, backed by encoding/json.
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (stdCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// codec returns the Codec used by db.
+func (db *Database) codec() Codec {
+	if db.Codec != nil {
+		return db.Codec
+	}
+	return stdCodec{}
+}
+
+// doCodec executes rr with its body encoded, and its result decoded, by
+// db.Codec.  The restclient only copies the pre-encoded bytes, so the costly
+// reflection is done by the codec.
+func (db *Database) doCodec(rr *restclient.RequestResponse) (status int, err error) {
+	data, result := rr.Data, rr.Result
+	defer func() {
+		rr.Data, rr.Result = data, result
+	}()
+	if data != nil {
+		b, err := db.Codec.Marshal(data)
+		if err != nil {
+			return 0, err
+		}
+		raw := json.RawMessage(b)
+		rr.Data = &raw
+	}
+	var raw json.RawMessage
+	if result != nil {
+		rr.Result = &raw
+	}
+	status, err = db.Rc.Do(rr)
+	if err == nil && result != nil && len(raw) > 0 {
+		err = db.Codec.Unmarshal(raw, result)
+	}
+	return status, err
+}
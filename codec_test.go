@@ -0,0 +1,79 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+// countingCodec is encoding/json, counting its calls.
+type countingCodec struct {
+	marshals   int
+	unmarshals int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return json.Unmarshal(data, v)
+}
+
+func TestCypherQueryUnmarshalCodec(t *testing.T) {
+	c := &countingCodec{}
+	raw := json.RawMessage(`"kirk"`)
+	cq := CypherQuery{
+		cr: cypherResult{
+			Columns: []string{"name"},
+			Data:    [][]*json.RawMessage{{&raw}},
+		},
+		codec: c,
+	}
+	res := []struct {
+		Name string `json:"name"`
+	}{}
+	err := cq.Unmarshal(&res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk", res[0].Name)
+	assert.Equal(t, 0, c.marshals)
+	assert.Equal(t, 1, c.unmarshals)
+}
+
+func TestCodec(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	c := &countingCodec{}
+	db.Codec = c
+	defer func() { db.Codec = nil }()
+	n0, err := db.CreateNode(Props{"name": "kirk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk", n0.Data["name"])
+	assert.Equal(t, 1, c.marshals)
+	assert.Equal(t, 1, c.unmarshals)
+	res := []struct {
+		Name string `json:"name"`
+	}{}
+	cq := CypherQuery{
+		Statement:  "START n=node({id}) RETURN n.name AS name",
+		Parameters: Props{"id": n0.Id()},
+		Result:     &res,
+	}
+	err = db.Cypher(&cq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk", res[0].Name)
+	assert.Equal(t, 2, c.marshals)
+	assert.Equal(t, 3, c.unmarshals)
+}
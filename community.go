@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// LabelPropagation detects communities in g, ignoring edge direction, and
+// returns the community ID of every node.  Each node starts in a community
+// of its own, identified by its node ID, then repeatedly joins the community
+// most common among its neighbours, until no node changes or maxIterations is
+// reached.  A node stays put if its community is among the most common;
+// other ties are broken at random using r.  If r is nil, nodes are visited in
+// ascending ID order and ties go to the lowest ID, which is deterministic but
+// tends to merge neighbouring communities; otherwise the visiting order is
+// shuffled too.
+func (g *Graph) LabelPropagation(maxIterations int, r *rand.Rand) map[int]int {
+	ids := g.Nodes()
+	community := make(map[int]int, len(ids))
+	for _, id := range ids {
+		community[id] = id
+	}
+	for i := 0; i < maxIterations; i++ {
+		if r != nil {
+			for j, k := range r.Perm(len(ids)) {
+				ids[j], ids[k] = ids[k], ids[j]
+			}
+		}
+		changed := false
+		for _, id := range ids {
+			counts := map[int]int{}
+			for _, m := range g.out[id] {
+				counts[community[m]]++
+			}
+			for _, m := range g.in[id] {
+				counts[community[m]]++
+			}
+			// Candidates are sorted so that results depend only on r.
+			candidates := make([]int, 0, len(counts))
+			for c := range counts {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			best, bestCount, ties := community[id], 0, 0
+			for _, c := range candidates {
+				n := counts[c]
+				switch {
+				case n > bestCount:
+					best, bestCount, ties = c, n, 1
+				case n < bestCount:
+				case r == nil:
+					if c < best {
+						best = c
+					}
+				default:
+					// Each of the tied communities is equally likely.
+					ties++
+					if r.Intn(ties) == 0 {
+						best = c
+					}
+				}
+			}
+			if bestCount > 0 && counts[community[id]] < bestCount {
+				community[id] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return community
+}
+
+// WriteCommunities stores community IDs, keyed by node ID, as property prop of
+// the nodes - for instance the results of LabelPropagation.
+func (db *Database) WriteCommunities(prop string, communities map[int]int) error {
+	values := make(map[int]interface{}, len(communities))
+	for id, c := range communities {
+		values[id] = c
+	}
+	return db.setNodeValues(prop, values)
+}
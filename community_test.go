@@ -0,0 +1,59 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"math/rand"
+	"testing"
+)
+
+// twoCliques returns two complete graphs, on nodes 1-4 and 5-8, joined by
+// the edge 4 -> 5.
+func twoCliques() *Graph {
+	g := NewGraph()
+	for _, base := range []int{1, 5} {
+		for i := base; i < base+4; i++ {
+			for j := i + 1; j < base+4; j++ {
+				g.AddEdge(i, j)
+			}
+		}
+	}
+	g.AddEdge(4, 5)
+	return g
+}
+
+func TestLabelPropagation(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		c := twoCliques().LabelPropagation(20, rand.New(rand.NewSource(seed)))
+		for i := 2; i <= 4; i++ {
+			assert.Equal(t, c[1], c[i])
+			assert.Equal(t, c[5], c[i+4])
+		}
+		assert.NotEqual(t, c[1], c[5])
+	}
+	g := twoCliques()
+	g.AddNode(9)
+	c := g.LabelPropagation(20, nil)
+	assert.Equal(t, 9, c[9])
+}
+
+func TestWriteCommunities(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	a, _ := db.CreateNode(Props{})
+	b, _ := db.CreateNode(Props{})
+	a.AddLabel("Person")
+	b.AddLabel("Person")
+	a.Relate("knows", b.Id(), nil)
+	g, _ := db.ExtractGraph("Person", "knows")
+	err := db.WriteCommunities("community", g.LabelPropagation(10, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pa, _ := a.Properties()
+	pb, _ := b.Properties()
+	assert.Equal(t, pa["community"], pb["community"])
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// EnableCompression makes db ask the server for gzip-compressed responses,
+// which greatly shrinks large Cypher results.  If minSize is greater than
+// zero, request bodies of at least minSize bytes - typically big batch and
+// import payloads - are also gzip-compressed.  The stock Neo4j server does
+// not accept compressed request bodies, so only set minSize if the server, or
+// a proxy in front of it, does.
+func (db *Database) EnableCompression(minSize int) {
+	if db.Rc.HttpClient == nil {
+		db.Rc.HttpClient = new(http.Client)
+	}
+	c := *db.Rc.HttpClient
+	c.Transport = &gzipTransport{base: c.Transport, minSize: minSize}
+	db.Rc.HttpClient = &c
+}
+
+// A gzipTransport compresses request bodies and decompresses responses.
+type gzipTransport struct {
+	base    http.RoundTripper // Nil means http.DefaultTransport
+	minSize int               // Smallest request body to compress; 0 disables
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := *req // RoundTrippers must not modify the request
+	r.Header = make(http.Header, len(req.Header)+2)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	if r.Header.Get("Accept-Encoding") == "" {
+		r.Header.Set("Accept-Encoding", "gzip")
+	}
+	if t.minSize > 0 && r.Body != nil && r.ContentLength >= int64(t.minSize) && r.Header.Get("Content-Encoding") == "" {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := io.Copy(zw, r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		zipped := buf.Bytes()
+		r.Body = ioutil.NopCloser(bytes.NewReader(zipped))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(zipped)), nil
+		}
+		r.ContentLength = int64(len(zipped))
+		r.Header.Set("Content-Encoding", "gzip")
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(&r)
+	if err != nil {
+		return resp, err
+	}
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		resp.Body = &gzipBody{zr: zr, body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+	}
+	return resp, nil
+}
+
+// A gzipBody decompresses a response body, closing the underlying body when
+// it is closed.
+type gzipBody struct {
+	zr   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) { return b.zr.Read(p) }
+
+func (b *gzipBody) Close() error {
+	b.zr.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"compress/gzip"
+	"github.com/bmizerany/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGzipTransport(t *testing.T) {
+	body := strings.Repeat(`{"name":"kirk"}`, 100)
+	var mu sync.Mutex
+	accepted := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		accepted = append(accepted, r.Header.Get("Accept-Encoding"))
+		mu.Unlock()
+		var b []byte
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			b, _ = ioutil.ReadAll(zr)
+		} else {
+			b, _ = ioutil.ReadAll(r.Body)
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(r.Header.Get("Content-Encoding") + ":" + string(b)))
+		zw.Close()
+	}))
+	defer ts.Close()
+	c := &http.Client{Transport: &gzipTransport{minSize: 100}}
+	post := func(s string) string {
+		resp, err := c.Post(ts.URL, "application/json", strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	// Large bodies are compressed, small ones are not
+	assert.Equal(t, "gzip:"+body, post(body))
+	assert.Equal(t, ":{}", post("{}"))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"gzip", "gzip"}, accepted)
+}
+
+// A recordingTransport records the last request it was asked to send.
+type recordingTransport struct {
+	req *http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.req = req
+	return &http.Response{StatusCode: 200, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestGzipTransportGetBody(t *testing.T) {
+	rt := &recordingTransport{}
+	body := strings.Repeat(`{"name":"kirk"}`, 100)
+	req, err := http.NewRequest("POST", "http://localhost:7474/db/data/batch", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&gzipTransport{base: rt, minSize: 100}).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent, _ := ioutil.ReadAll(rt.req.Body)
+	assert.Equal(t, int64(len(sent)), rt.req.ContentLength)
+	// A retried request must resend the compressed body
+	rc, err := rt.req.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := ioutil.ReadAll(rc)
+	assert.Equal(t, sent, again)
+}
+
+func TestEnableCompression(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	db.EnableCompression(0)
+	n0, err := db.CreateNode(Props{"name": "kirk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1, err := db.Node(n0.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk", n1.Data["name"])
+}
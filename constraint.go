@@ -0,0 +1,94 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/jmcvetta/restclient"
+)
+
+// A Constraint is a uniqueness constraint on a property of nodes with a given
+// label.  Creating a constraint also creates an index on the property.
+type Constraint struct {
+	db           *Database
+	Label        string   `json:"label"`
+	Type         string   `json:"type"`
+	PropertyKeys []string `json:"property_keys"`
+}
+
+// Drop removes the constraint.
+func (c *Constraint) Drop() error {
+	url := join(c.db.hrefConstraints(), PathEscape(c.Label), "uniqueness", PathEscape(c.PropertyKeys[0]))
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    url,
+		Method: "DELETE",
+		Error:  &ne,
+	}
+	status, err := c.db.do(&rr)
+	if err != nil {
+		return err
+	}
+	if status == 404 {
+		return NotFound
+	}
+	if status != 204 {
+		return ne
+	}
+	return nil
+}
+
+// CreateUniqueConstraint requires that no two nodes with label have the same
+// value of property.  It fails if existing nodes already violate it.
+func (db *Database) CreateUniqueConstraint(label, property string) (*Constraint, error) {
+	url := join(db.hrefConstraints(), PathEscape(label), "uniqueness")
+	payload := indexRequest{[]string{property}}
+	ne := NeoError{}
+	res := Constraint{db: db}
+	rr := restclient.RequestResponse{
+		Url:    url,
+		Method: "POST",
+		Data:   payload,
+		Result: &res,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return nil, err
+	}
+	if status == 404 {
+		return nil, NotFound
+	}
+	if status != 200 {
+		return nil, ne
+	}
+	return &res, nil
+}
+
+// Constraints lists constraints for a label.
+func (db *Database) Constraints(label string) ([]*Constraint, error) {
+	url := join(db.hrefConstraints(), PathEscape(label))
+	ne := NeoError{}
+	res := []*Constraint{}
+	rr := restclient.RequestResponse{
+		Url:    url,
+		Method: "GET",
+		Result: &res,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return res, err
+	}
+	if status == 404 {
+		return res, NotFound
+	}
+	if status != 200 {
+		return res, ne
+	}
+	for _, c := range res {
+		c.db = db
+	}
+	return res, nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestUniqueConstraint(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	prop := rndStr(t)
+	c, err := db.CreateUniqueConstraint(label, prop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, label, c.Label)
+	assert.Equal(t, []string{prop}, c.PropertyKeys)
+	cs, err := db.Constraints(label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*Constraint{c}, cs)
+	// Two nodes with the same value violate the constraint
+	node := "(:" + quoteIdent(label) + " {" + quoteIdent(prop) + ": {v}})"
+	cq := CypherQuery{
+		Statement:  "CREATE " + node + ", " + node,
+		Parameters: Props{"v": "same"},
+	}
+	assert.NotEqual(t, nil, db.Cypher(&cq))
+	err = c.Drop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, nil, db.Cypher(&cq))
+	assert.Equal(t, NotFound, c.Drop())
+}
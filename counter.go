@@ -0,0 +1,153 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strings"
+)
+
+// A Counter keeps denormalized counts of relationships of one type on the
+// nodes they join - for example the followerCount and followingCount of
+// users related by FOLLOWS - so that the counts can be read without
+// traversing the relationships.  Each count is updated in the same statement
+// as the relationship is created or deleted, so it cannot drift, provided
+// every such relationship is created and deleted through the Counter.
+// Creating or deleting a relationship locks both its nodes, so concurrent
+// updates are not lost.
+type Counter struct {
+	db      *Database
+	RelType string
+	OutProp string // Count of outgoing relationships, kept on start nodes; empty for none
+	InProp  string // Count of incoming relationships, kept on end nodes; empty for none
+}
+
+// Counter returns a Counter of relationships of relType, kept in property
+// outProp of their start nodes and inProp of their end nodes.  Either
+// property may be empty, to keep no such count.
+func (db *Database) Counter(relType, outProp, inProp string) *Counter {
+	return &Counter{db: db, RelType: relType, OutProp: outProp, InProp: inProp}
+}
+
+// adjust returns a SET clause adding delta to the counts of a and b.
+func (c *Counter) adjust(delta string) string {
+	sets := ""
+	for _, s := range []struct{ node, prop string }{{"a", c.OutProp}, {"b", c.InProp}} {
+		if s.prop == "" {
+			continue
+		}
+		if sets == "" {
+			sets = " SET "
+		} else {
+			sets += ", "
+		}
+		p := s.node + "." + quoteIdent(s.prop)
+		sets += p + " = coalesce(" + p + ", 0) " + delta
+	}
+	return sets
+}
+
+// RelateQuery returns a statement creating a relationship, with properties
+// p, from the node with ID start to the node with ID end, and incrementing
+// their counts.  It returns the relationship's ID in column id.  The
+// statement may be executed in a transaction along with other changes.
+func (c *Counter) RelateQuery(start, end int, p Props) *CypherQuery {
+	if p == nil {
+		p = Props{}
+	}
+	return &CypherQuery{
+		Statement: "START a=node({start}), b=node({end}) CREATE (a)-[r:" + quoteIdent(c.RelType) + " {props}]->(b)" +
+			c.adjust("+ 1") + " RETURN id(r) AS id",
+		Parameters: Props{"start": start, "end": end, "props": p},
+	}
+}
+
+// UnrelateQuery returns a statement deleting the relationship with ID rel,
+// and decrementing the counts of its nodes.  It returns the number of
+// relationships deleted in column count.  The statement may be executed in a
+// transaction along with other changes.
+func (c *Counter) UnrelateQuery(rel int) *CypherQuery {
+	return &CypherQuery{
+		Statement: "START r=rel({rel}) MATCH (a)-[r:" + quoteIdent(c.RelType) + "]->(b) DELETE r WITH a, b" +
+			c.adjust("- 1") + " RETURN count(*) AS count",
+		Parameters: Props{"rel": rel},
+	}
+}
+
+// Relate creates a relationship, with properties p, from the node with ID
+// start to the node with ID end, updating their counts, and returns its ID.
+func (c *Counter) Relate(start, end int, p Props) (int, error) {
+	err := c.db.Vocabulary.CheckRelTypes(c.RelType)
+	if err != nil {
+		return 0, err
+	}
+	res := []struct {
+		Id int `json:"id"`
+	}{}
+	cq := c.RelateQuery(start, end, p)
+	cq.Result = &res
+	err = c.db.Cypher(cq)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, NotFound
+	}
+	return res[0].Id, nil
+}
+
+// Unrelate deletes the relationship with ID rel, updating the counts of its
+// nodes.  It returns NotFound if rel is not a relationship of the counter's
+// type.
+func (c *Counter) Unrelate(rel int) error {
+	cq := c.UnrelateQuery(rel)
+	n, err := c.db.cypherCount(cq.Statement, cq.Parameters)
+	if err == nil && n == 0 {
+		err = NotFound
+	}
+	return err
+}
+
+// Recount recomputes every count from the relationships themselves, to
+// initialize the counts of existing relationships or to repair counts after
+// relationships were changed other than through the Counter.  Only nodes at
+// either end of a relationship of the type are counted, along with nodes
+// holding a nonzero count, whose relationships must have been deleted; all
+// counts are recomputed by one statement, so no count is seen reset.
+func (c *Counter) Recount() error {
+	stmt := c.recountStatement()
+	if stmt == "" {
+		return nil
+	}
+	return c.db.Cypher(&CypherQuery{Statement: stmt})
+}
+
+// recountStatement returns the statement recomputing the counts, or an empty
+// string if there are none.
+func (c *Counter) recountStatement() string {
+	if c.OutProp == "" && c.InProp == "" {
+		return ""
+	}
+	typ := quoteIdent(c.RelType)
+	where := []string{"(n)-[:" + typ + "]-()"}
+	for _, prop := range []string{c.OutProp, c.InProp} {
+		if prop != "" {
+			p := "n." + quoteIdent(prop)
+			where = append(where, "(has("+p+") AND "+p+" <> 0)")
+		}
+	}
+	stmt := "MATCH (n) WHERE " + strings.Join(where, " OR ") + " "
+	with := "n"
+	sets := []string{}
+	if c.OutProp != "" {
+		stmt += "OPTIONAL MATCH (n)-[o:" + typ + "]->() WITH " + with + ", count(o) AS outCount "
+		with += ", outCount"
+		sets = append(sets, "n."+quoteIdent(c.OutProp)+" = outCount")
+	}
+	if c.InProp != "" {
+		stmt += "OPTIONAL MATCH (n)<-[i:" + typ + "]-() WITH " + with + ", count(i) AS inCount "
+		sets = append(sets, "n."+quoteIdent(c.InProp)+" = inCount")
+	}
+	return stmt + "SET " + strings.Join(sets, ", ")
+}
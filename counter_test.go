@@ -0,0 +1,101 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestCounterQueries(t *testing.T) {
+	db := &Database{}
+	c := db.Counter("FOLLOWS", "followingCount", "followerCount")
+	assert.Equal(t, "START a=node({start}), b=node({end}) CREATE (a)-[r:`FOLLOWS` {props}]->(b) "+
+		"SET a.`followingCount` = coalesce(a.`followingCount`, 0) + 1, b.`followerCount` = coalesce(b.`followerCount`, 0) + 1 "+
+		"RETURN id(r) AS id", c.RelateQuery(1, 2, nil).Statement)
+	c.OutProp = ""
+	assert.Equal(t, "START r=rel({rel}) MATCH (a)-[r:`FOLLOWS`]->(b) DELETE r WITH a, b "+
+		"SET b.`followerCount` = coalesce(b.`followerCount`, 0) - 1 RETURN count(*) AS count",
+		c.UnrelateQuery(3).Statement)
+	c.InProp = ""
+	assert.Equal(t, "START r=rel({rel}) MATCH (a)-[r:`FOLLOWS`]->(b) DELETE r WITH a, b RETURN count(*) AS count",
+		c.UnrelateQuery(3).Statement)
+	assert.Equal(t, "", c.recountStatement())
+	c = db.Counter("FOLLOWS", "followingCount", "followerCount")
+	assert.Equal(t, "MATCH (n) WHERE (n)-[:`FOLLOWS`]-() "+
+		"OR (has(n.`followingCount`) AND n.`followingCount` <> 0) OR (has(n.`followerCount`) AND n.`followerCount` <> 0) "+
+		"OPTIONAL MATCH (n)-[o:`FOLLOWS`]->() WITH n, count(o) AS outCount "+
+		"OPTIONAL MATCH (n)<-[i:`FOLLOWS`]-() WITH n, outCount, count(i) AS inCount "+
+		"SET n.`followingCount` = outCount, n.`followerCount` = inCount", c.recountStatement())
+}
+
+func TestCounter(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	c := db.Counter("FOLLOWS", "followingCount", "followerCount")
+	alice, _ := db.CreateNode(Props{"name": "alice"})
+	bob, _ := db.CreateNode(Props{"name": "bob"})
+	carol, _ := db.CreateNode(Props{"name": "carol"})
+	dave, _ := db.CreateNode(Props{"name": "dave"})
+	r1, err := c.Relate(alice.Id(), carol.Id(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Relate(bob.Id(), carol.Id(), Props{"since": 2013})
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ := carol.Properties()
+	assert.Equal(t, Props{"name": "carol", "followerCount": 2.0}, props)
+	props, _ = alice.Properties()
+	assert.Equal(t, Props{"name": "alice", "followingCount": 1.0}, props)
+	err = c.Unrelate(r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, NotFound, c.Unrelate(r1))
+	props, _ = carol.Properties()
+	assert.Equal(t, 1.0, props["followerCount"])
+	props, _ = alice.Properties()
+	assert.Equal(t, 0.0, props["followingCount"])
+	//
+	// Within a transaction, alongside other changes
+	//
+	tx, err := db.Begin([]*CypherQuery{
+		c.RelateQuery(alice.Id(), bob.Id(), nil),
+		&CypherQuery{Statement: "START n=node({id}) SET n.active = true", Parameters: Props{"id": alice.Id()}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	props, _ = bob.Properties()
+	assert.Equal(t, 1.0, props["followerCount"])
+	//
+	// Repair after an uncounted change
+	//
+	alice.Relate("FOLLOWS", carol.Id(), nil)
+	err = c.Recount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ = carol.Properties()
+	assert.Equal(t, 2.0, props["followerCount"])
+	props, _ = alice.Properties()
+	assert.Equal(t, 2.0, props["followingCount"])
+	// Nodes left without relationships of the type are reset
+	db.Cypher(&CypherQuery{
+		Statement:  "START n=node({id}) MATCH (n)-[r:FOLLOWS]-() DELETE r",
+		Parameters: Props{"id": carol.Id()},
+	})
+	err = c.Recount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ = carol.Properties()
+	assert.Equal(t, 0.0, props["followerCount"])
+	props, _ = dave.Properties()
+	assert.Equal(t, Props{"name": "dave"}, props)
+}
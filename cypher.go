@@ -5,9 +5,13 @@
 package neo4j
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
 	"github.com/jmcvetta/restclient"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
 )
 
 // A CypherQuery is a statement in the Cypher query language, with optional
@@ -18,7 +22,21 @@ type CypherQuery struct {
 	Statement  string                 `json:"statement"`
 	Parameters map[string]interface{} `json:"parameters"`
 	Result     interface{}            `json:"-"`
-	cr         cypherResult
+	// Timeout, if set, asks the server to abort the query once it has run
+	// this long, via the max-execution-time header.  The server must have
+	// execution_guard_enabled=true for this to take effect.  Statements
+	// sent together in a transaction or CypherBatch share the longest of their
+	// timeouts.
+	Timeout time.Duration `json:"-"`
+	// ResultDataContents, if set, selects the formats in which the
+	// transactional endpoint returns results: RowFormat, GraphFormat and/or
+	// RESTFormat.  Rows are decoded from the REST format if it is requested,
+	// and from the row format otherwise; the graph format is read with
+	// Graph.  It is ignored by Database.Cypher and CypherBatch.
+	ResultDataContents []string `json:"resultDataContents,omitempty"`
+	cr                 cypherResult
+	codec              Codec     // Codec of the Database which executed the query
+	db                 *Database // Database which executed the query
 }
 
 // Columns returns the names, in order, of the columns returned for this query.
@@ -30,9 +48,76 @@ func (cq *CypherQuery) Columns() []string {
 // Unmarshal decodes result data into v, which must be a pointer to a slice of
 // structs - e.g. &[]someStruct{}.  Struct fields are matched up with fields
 // returned by the cypher query using the `json:"fieldName"` tag.
+//
+// Nodes, relationships and paths in the result are bound to the Database
+// which executed the query, so they can be used straight away; those decoded
+// into interface{} fields become *Node, *Relationship and *Path, as they do
+// with Rows.
+//
+// Each row is decoded straight into its element of the slice, reusing the
+// slice's backing array if it has capacity for every row, so callers
+// decoding many results may save allocations by passing the same slice each
+// time.  Elements are zeroed before decoding.
 func (cq *CypherQuery) Unmarshal(v interface{}) error {
-	// We do a round-trip thru the JSON marshaller.  A fairly simple way to
-	// do type-safe unmarshalling, but perhaps not the most efficient solution.
+	c := cq.codec
+	if c == nil {
+		c = stdCodec{}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return cq.unmarshalRoundTrip(c, v)
+	}
+	slice := rv.Elem()
+	n := len(cq.cr.Data)
+	if slice.Cap() < n {
+		slice.Set(reflect.MakeSlice(slice.Type(), n, n))
+	} else {
+		slice.SetLen(n)
+	}
+	// Each row is rewritten as a JSON object, {"column": value, ...}, in a
+	// buffer reused from row to row.
+	keys := make([][]byte, len(cq.cr.Columns))
+	for i, name := range cq.cr.Columns {
+		b, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		keys[i] = append(b, ':')
+	}
+	var buf bytes.Buffer
+	zero := reflect.Zero(slice.Type().Elem())
+	for rowNum, row := range cq.cr.Data {
+		buf.Reset()
+		buf.WriteByte('{')
+		for colNum, col := range row {
+			if colNum >= len(keys) {
+				break
+			}
+			if colNum > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[colNum])
+			if col == nil {
+				buf.WriteString("null")
+			} else {
+				buf.Write(*col)
+			}
+		}
+		buf.WriteByte('}')
+		elem := slice.Index(rowNum)
+		elem.Set(zero)
+		err := c.Unmarshal(buf.Bytes(), elem.Addr().Interface())
+		if err != nil {
+			return err
+		}
+		cq.bind(elem)
+	}
+	return nil
+}
+
+// unmarshalRoundTrip decodes result data into a v which is not a pointer to a
+// slice, by re-encoding the whole result as an array of objects.
+func (cq *CypherQuery) unmarshalRoundTrip(c Codec, v interface{}) error {
 	rs := make([]map[string]*json.RawMessage, len(cq.cr.Data))
 	for rowNum, row := range cq.cr.Data {
 		m := map[string]*json.RawMessage{}
@@ -42,12 +127,44 @@ func (cq *CypherQuery) Unmarshal(v interface{}) error {
 		}
 		rs[rowNum] = m
 	}
-	b, err := json.MarshalIndent(rs, "", "  ")
+	b, err := c.Marshal(rs)
 	if err != nil {
 		logPretty(err)
 		return err
 	}
-	return json.Unmarshal(b, v)
+	err = c.Unmarshal(b, v)
+	if err != nil {
+		return err
+	}
+	cq.bind(reflect.ValueOf(v))
+	return nil
+}
+
+// cypherNodes executes a Cypher statement returning a single column of nodes,
+// and returns those nodes bound to db.
+func (db *Database) cypherNodes(stmt string, params Props) ([]*Node, error) {
+	cq := CypherQuery{
+		Statement:  stmt,
+		Parameters: params,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*Node, 0, len(cq.cr.Data))
+	for _, row := range cq.cr.Data {
+		if len(row) == 0 || row[0] == nil {
+			continue
+		}
+		n := Node{}
+		err := db.codec().Unmarshal(*row[0], &n)
+		if err != nil {
+			return nil, err
+		}
+		n.Db = db
+		nodes = append(nodes, &n)
+	}
+	return nodes, nil
 }
 
 type cypherRequest struct {
@@ -58,12 +175,71 @@ type cypherRequest struct {
 type cypherResult struct {
 	Columns []string
 	Data    [][]*json.RawMessage
+	Graphs  []*ResultGraph // Per row, if the graph format was requested
+}
+
+// UnmarshalJSON decodes the results of both the legacy Cypher endpoint, whose
+// rows are arrays, and of the transactional endpoint, whose rows are objects
+// holding each format requested by CypherQuery.ResultDataContents.
+func (cr *cypherResult) UnmarshalJSON(b []byte) error {
+	raw := struct {
+		Columns []string
+		Data    []json.RawMessage
+	}{}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+	cr.Columns = raw.Columns
+	cr.Data = make([][]*json.RawMessage, len(raw.Data))
+	cr.Graphs = nil
+	for i, d := range raw.Data {
+		var graph *ResultGraph
+		cr.Data[i], graph, err = decodeRow(d)
+		if err != nil {
+			return err
+		}
+		if graph != nil {
+			if cr.Graphs == nil {
+				cr.Graphs = make([]*ResultGraph, len(raw.Data))
+			}
+			cr.Graphs[i] = graph
+		}
+	}
+	return nil
+}
+
+// decodeRow decodes a single row of results, either a legacy array or a
+// transactional object, returning its cells and graph, if any.
+func decodeRow(d json.RawMessage) ([]*json.RawMessage, *ResultGraph, error) {
+	if len(d) > 0 && d[0] == '[' {
+		var cells []*json.RawMessage
+		err := json.Unmarshal(d, &cells)
+		return cells, nil, err
+	}
+	row := struct {
+		Row   []*json.RawMessage `json:"row"`
+		Rest  []*json.RawMessage `json:"rest"`
+		Graph *ResultGraph       `json:"graph"`
+	}{}
+	err := json.Unmarshal(d, &row)
+	if err != nil {
+		return nil, nil, err
+	}
+	if row.Rest != nil {
+		return row.Rest, row.Graph, nil
+	}
+	return row.Row, row.Graph, nil
 }
 
 // Cypher executes a db query written in the Cypher language.  Data returned
 // from the db is used to populate `result`, which should be a pointer to a
 // slice of structs.  TODO:  Or a pointer to a two-dimensional array of structs?
 func (db *Database) Cypher(q *CypherQuery) error {
+	err := db.lint(q)
+	if err != nil {
+		return err
+	}
 	cRes := cypherResult{}
 	cReq := cypherRequest{
 		Query:      q.Statement,
@@ -76,8 +252,9 @@ func (db *Database) Cypher(q *CypherQuery) error {
 		Data:   &cReq,
 		Result: &cRes,
 		Error:  ne,
+		Header: timeoutHeader([]*CypherQuery{q}),
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -86,23 +263,28 @@ func (db *Database) Cypher(q *CypherQuery) error {
 		return *ne
 	}
 	q.cr = cRes
+	q.codec = db.Codec
+	q.db = db
 	if q.Result != nil {
-		q.Unmarshal(q.Result)
+		return q.Unmarshal(q.Result)
 	}
 	return nil
 }
 
-type batchCypherQuery struct {
-	Method string        `json:"method"`
-	To     string        `json:"to"`
-	Id     int           `json:"id"`
-	Body   cypherRequest `json:"body"`
-}
-
-type batchCypherResponse struct {
-	Id       int
-	Location string
-	Body     cypherResult
+// timeoutHeader returns the max-execution-time header for the longest
+// Timeout among qs, or nil if none has a Timeout.
+func timeoutHeader(qs []*CypherQuery) *http.Header {
+	var max time.Duration
+	for _, q := range qs {
+		if q.Timeout > max {
+			max = q.Timeout
+		}
+	}
+	if max <= 0 {
+		return nil
+	}
+	ms := (max + time.Millisecond - 1) / time.Millisecond
+	return &http.Header{"Max-Execution-Time": {strconv.FormatInt(int64(ms), 10)}}
 }
 
 // CypherBatch executes a set of cypher queries as a batch.  When using the
@@ -110,11 +292,15 @@ type batchCypherResponse struct {
 // strings in subsequent job descriptions, CypherQuery's batch id will be its
 // index in the slice.
 func (db *Database) CypherBatch(qs []*CypherQuery) error {
-	payload := make([]batchCypherQuery, len(qs))
+	err := db.lint(qs...)
+	if err != nil {
+		return err
+	}
+	jobs := make([]*batchJob, len(qs))
 	for i, q := range qs {
-		payload[i] = batchCypherQuery{
+		jobs[i] = &batchJob{
 			Method: "POST",
-			To:     "/cypher",
+			To:     db.relPath(db.HrefCypher),
 			Id:     i,
 			Body: cypherRequest{
 				Query:      q.Statement,
@@ -122,28 +308,17 @@ func (db *Database) CypherBatch(qs []*CypherQuery) error {
 			},
 		}
 	}
-	res := []batchCypherResponse{}
-	ne := NeoError{}
-	rr := restclient.RequestResponse{
-		Url:    db.HrefBatch,
-		Method: "POST",
-		Data:   payload,
-		Result: &res,
-		Error:  &ne,
-	}
-	status, err := db.Rc.Do(&rr)
+	res, err := db.batchWith(jobs, timeoutHeader(qs))
 	if err != nil {
 		return err
 	}
-	if status != 200 {
-		logPretty(ne)
-		return ne
-	}
-	if len(res) != len(qs) {
-		return errors.New("Result count does not match query count")
-	}
 	for i, s := range qs {
-		s.cr = res[i].Body
+		err := db.codec().Unmarshal(res[i].Body, &s.cr)
+		if err != nil {
+			return err
+		}
+		s.codec = db.Codec
+		s.db = db
 		if s.Result != nil {
 			err := s.Unmarshal(s.Result)
 			if err != nil {
@@ -5,11 +5,106 @@
 package neo4j
 
 import (
+	"encoding/json"
 	"github.com/bmizerany/assert"
+	"net/http"
 	"strconv"
 	"testing"
+	"time"
 )
 
+// syntheticResult returns a Cypher result of rows rows, with a string, number
+// and null column.
+func syntheticResult(rows int) cypherResult {
+	cr := cypherResult{Columns: []string{"name", "n.age", "missing"}}
+	cr.Data = make([][]*json.RawMessage, rows)
+	for i := range cr.Data {
+		name := json.RawMessage(strconv.Quote("name" + strconv.Itoa(i)))
+		age := json.RawMessage(strconv.Itoa(i))
+		cr.Data[i] = []*json.RawMessage{&name, &age, nil}
+	}
+	return cr
+}
+
+type syntheticRow struct {
+	Name    string  `json:"name"`
+	Age     int     `json:"n.age"`
+	Missing *string `json:"missing"`
+}
+
+func TestCypherQueryUnmarshal(t *testing.T) {
+	cq := CypherQuery{cr: syntheticResult(3)}
+	res := []syntheticRow{}
+	err := cq.Unmarshal(&res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(res))
+	assert.Equal(t, syntheticRow{Name: "name2", Age: 2}, res[2])
+	//
+	// A slice with capacity is reused, and stale values are cleared
+	//
+	s := "stale"
+	buf := make([]syntheticRow, 5)
+	buf[0].Missing = &s
+	err = cq.Unmarshal(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(buf))
+	assert.Equal(t, 5, cap(buf))
+	assert.Equal(t, syntheticRow{Name: "name0", Age: 0}, buf[0])
+	//
+	// Other destinations are still supported
+	//
+	maps := []map[string]interface{}{}
+	err = cq.Unmarshal(&maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "name1", maps[1]["name"])
+	var iface interface{}
+	err = cq.Unmarshal(&iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(iface.([]interface{})))
+}
+
+func TestTimeoutHeader(t *testing.T) {
+	q0 := &CypherQuery{}
+	q1 := &CypherQuery{Timeout: 1500 * time.Microsecond}
+	q2 := &CypherQuery{Timeout: time.Second}
+	assert.Equal(t, (*http.Header)(nil), timeoutHeader([]*CypherQuery{q0}))
+	assert.Equal(t, "2", timeoutHeader([]*CypherQuery{q0, q1}).Get("max-execution-time"))
+	assert.Equal(t, "1000", timeoutHeader([]*CypherQuery{q1, q2}).Get("max-execution-time"))
+}
+
+func TestCypherTimeout(t *testing.T) {
+	ct := &countingTransport{}
+	db, err := ConnectWithOptions("http://localhost:7474/db/data", &ConnectOptions{Transport: ct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cq := CypherQuery{Statement: "RETURN 1", Timeout: 5 * time.Second}
+	err = db.Cypher(&cq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5000", ct.header.Get("max-execution-time"))
+	tx, err := db.Begin([]*CypherQuery{&cq})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5000", ct.header.Get("max-execution-time"))
+	tx.Rollback()
+	err = db.CypherBatch([]*CypherQuery{&CypherQuery{Statement: "RETURN 1"}, &cq})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "5000", ct.header.Get("max-execution-time"))
+}
+
 // 18.3.1. Send queries with parameters
 func TestCypherParameters(t *testing.T) {
 	var db *Database
@@ -28,7 +28,15 @@ package neo4j
 import (
 	"github.com/bmizerany/assert"
 	"github.com/jmcvetta/randutil"
+	"io"
+	"io/ioutil"
 	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -96,3 +104,99 @@ func TestConnectInvalidUrl(t *testing.T) {
 	_, err = Connect("http://localhost:7474")
 	assert.Equal(t, InvalidDatabase, err)
 }
+
+// countingTransport counts the requests passing through it, and records the
+// headers of the last one.
+type countingTransport struct {
+	n      int
+	header http.Header
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.n++
+	ct.header = req.Header
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestConnectWithOptions(t *testing.T) {
+	ct := &countingTransport{}
+	db, err := ConnectWithOptions("http://localhost:7474/db/data", &ConnectOptions{Transport: ct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, ct.n)
+	_, err = db.CreateNode(Props{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, ct.n)
+	cleanup(t, db)
+	//
+	// A whole client takes precedence over a transport
+	//
+	ct2 := &countingTransport{}
+	_, err = ConnectWithOptions("http://localhost:7474/db/data", &ConnectOptions{
+		HttpClient: &http.Client{Transport: ct2},
+		Transport:  ct,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, ct2.n)
+}
+
+func TestUnixDialer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "neo4j")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "neo4j.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host+r.URL.Path)
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+	opts := &ConnectOptions{Dial: UnixDialer(sock)}
+	client, _ := opts.httpClient()
+	resp, err := client.Get("http://neo4j.local/db/data/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "neo4j.local/db/data/", string(b))
+	client, err = new(ConnectOptions).httpClient()
+	assert.Equal(t, (*http.Client)(nil), client)
+}
+
+func TestProxyUrl(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := (&http.Request{Header: http.Header{
+			"Authorization": r.Header["Proxy-Authorization"],
+		}}).BasicAuth()
+		io.WriteString(w, user+":"+pass+" "+r.URL.String())
+	}))
+	defer proxy.Close()
+	u, _ := url.Parse(proxy.URL)
+	u.User = url.UserPassword("alice", "s3cret")
+	opts := &ConnectOptions{ProxyUrl: u.String()}
+	client, err := opts.httpClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://neo4j.internal:7474/db/data/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "alice:s3cret http://neo4j.internal:7474/db/data/", string(b))
+	_, err = (&ConnectOptions{ProxyUrl: "%zz"}).httpClient()
+	assert.NotEqual(t, nil, err)
+}
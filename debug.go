@@ -0,0 +1,109 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/jmcvetta/restclient"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces sensitive values in debug logs.
+const redacted = "[REDACTED]"
+
+// logRequest logs the method, URL, headers and body of rr, with credentials
+// and sensitive properties masked.
+func (db *Database) logRequest(rr *restclient.RequestResponse) {
+	log.Printf("neo4j: --> %s %s", rr.Method, redactUrl(rr.Url))
+	if rr.Userinfo != nil {
+		log.Printf("neo4j: --> Authorization: %s", redacted)
+	}
+	if rr.Header != nil {
+		for key, values := range *rr.Header {
+			v := strings.Join(values, ", ")
+			if strings.EqualFold(key, "Authorization") || strings.EqualFold(key, "Proxy-Authorization") {
+				v = redacted
+			}
+			log.Printf("neo4j: --> %s: %s", key, v)
+		}
+	}
+	if rr.Data != nil {
+		b, err := json.Marshal(rr.Data)
+		if err != nil {
+			log.Printf("neo4j: --> (unencodable body: %s)", err)
+			return
+		}
+		log.Printf("neo4j: --> %s", db.redact(b))
+	}
+}
+
+// logResponse logs the status and body of the server's response to rr.
+func (db *Database) logResponse(rr *restclient.RequestResponse, status int, err error) {
+	if err != nil {
+		log.Printf("neo4j: <-- %s %s [%s]: %s", rr.Method, redactUrl(rr.Url), requestId(rr), err)
+		return
+	}
+	log.Printf("neo4j: <-- %d %s %s [%s]", status, rr.Method, redactUrl(rr.Url), requestId(rr))
+	if rr.RawText != "" {
+		log.Printf("neo4j: <-- %s", db.redact([]byte(rr.RawText)))
+	}
+}
+
+// redactUrl returns u with any username and password removed.
+func redactUrl(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.User == nil {
+		return u
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
+// redact returns JSON document b with the values of any properties named in
+// db.RedactProps masked.  Documents that cannot be parsed are returned as-is.
+func (db *Database) redact(b []byte) string {
+	if len(db.RedactProps) == 0 {
+		return string(b)
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	v = redactValue(v, db.RedactProps)
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(b)
+	}
+	return string(out)
+}
+
+// redactValue recursively masks map entries whose keys appear in keys.  Key
+// comparison is case-insensitive.
+func redactValue(v interface{}, keys []string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			masked := false
+			for _, key := range keys {
+				if strings.EqualFold(k, key) {
+					masked = true
+					break
+				}
+			}
+			if masked {
+				t[k] = redacted
+			} else {
+				t[k] = redactValue(val, keys)
+			}
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactValue(val, keys)
+		}
+	}
+	return v
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strconv"
+)
+
+// DefaultDeletionBatch is the number of deletions made per batch request by
+// a DeletionPlan whose BatchSize is not set.
+const DefaultDeletionBatch = 500
+
+// An IndexedNode is the entry of a node in a legacy node index.
+type IndexedNode struct {
+	Index string
+	Node  int
+}
+
+// A DeletionPlan lists everything which must be removed to delete a set of
+// nodes, in an order the server will accept: first their relationships, then
+// their legacy index entries, and finally the nodes themselves.
+type DeletionPlan struct {
+	db            *Database
+	Relationships []int
+	IndexEntries  []IndexedNode
+	Nodes         []int
+	BatchSize     int // Deletions per batch request
+}
+
+// A DeletionReport counts what was removed by a DeletionPlan.
+type DeletionReport struct {
+	Relationships int
+	IndexEntries  int
+	Nodes         int
+}
+
+// PlanDeletion plans the deletion of the nodes with the given IDs, finding
+// their relationships and legacy node index entries.  Nothing is deleted
+// until the plan is executed.
+func (db *Database) PlanDeletion(nodeIds []int) (*DeletionPlan, error) {
+	p := &DeletionPlan{db: db, Nodes: nodeIds, BatchSize: DefaultDeletionBatch}
+	if len(nodeIds) == 0 {
+		return p, nil
+	}
+	res := []struct {
+		Id int `json:"id"`
+	}{}
+	cq := CypherQuery{
+		Statement:  "START n=node({ids}) MATCH (n)-[r]-() RETURN DISTINCT id(r) AS id ORDER BY id",
+		Parameters: map[string]interface{}{"ids": nodeIds},
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range res {
+		p.Relationships = append(p.Relationships, r.Id)
+	}
+	indexes, err := db.LegacyNodeIndexes()
+	if err != nil && err != NotFound {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		res = res[:0]
+		cq := CypherQuery{
+			Statement: "START n=node:" + quoteIdent(idx.Name) + `("*:*") ` +
+				"WHERE id(n) IN {ids} RETURN DISTINCT id(n) AS id ORDER BY id",
+			Parameters: map[string]interface{}{"ids": nodeIds},
+			Result:     &res,
+		}
+		err := db.Cypher(&cq)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			p.IndexEntries = append(p.IndexEntries, IndexedNode{Index: idx.Name, Node: r.Id})
+		}
+	}
+	return p, nil
+}
+
+// jobs returns the batch jobs executing the plan, in order.
+func (p *DeletionPlan) jobs() []*batchJob {
+	db := p.db
+	jobs := []*batchJob{}
+	for _, id := range p.Relationships {
+		jobs = append(jobs, &batchJob{Method: "DELETE", To: db.relPath(db.hrefRelationship(id))})
+	}
+	for _, e := range p.IndexEntries {
+		to := join(db.HrefNodeIndex, PathEscape(e.Index), strconv.Itoa(e.Node))
+		jobs = append(jobs, &batchJob{Method: "DELETE", To: db.relPath(to)})
+	}
+	for _, id := range p.Nodes {
+		jobs = append(jobs, &batchJob{Method: "DELETE", To: db.relPath(join(db.HrefNode, strconv.Itoa(id)))})
+	}
+	return jobs
+}
+
+// Execute carries out the plan, BatchSize deletions at a time, or fewer if
+// the Database's batch limits require it.  Each batch request succeeds or
+// fails as a whole; on failure the report counts what was removed by the
+// earlier batches.  Hooks registered with OnNodeDeleted and
+// OnRelationshipDeleted are called, with unfetched handles, for what each
+// batch deleted.
+func (p *DeletionPlan) Execute() (*DeletionReport, error) {
+	size := p.BatchSize
+	if size < 1 {
+		size = DefaultDeletionBatch
+	}
+	rep := &DeletionReport{}
+	jobs := p.jobs()
+	for i, j := range jobs {
+		j.Id = i
+	}
+	for start := 0; start < len(jobs); start += size {
+		end := start + size
+		if end > len(jobs) {
+			end = len(jobs)
+		}
+		chunks, err := p.db.chunkBatch(jobs[start:end])
+		if err != nil {
+			return rep, err
+		}
+		for _, c := range chunks {
+			_, err := p.db.sendBatch(c)
+			if err != nil {
+				return rep, err
+			}
+			p.record(rep, c[0].Id, c[0].Id+len(c))
+		}
+	}
+	return rep, nil
+}
+
+// record adds jobs start to end of the plan, once executed, to the report,
+// and calls the hooks for the relationships and nodes they deleted.
+func (p *DeletionPlan) record(rep *DeletionReport, start, end int) {
+	rels, entries := len(p.Relationships), len(p.IndexEntries)
+	for i := start; i < end; i++ {
+		switch {
+		case i < rels:
+			rep.Relationships++
+			r := &Relationship{}
+			r.Db = p.db
+			r.HrefSelf = p.db.hrefRelationship(p.Relationships[i])
+			p.db.relDeleted(r)
+		case i < rels+entries:
+			rep.IndexEntries++
+		default:
+			rep.Nodes++
+			p.db.nodeDeleted(p.db.NodeHandle(p.Nodes[i-rels-entries]))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestDeletionPlanJobs(t *testing.T) {
+	db := &Database{
+		Url:           "http://localhost:7474/db/data",
+		HrefNode:      "http://localhost:7474/db/data/node",
+		HrefNodeIndex: "http://localhost:7474/db/data/index/node",
+	}
+	p := DeletionPlan{
+		db:            db,
+		Relationships: []int{7},
+		IndexEntries:  []IndexedNode{{"my idx", 1}},
+		Nodes:         []int{1, 2},
+	}
+	to := []string{}
+	for _, j := range p.jobs() {
+		assert.Equal(t, "DELETE", j.Method)
+		to = append(to, j.To)
+	}
+	assert.Equal(t, []string{"/relationship/7", "/index/node/my%20idx/1", "/node/1", "/node/2"}, to)
+	rep := DeletionReport{}
+	p.record(&rep, 0, 2)
+	p.record(&rep, 2, 4)
+	assert.Equal(t, DeletionReport{Relationships: 1, IndexEntries: 1, Nodes: 2}, rep)
+}
+
+func TestDeletionPlanExecute(t *testing.T) {
+	sizes := []int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jobs := []batchJob{}
+		json.NewDecoder(r.Body).Decode(&jobs)
+		sizes = append(sizes, len(jobs))
+		res := []batchResponse{}
+		for _, j := range jobs {
+			res = append(res, batchResponse{Id: j.Id, Status: 204})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs: map[string]string{
+			"batch":      srv.URL + "/db/data/batch",
+			"node":       srv.URL + "/db/data/node",
+			"node_index": srv.URL + "/db/data/index/node",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := []string{}
+	db.OnNodeDeleted(func(n *Node) { deleted = append(deleted, "node "+strconv.Itoa(n.Id())) })
+	db.OnRelationshipDeleted(func(r *Relationship) { deleted = append(deleted, "rel "+strconv.Itoa(r.Id())) })
+	db.BatchMaxJobs = 2
+	p := DeletionPlan{
+		db:            db,
+		Relationships: []int{7},
+		IndexEntries:  []IndexedNode{{"people", 1}},
+		Nodes:         []int{1, 2},
+		BatchSize:     3,
+	}
+	rep, err := p.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, DeletionReport{Relationships: 1, IndexEntries: 1, Nodes: 2}, *rep)
+	assert.Equal(t, []int{2, 1, 1}, sizes)
+	assert.Equal(t, []string{"rel 7", "node 1", "node 2"}, deleted)
+}
+
+func TestPlanDeletion(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	n2, _ := db.CreateNode(Props{})
+	n0.Relate("knows", n1.Id(), nil)
+	n1.Relate("knows", n2.Id(), nil)
+	idx, _ := db.CreateLegacyNodeIndex(rndStr(t), "", "")
+	defer idx.Delete()
+	idx.Add(n0, "name", "n0")
+	p, err := db.PlanDeletion([]int{n0.Id(), n1.Id()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(p.Relationships))
+	assert.Equal(t, []IndexedNode{{idx.Name, n0.Id()}}, p.IndexEntries)
+	p.BatchSize = 2
+	rep, err := p.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, DeletionReport{Relationships: 2, IndexEntries: 1, Nodes: 2}, *rep)
+	ok, _ := db.NodeExists(n1.Id())
+	assert.T(t, !ok)
+	ok, _ = db.NodeExists(n2.Id())
+	assert.T(t, ok)
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"fmt"
+	"github.com/jmcvetta/restclient"
+	"io"
+	"net/url"
+	"sort"
+)
+
+// A DanglingEntry is a legacy index entry for a node or relationship which
+// has been deleted.
+type DanglingEntry struct {
+	Index        string
+	Id           int
+	Relationship bool // Entry is in a relationship index
+}
+
+// A DoctorReport lists the problems found by Doctor.
+type DoctorReport struct {
+	DanglingEntries []DanglingEntry
+	Duplicates      []Violation // Values shared by nodes which should be unique
+	UnindexedLabels []string    // Labels with neither schema index nor constraint
+}
+
+// Healthy reports whether no problems were found.
+func (r *DoctorReport) Healthy() bool {
+	return len(r.DanglingEntries) == 0 && len(r.Duplicates) == 0 && len(r.UnindexedLabels) == 0
+}
+
+// WriteText writes the report in human readable form.
+func (r *DoctorReport) WriteText(w io.Writer) error {
+	if r.Healthy() {
+		_, err := fmt.Fprintln(w, "No problems found.")
+		return err
+	}
+	for _, e := range r.DanglingEntries {
+		kind := "node"
+		if e.Relationship {
+			kind = "relationship"
+		}
+		_, err := fmt.Fprintf(w, "dangling entry: index %s refers to deleted %s %d\n", e.Index, kind, e.Id)
+		if err != nil {
+			return err
+		}
+	}
+	for _, v := range r.Duplicates {
+		_, err := fmt.Fprintf(w, "duplicate: %s value %v shared by nodes %v\n", v.Check, v.Value, v.Ids)
+		if err != nil {
+			return err
+		}
+	}
+	for _, l := range r.UnindexedLabels {
+		_, err := fmt.Fprintf(w, "unindexed label: %s\n", l)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Doctor checks the database for common problems: legacy index entries
+// referring to deleted nodes or relationships, duplicated values of
+// properties with a uniqueness constraint or named in unique, and labels
+// with neither a schema index nor a constraint.
+func (db *Database) Doctor(unique ...UniqueCheck) (*DoctorReport, error) {
+	rep := &DoctorReport{
+		DanglingEntries: []DanglingEntry{},
+		Duplicates:      []Violation{},
+		UnindexedLabels: []string{},
+	}
+	indexes := []*index{}
+	nis, err := db.LegacyNodeIndexes()
+	if err != nil && err != NotFound {
+		return nil, err
+	}
+	for _, ni := range nis {
+		indexes = append(indexes, &ni.index)
+	}
+	ris, err := db.LegacyRelIndexes()
+	if err != nil && err != NotFound {
+		return nil, err
+	}
+	for _, ri := range ris {
+		indexes = append(indexes, &ri.index)
+	}
+	for _, idx := range indexes {
+		ids, err := idx.danglingIds()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			rep.DanglingEntries = append(rep.DanglingEntries, DanglingEntry{Index: idx.Name, Id: id, Relationship: idx.isRel()})
+		}
+	}
+	checks := []Check{}
+	for _, c := range unique {
+		checks = append(checks, c)
+	}
+	labels, err := db.Labels()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		cs, err := db.Constraints(l)
+		if err != nil && err != NotFound {
+			return nil, err
+		}
+		for _, c := range cs {
+			if c.Type == "UNIQUENESS" && len(c.PropertyKeys) == 1 {
+				checks = append(checks, UniqueCheck{Label: l, Property: c.PropertyKeys[0]})
+			}
+		}
+		is, err := db.Indexes(l)
+		if err != nil && err != NotFound {
+			return nil, err
+		}
+		if len(cs) == 0 && len(is) == 0 {
+			rep.UnindexedLabels = append(rep.UnindexedLabels, l)
+		}
+	}
+	vs, err := db.Validate(checks...)
+	if err != nil {
+		return nil, err
+	}
+	rep.Duplicates = vs
+	return rep, nil
+}
+
+// isRel reports whether idx is a relationship index.
+func (idx *index) isRel() bool {
+	return idx.HrefIndex == idx.db.HrefRelIndex
+}
+
+// entryIds returns the IDs of the nodes or relationships in the index.
+func (idx *index) entryIds() ([]int, error) {
+	uri, err := idx.uri()
+	if err != nil {
+		return nil, err
+	}
+	res := []struct {
+		Self string `json:"self"`
+	}{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    uri + "?" + url.Values{"query": {"*:*"}}.Encode(),
+		Method: "GET",
+		Result: &res,
+		Error:  &ne,
+	}
+	status, err := idx.db.do(&rr)
+	if err != nil {
+		return nil, err
+	}
+	if status != 200 {
+		logPretty(ne)
+		return nil, ne
+	}
+	ids := []int{}
+	seen := map[int]bool{}
+	for _, e := range res {
+		id, err := idFromHref(e.Self)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// danglingIds returns the IDs of deleted nodes or relationships which are
+// still in the index, in ascending order.
+func (idx *index) danglingIds() ([]int, error) {
+	ids, err := idx.entryIds()
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
+	stmt := "MATCH (e) WHERE id(e) IN {ids} RETURN id(e) AS id"
+	if idx.isRel() {
+		stmt = "MATCH ()-[e]->() WHERE id(e) IN {ids} RETURN id(e) AS id"
+	}
+	res := []struct {
+		Id int `json:"id"`
+	}{}
+	cq := CypherQuery{
+		Statement:  stmt,
+		Parameters: map[string]interface{}{"ids": ids},
+		Result:     &res,
+	}
+	err = idx.db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	live := map[int]bool{}
+	for _, r := range res {
+		live[r.Id] = true
+	}
+	dangling := []int{}
+	for _, id := range ids {
+		if !live[id] {
+			dangling = append(dangling, id)
+		}
+	}
+	sort.Ints(dangling)
+	return dangling, nil
+}
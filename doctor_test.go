@@ -0,0 +1,73 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"bytes"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestDoctorReport(t *testing.T) {
+	var buf bytes.Buffer
+	rep := DoctorReport{}
+	assert.T(t, rep.Healthy())
+	rep.WriteText(&buf)
+	assert.Equal(t, "No problems found.\n", buf.String())
+	rep.DanglingEntries = []DanglingEntry{{Index: "people", Id: 7}}
+	rep.Duplicates = []Violation{{Check: "unique :Person.name", Ids: []int{1, 2}, Value: "alice"}}
+	rep.UnindexedLabels = []string{"Place"}
+	assert.T(t, !rep.Healthy())
+	buf.Reset()
+	rep.WriteText(&buf)
+	exp := "dangling entry: index people refers to deleted node 7\n" +
+		"duplicate: unique :Person.name value alice shared by nodes [1 2]\n" +
+		"unindexed label: Place\n"
+	assert.Equal(t, exp, buf.String())
+}
+
+func TestDoctor(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	n0, _ := db.CreateNode(Props{"name": "alice"})
+	n1, _ := db.CreateNode(Props{"name": "alice"})
+	n0.AddLabel(label)
+	n1.AddLabel(label)
+	idx, _ := db.CreateLegacyNodeIndex(rndStr(t), "", "")
+	defer idx.Delete()
+	idx.Add(n0, "name", "alice")
+	// An entry left behind by deleting its node out of band
+	n2, _ := db.CreateNode(Props{"name": "carol"})
+	idx.Add(n2, "name", "carol")
+	err := db.Cypher(&CypherQuery{
+		Statement:  "MATCH (n) WHERE id(n) = {id} DELETE n",
+		Parameters: Props{"id": n2.Id()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := db.Doctor(UniqueCheck{Label: label, Property: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.T(t, !rep.Healthy())
+	assert.T(t, len(rep.Duplicates) > 0)
+	found := false
+	for _, l := range rep.UnindexedLabels {
+		found = found || l == label
+	}
+	assert.T(t, found)
+	found = false
+	for _, e := range rep.DanglingEntries {
+		found = found || e == DanglingEntry{Index: idx.Name, Id: n2.Id()}
+	}
+	assert.T(t, found)
+	ids, err := idx.danglingIds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int{n2.Id()}, ids)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/jmcvetta/restclient"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// In dry run mode each write request is logged and answered with
+// DryRunSkipped instead of being sent, while reads are sent as usual.  Since
+// nothing was written, a call which writes and then uses the result - such as
+// Import, a CypherStream, or CreateNode followed by Relate - stops at its
+// first write, so only that write is logged.  To validate such a job, dry run
+// each of its steps separately.
+
+// writeClause matches Cypher clauses which modify the graph or schema.
+var writeClause = regexp.MustCompile(`(?i)\b(CREATE|MERGE|SET|DELETE|REMOVE|DROP|FOREACH)\b`)
+
+// cypherNoise matches the parts of a Cypher statement which may contain
+// clause keywords without being clauses: string literals, quoted identifiers,
+// comments, property accessors, map keys, labels and relationship types.
+var cypherNoise = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|` + "`[^`]*`" +
+	`|//[^\n]*|\.\s*[A-Za-z_]\w*|(?:[A-Za-z_]\w*\s*)?:(?:\s*[A-Za-z_]\w*)?`)
+
+// isWriteStatement reports whether a Cypher statement contains a writing
+// clause.
+func isWriteStatement(stmt string) bool {
+	return writeClause.MatchString(cypherNoise.ReplaceAllString(stmt, " "))
+}
+
+// isWrite reports whether rr would modify the database.  Requests to the
+// Cypher, transaction and batch endpoints are writes if any statement they
+// carry contains a writing clause, or any batch job uses a method other than
+// GET.  Committing or rolling back a transaction is not itself a write.
+func (db *Database) isWrite(rr *restclient.RequestResponse) bool {
+	endpoint := func(href string) bool {
+		return href != "" && strings.HasPrefix(rr.Url, href)
+	}
+	switch {
+	case endpoint(db.HrefCypher), endpoint(db.HrefTransaction), endpoint(db.HrefBatch):
+		if rr.Data == nil {
+			return false
+		}
+		b, err := json.Marshal(rr.Data)
+		if err != nil {
+			return true
+		}
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return true
+		}
+		return db.containsWrite(v)
+	case rr.Method == "GET" || rr.Method == "HEAD":
+		return false
+	}
+	return true
+}
+
+// containsWrite searches a decoded request body for writing Cypher statements
+// or batch jobs.  A batch job posting to the Cypher endpoint is judged by its
+// statement.
+func (db *Database) containsWrite(v interface{}) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			s, isString := val.(string)
+			switch {
+			case isString && (k == "query" || k == "statement"):
+				if isWriteStatement(s) {
+					return true
+				}
+			case isString && k == "method":
+				if s != "GET" {
+					to, _ := t["to"].(string)
+					if s != "POST" || db.HrefCypher == "" || to != db.relPath(db.HrefCypher) {
+						return true
+					}
+				}
+			case db.containsWrite(val):
+				return true
+			}
+		}
+	case []interface{}:
+		for _, val := range t {
+			if db.containsWrite(val) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipWrite logs a write request which will not be sent because the database
+// is in dry run mode.
+func (db *Database) skipWrite(rr *restclient.RequestResponse) {
+	body := ""
+	if rr.Data != nil {
+		b, err := json.Marshal(rr.Data)
+		if err == nil {
+			body = " " + db.redact(b)
+		}
+	}
+	log.Printf("neo4j: dry run: %s %s%s", rr.Method, redactUrl(rr.Url), body)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/restclient"
+	"testing"
+)
+
+func TestIsWrite(t *testing.T) {
+	db := &Database{
+		Url:             "http://localhost:7474/db/data",
+		HrefNode:        "http://localhost:7474/db/data/node",
+		HrefCypher:      "http://localhost:7474/db/data/cypher",
+		HrefTransaction: "http://localhost:7474/db/data/transaction",
+		HrefBatch:       "http://localhost:7474/db/data/batch",
+	}
+	cases := []struct {
+		rr    restclient.RequestResponse
+		write bool
+	}{
+		{restclient.RequestResponse{Method: "GET", Url: db.HrefNode + "/1"}, false},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefNode, Data: Props{}}, true},
+		{restclient.RequestResponse{Method: "DELETE", Url: db.HrefNode + "/1"}, true},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefCypher,
+			Data: cypherRequest{Query: "MATCH (n) RETURN n"}}, false},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefCypher,
+			Data: cypherRequest{Query: "MATCH (n) SET n.x = 1"}}, true},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefTransaction + "/1/commit"}, false},
+		{restclient.RequestResponse{Method: "DELETE", Url: db.HrefTransaction + "/1"}, false},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefTransaction,
+			Data: txRequest{Statements: []*CypherQuery{&CypherQuery{Statement: "create (n)"}}}}, true},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefBatch,
+			Data: []*batchJob{&batchJob{Method: "GET", To: "/node/1"}}}, false},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefBatch,
+			Data: []*batchJob{&batchJob{Method: "DELETE", To: "/node/1"}}}, true},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefBatch,
+			Data: []*batchJob{&batchJob{Method: "POST", To: "/cypher",
+				Body: cypherRequest{Query: "MATCH (n) RETURN n"}}}}, false},
+		{restclient.RequestResponse{Method: "POST", Url: db.HrefBatch,
+			Data: []*batchJob{&batchJob{Method: "POST", To: "/index/node/cypher_idx",
+				Body: Props{"key": "k", "value": "v", "uri": "/node/1"}}}}, true},
+	}
+	for i, c := range cases {
+		assert.Equalf(t, c.write, db.isWrite(&c.rr), "case %d", i)
+	}
+}
+
+func TestIsWriteStatement(t *testing.T) {
+	cases := map[string]bool{
+		"MATCH (n) RETURN n":                              false,
+		"MATCH (n) RETURN n.set":                          false,
+		"MATCH (n) WHERE n.name = 'Create' RETURN n":      false,
+		`MATCH (n) WHERE n.name = "it's DELETE" RETURN n`: false,
+		"MATCH (n:Merge)-[:REMOVE]->(m) RETURN n":         false,
+		"MATCH (n {set: 1}) RETURN `drop`":                false,
+		"MATCH (n) RETURN n // then delete it":            false,
+		"MATCH (n) SET n.x = 1":                           true,
+		"match (n) detach delete n":                       true,
+		"MERGE (n:Person {name: 'Set'})":                  true,
+		"CREATE INDEX ON :Person(name)":                   true,
+	}
+	for stmt, write := range cases {
+		assert.Equalf(t, write, isWriteStatement(stmt), "%s", stmt)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{"name": "kirk"})
+	db.DryRun = true
+	defer func() { db.DryRun = false }()
+	_, err := db.CreateNode(Props{})
+	assert.Equal(t, DryRunSkipped, err)
+	err = n0.SetProperty("name", "spock")
+	assert.Equal(t, DryRunSkipped, err)
+	n1, err := db.Node(n0.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk", n1.Data["name"])
+}
@@ -20,13 +20,27 @@ type entity struct {
 }
 
 // do is a convenience wrapper around the embedded restclient's Do() method.
+// Writes are serialized by the Database's WriteCoordinator, if it has one.
 func (e *entity) do(rr *restclient.RequestResponse) (status int, err error) {
-	return e.Db.Rc.Do(rr)
+	if rr.Method == "GET" || e.Db.Writes == nil {
+		return e.Db.do(rr)
+	}
+	err = e.Db.Writes.Do([]string{e.Db.relPath(e.HrefSelf)}, func() error {
+		var err error
+		status, err = e.Db.do(rr)
+		return err
+	})
+	return status, err
 }
 
 // SetProperty sets the single property key to value.
 func (e *entity) SetProperty(key string, value string) error {
-	parts := []string{e.HrefProperties, key}
+	return e.setProperty(key, value)
+}
+
+// setProperty sets the single property key to a value of any type.
+func (e *entity) setProperty(key string, value interface{}) error {
+	parts := []string{e.HrefProperties, PathEscape(key)}
 	uri := strings.Join(parts, "/")
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
@@ -48,7 +62,7 @@ func (e *entity) SetProperty(key string, value string) error {
 // GetProperty fetches the value of property key.
 func (e *entity) Property(key string) (string, error) {
 	var val string
-	parts := []string{e.HrefProperties, key}
+	parts := []string{e.HrefProperties, PathEscape(key)}
 	uri := strings.Join(parts, "/")
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
@@ -73,7 +87,7 @@ func (e *entity) Property(key string) (string, error) {
 
 // DeleteProperty deletes property key
 func (e *entity) DeleteProperty(key string) error {
-	parts := []string{e.HrefProperties, key}
+	parts := []string{e.HrefProperties, PathEscape(key)}
 	uri := strings.Join(parts, "/")
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
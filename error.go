@@ -16,15 +16,123 @@ var (
 	CannotDelete    = errors.New("The node cannot be deleted. Check that the node is orphaned before deletion.")
 )
 
+// QueueFull is returned when a request cannot be queued because its
+// Scheduler's queue is full.
+var QueueFull = errors.New("Request queue is full.")
+
+// SchedulerClosed is returned when a request is made through a Scheduler
+// which has been closed.
+var SchedulerClosed = errors.New("Scheduler is closed.")
+
+// CircuitOpen is returned without contacting the server while a Database's
+// CircuitBreaker is open.
+var CircuitOpen = errors.New("Circuit breaker is open: server unavailable.")
+
+// DryRunSkipped is returned in place of sending a write request, when the
+// Database is in dry run mode.
+var DryRunSkipped = errors.New("Dry run: write request was not sent.")
+
+// BatchReferenceSplit is returned when a batch must be split into several
+// requests to respect Database.BatchMaxJobs or BatchMaxBytes, but jobs which
+// refer to one another by {N} cannot all be kept in the same request.
+var BatchReferenceSplit = errors.New("Batch too large: jobs referring to each other cannot be split across requests.")
+
+// BatchJobTooLarge is returned when a single batch job is larger than
+// Database.BatchMaxBytes, so that no request could respect it.
+var BatchJobTooLarge = errors.New("Batch job larger than BatchMaxBytes.")
+
+// InlineLiteral is returned, when Database.Lint is set, for a Cypher statement
+// which has parameters but also contains literal values.
+var InlineLiteral = errors.New("Statement has parameters, but also inline literals.")
+
+// Conflict is returned by SetPropertiesIfUnchanged when the node's properties
+// have been changed since they were read.
+var Conflict = errors.New("Properties have changed: update not applied.")
+
+// BatchExecuted is returned when Execute is called on a Batch which has
+// already been executed.
+var BatchExecuted = errors.New("Batch has already been executed.")
+
+// Unauthorized is returned when the server rejects the credentials given.
+var Unauthorized = errors.New("Authentication failed.  Check username and password.")
+
+// PasswordChangeRequired is returned on connecting to a server which refuses
+// requests until the user's password has been changed with ChangePassword.
+var PasswordChangeRequired = errors.New("Password change required.  Call ChangePassword.")
+
+// NoToken is returned by RotateToken when the server does not use token
+// authentication.
+var NoToken = errors.New("Server does not use token authentication.")
+
+// One of these errors is returned when a name is not registered in a strict
+// Vocabulary.
+var (
+	UnknownLabel   = errors.New("Label is not registered in the vocabulary.")
+	UnknownRelType = errors.New("Relationship type is not registered in the vocabulary.")
+)
+
+// One of these errors is returned when labels break the rules of a
+// Vocabulary's label groups.
+var (
+	UnknownLabelGroup = errors.New("Label group is not registered in the vocabulary.")
+	NotInLabelGroup   = errors.New("Label is not a member of the label group.")
+	ExclusiveLabels   = errors.New("Labels are mutually exclusive members of a label group.")
+)
+
+// LabelCycle is returned by RegisterSubLabel when a label would become its
+// own ancestor.
+var LabelCycle = errors.New("Label cannot be beneath itself in the taxonomy.")
+
 // A NeoError is populated by api calls when there is an error.
 type NeoError struct {
 	Message    string      `json:"message"`
 	Exception  string      `json:"exception"`
 	Stacktrace []string    `json:"stacktrace"`
 	Cause      interface{} `json:"cause"` // New in Neo4j 2.0
+	RequestId  string      `json:"-"`     // ID of the failed request
 }
 
-// Error returns the error message supplied by the server.
+// Error returns the error message supplied by the server, with the ID of the
+// failed request.
 func (ne NeoError) Error() string {
-	return ne.Message
+	if ne.RequestId == "" {
+		return ne.Message
+	}
+	return ne.Message + " (request " + ne.RequestId + ")"
 }
+
+// InvalidSchemaType is returned by SchemaFor when given a value which is not
+// a named struct, or a field with an unknown neo4j tag.
+var InvalidSchemaType = errors.New("SchemaFor requires named structs tagged with neo4j:\"index\" or neo4j:\"unique\".")
+
+// MissingKey is returned by BulkMerge when a row lacks the key property.
+var MissingKey = errors.New("Row has no value for the key property.")
+
+// NoMembers is returned when no member of a Cluster can be reached.
+var NoMembers = errors.New("No member of the cluster can be reached.")
+
+// NoMaster is returned for a write to a Cluster which has no available master,
+// as during a failover.
+var NoMaster = errors.New("Cluster has no available master.")
+
+// IndexTimeout is returned by WaitFor when an index entry does not become
+// visible in time.
+var IndexTimeout = errors.New("Timed out waiting for index entry.")
+
+// NoTransform is returned by a Backfill run with neither Set nor Transform.
+var NoTransform = errors.New("Backfill has neither a Set clause nor a Transform.")
+
+// LockHeld is returned by Lock when the lock is held by someone else.
+var LockHeld = errors.New("Lock is held by someone else.")
+
+// LockLost is returned by Refresh and Unlock when the lock is no longer held.
+var LockLost = errors.New("Lock is no longer held.")
+
+// UnknownSeedNode is returned by Commit when a relationship joins a node not
+// described by the same GraphBuilder, and by Fixture.Load when a relationship
+// joins a node not in the fixture.
+var UnknownSeedNode = errors.New("Relationship joins an unknown node.")
+
+// MissingSalt is returned when an Anonymizer is given properties to
+// anonymize but no salt, which would let pseudonyms be reversed by guessing.
+var MissingSalt = errors.New("Anonymizer has properties to anonymize but no salt.")
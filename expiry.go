@@ -0,0 +1,116 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultExpiryProperty is the property holding a node's expiry time when
+// none is given to SweepExpired or NewSweeper.
+const DefaultExpiryProperty = "expiresAt"
+
+// DefaultSweepInterval is the interval between sweeps of a Sweeper created
+// with an interval which is not positive.
+const DefaultSweepInterval = time.Minute
+
+// sweepBatch is the maximum number of nodes deleted by a single request.
+const sweepBatch = 500
+
+// SweepExpired deletes the nodes with label whose property prop - a time in
+// milliseconds since the epoch, as returned by Cypher's timestamp() - has
+// passed by the server's clock, along with their relationships.  Nodes are
+// deleted in batches, and the number deleted is returned.  Hooks registered
+// with OnNodeDeleted and OnRelationshipDeleted are not called.
+func (db *Database) SweepExpired(label, prop string) (int, error) {
+	if prop == "" {
+		prop = DefaultExpiryProperty
+	}
+	p := "n." + quoteIdent(prop)
+	stmt := "MATCH (n:" + quoteIdent(label) + ") WHERE has(" + p + ") AND " + p + " <= timestamp() " +
+		"WITH n LIMIT {limit} OPTIONAL MATCH (n)-[r]-() DELETE r, n RETURN count(DISTINCT n) AS count"
+	total := 0
+	for {
+		count, err := db.cypherCount(stmt, Props{"limit": sweepBatch})
+		total += count
+		if err != nil || count < sweepBatch {
+			return total, err
+		}
+	}
+}
+
+// A Sweeper deletes expired nodes with a label, as by SweepExpired, in a
+// background goroutine.  A failed sweep is logged and retried at the next
+// interval.
+type Sweeper struct {
+	db       *Database
+	label    string
+	prop     string
+	interval time.Duration
+	stop     chan bool
+	done     chan bool
+	once     sync.Once
+	mu       sync.Mutex
+	deleted  int
+	err      error
+}
+
+// NewSweeper starts a Sweeper deleting nodes with label whose property prop
+// - DefaultExpiryProperty if empty - has passed, every interval, or every
+// DefaultSweepInterval if interval is not positive.
+func (db *Database) NewSweeper(label, prop string, interval time.Duration) *Sweeper {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	s := &Sweeper{
+		db:       db,
+		label:    label,
+		prop:     prop,
+		interval: interval,
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+	go s.run()
+	return s
+}
+
+// Deleted returns the number of nodes deleted so far.
+func (s *Sweeper) Deleted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleted
+}
+
+// Close stops the sweeper, waiting for any sweep in progress to finish.  If
+// the most recent sweep failed, its error is returned.  Close may be called
+// more than once.
+func (s *Sweeper) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Sweeper) run() {
+	defer close(s.done)
+	for {
+		n, err := s.db.SweepExpired(s.label, s.prop)
+		if err != nil {
+			log.Printf("neo4j: sweep of expired %s nodes failed, retrying in %s: %s", s.label, s.interval, err)
+		}
+		s.mu.Lock()
+		s.deleted += n
+		s.err = err
+		s.mu.Unlock()
+		select {
+		case <-time.After(s.interval):
+		case <-s.stop:
+			return
+		}
+	}
+}
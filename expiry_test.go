@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/restclient"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSweepExpired(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	expired, _ := db.CreateNode(Props{"expiresAt": now - 60000})
+	expired.AddLabel(label)
+	live, _ := db.CreateNode(Props{"expiresAt": now + 600000})
+	live.AddLabel(label)
+	forever, _ := db.CreateNode(Props{})
+	forever.AddLabel(label)
+	expired.Relate("knows", live.Id(), nil)
+	n, err := db.SweepExpired(label, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, n)
+	nodes, _ := db.NodesByLabel(label)
+	assert.Equal(t, 2, len(nodes))
+	_, err = db.Node(expired.Id())
+	assert.Equal(t, NotFound, err)
+}
+
+func TestSweeper(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	s := db.NewSweeper(label, "ttl", 20*time.Millisecond)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	n, _ := db.CreateNode(Props{"ttl": now})
+	n.AddLabel(label)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, nil, s.Close())
+	assert.Equal(t, 1, s.Deleted())
+}
+
+func TestSweeperRetry(t *testing.T) {
+	// Nothing listens on port 1, so every sweep fails; the sweeper keeps going
+	db := &Database{
+		Rc:         restclient.New(),
+		HrefCypher: "http://127.0.0.1:1/db/data/cypher",
+	}
+	s := db.NewSweeper("Session", "", 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	err := s.Close()
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, err, s.Close())
+	assert.Equal(t, 0, s.Deleted())
+}
+
+func TestSweeperZeroInterval(t *testing.T) {
+	var sweeps int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sweeps, 1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"columns": ["count"], "data": [[0]]}`)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"cypher": srv.URL + "/db/data/cypher"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := db.NewSweeper("Session", "", 0)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, nil, s.Close())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sweeps))
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/jmcvetta/restclient"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// cellString renders a single result value as text.  Strings are written
+// without quotes, nulls as empty strings, and nodes and relationships as
+// their properties in JSON; anything else is written as compact JSON.
+func cellString(raw *json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var v interface{}
+	if json.Unmarshal(*raw, &v) != nil {
+		return string(*raw)
+	}
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case map[string]interface{}:
+		_, entity := x["self"]
+		if data, ok := x["data"]; ok && entity {
+			b, _ := json.Marshal(data)
+			return string(b)
+		}
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, *raw) != nil {
+		return string(*raw)
+	}
+	return buf.String()
+}
+
+// csvRecord fills record with the cells of row, blank where row is short.
+func csvRecord(record []string, row []*json.RawMessage) []string {
+	for i := range record {
+		record[i] = ""
+		if i < len(row) {
+			record[i] = cellString(row[i])
+		}
+	}
+	return record
+}
+
+// WriteCSV writes the results of an executed query to w as CSV, with a header
+// row of column names.  To export a large result without holding it in
+// memory, use Database.CypherCSV instead.
+func (cq *CypherQuery) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(cq.cr.Columns)
+	if err != nil {
+		return err
+	}
+	record := make([]string, len(cq.cr.Columns))
+	for _, row := range cq.cr.Data {
+		err := cw.Write(csvRecord(record, row))
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSink decodes a Cypher response one row at a time, writing each row to a
+// CSV writer as soon as it is decoded rather than collecting the result set.
+type csvSink struct {
+	cw *csv.Writer
+}
+
+func (s *csvSink) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	_, err := dec.Token() // {
+	if err != nil {
+		return err
+	}
+	var record []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "columns":
+			var cols []string
+			err = dec.Decode(&cols)
+			if err != nil {
+				return err
+			}
+			record = make([]string, len(cols))
+			err = s.cw.Write(cols)
+		case "data":
+			_, err = dec.Token() // [
+			for err == nil && dec.More() {
+				var d json.RawMessage
+				err = dec.Decode(&d)
+				if err != nil {
+					break
+				}
+				var row []*json.RawMessage
+				row, _, err = decodeRow(d)
+				if err == nil {
+					err = s.cw.Write(csvRecord(record, row))
+				}
+			}
+			if err == nil {
+				_, err = dec.Token() // ]
+			}
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CypherCSV executes q and writes its results to w as CSV, with a header row
+// of column names, as WriteCSV does.  Rows are encoded as they are decoded
+// from the response, so the result set is never held in memory; q.Result is
+// not populated.
+func (db *Database) CypherCSV(q *CypherQuery, w io.Writer) error {
+	err := db.lint(q)
+	if err != nil {
+		return err
+	}
+	sink := csvSink{cw: csv.NewWriter(w)}
+	ne := new(NeoError)
+	rr := restclient.RequestResponse{
+		Url:    db.HrefCypher,
+		Method: "POST",
+		Data: &cypherRequest{
+			Query:      q.Statement,
+			Parameters: q.Parameters,
+		},
+		Result: &sink,
+		Error:  ne,
+		Header: timeoutHeader([]*CypherQuery{q}),
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		logPretty(ne)
+		return *ne
+	}
+	sink.cw.Flush()
+	return sink.cw.Error()
+}
+
+// WriteTable writes the results of an executed query to w as an aligned text
+// table, in the style of neo4j-shell, followed by a row count.
+func (cq *CypherQuery) WriteTable(w io.Writer) error {
+	cols := cq.cr.Columns
+	cells := make([][]string, len(cq.cr.Data))
+	width := make([]int, len(cols))
+	for i, c := range cols {
+		width[i] = utf8.RuneCountInString(c)
+	}
+	for r, row := range cq.cr.Data {
+		cells[r] = make([]string, len(cols))
+		for i := range cols {
+			if i < len(row) {
+				// Keep each row on one line.
+				s := strings.Replace(cellString(row[i]), "\n", " ", -1)
+				cells[r][i] = s
+				if n := utf8.RuneCountInString(s); n > width[i] {
+					width[i] = n
+				}
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	rule := func() {
+		bw.WriteString("+")
+		for _, n := range width {
+			bw.WriteString(strings.Repeat("-", n+2))
+			bw.WriteString("+")
+		}
+		bw.WriteString("\n")
+	}
+	line := func(values []string) {
+		bw.WriteString("|")
+		for i, v := range values {
+			bw.WriteString(" " + v)
+			bw.WriteString(strings.Repeat(" ", width[i]-utf8.RuneCountInString(v)))
+			bw.WriteString(" |")
+		}
+		bw.WriteString("\n")
+	}
+	rule()
+	line(cols)
+	rule()
+	for _, row := range cells {
+		line(row)
+	}
+	rule()
+	rows := "rows"
+	if len(cells) == 1 {
+		rows = "row"
+	}
+	fmt.Fprintf(bw, "%d %s\n", len(cells), rows)
+	return bw.Flush()
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// exportQuery returns an executed query with a column of each kind of value.
+func exportQuery() *CypherQuery {
+	row := []*json.RawMessage{}
+	for _, s := range []string{
+		`"say \"hi\", bob"`,
+		`42`,
+		`{"self": "http://localhost:7474/db/data/node/1", "data": {"a": 1}}`,
+		`[1, 2]`,
+	} {
+		raw := json.RawMessage(s)
+		row = append(row, &raw)
+	}
+	row = append(row, nil)
+	return &CypherQuery{cr: cypherResult{
+		Columns: []string{"s", "n", "node", "list", "null"},
+		Data:    [][]*json.RawMessage{row},
+	}}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := exportQuery().WriteCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "s,n,node,list,null\n" +
+		`"say ""hi"", bob",42,"{""a"":1}","[1,2]",` + "\n"
+	assert.Equal(t, exp, buf.String())
+}
+
+func TestCypherCSV(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"columns": ["name", "n"], "data": [["a,b", 1], ["c", null], {"row": ["d", 3]}]}`)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"cypher": srv.URL + "/db/data/cypher"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = db.CypherCSV(&CypherQuery{Statement: "MATCH (n) RETURN n.name, n.n"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "name,n\n\"a,b\",1\nc,\nd,3\n", buf.String())
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := exportQuery().WriteTable(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `+---------------+----+---------+-------+------+
+| s             | n  | node    | list  | null |
++---------------+----+---------+-------+------+
+| say "hi", bob | 42 | {"a":1} | [1,2] |      |
++---------------+----+---------+-------+------+
+1 row
+`
+	assert.Equal(t, exp, buf.String())
+}
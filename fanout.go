@@ -0,0 +1,114 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// Names used by FanOut for its bucket nodes.
+const (
+	FanOutLabel   = "FanOutBucket"  // Label of bucket nodes
+	FanOutRelType = "FANOUT_BUCKET" // Type of relationships from hub to bucket
+)
+
+// DefaultFanOutBuckets is the number of buckets used by a FanOut created
+// with fewer than one.
+const DefaultFanOutBuckets = 16
+
+// A FanOut spreads a dense node's outgoing relationships of one type across
+// intermediate bucket nodes, so that concurrent writers lock one of several
+// buckets rather than all contending for the dense node:
+//
+//	(hub)-[:FANOUT_BUCKET {type, bucket}]->(:FanOutBucket)-[:type]->(target)
+//
+// Relationships should be created and read through the FanOut, which hides
+// the buckets.  Each target is always placed in the same bucket.
+type FanOut struct {
+	db      *Database
+	Hub     int    // ID of the dense node
+	Type    string // Type of the relationships fanned out
+	Buckets int
+}
+
+// FanOut returns a FanOut of the relationships of relType from the node with
+// ID hub across the given number of buckets.  The number of buckets must not
+// change once relationships have been created.
+func (db *Database) FanOut(hub int, relType string, buckets int) *FanOut {
+	if buckets < 1 {
+		buckets = DefaultFanOutBuckets
+	}
+	return &FanOut{db: db, Hub: hub, Type: relType, Buckets: buckets}
+}
+
+// bucket returns the bucket of target.
+func (f *FanOut) bucket(target int) int {
+	return target % f.Buckets
+}
+
+// Relate creates a relationship, with properties p, from the hub - by way of
+// a bucket - to the node with ID target, and returns its ID.  The bucket is
+// created if need be; only then is the hub itself locked.
+func (f *FanOut) Relate(target int, p Props) (int, error) {
+	err := f.db.Vocabulary.CheckRelTypes(f.Type)
+	if err != nil {
+		return 0, err
+	}
+	if p == nil {
+		p = Props{}
+	}
+	params := f.params()
+	params["target"] = target
+	params["bucket"] = f.bucket(target)
+	params["props"] = p
+	bucket := "(hub)-[:" + quoteIdent(FanOutRelType) + " {type: {type}, bucket: {bucket}}]->(b:" + quoteIdent(FanOutLabel) + ") "
+	create := "CREATE (b)-[r:" + quoteIdent(f.Type) + " {props}]->(t) RETURN id(r) AS id"
+	for _, stmt := range []string{
+		"START hub=node({hub}), t=node({target}) MATCH " + bucket + "WITH b, t LIMIT 1 " + create,
+		"START hub=node({hub}), t=node({target}) MERGE " + bucket + create,
+	} {
+		res := []struct {
+			Id int `json:"id"`
+		}{}
+		cq := CypherQuery{
+			Statement:  stmt,
+			Parameters: params,
+			Result:     &res,
+		}
+		err = f.db.Cypher(&cq)
+		if err != nil {
+			return 0, err
+		}
+		if len(res) > 0 {
+			return res[0].Id, nil
+		}
+	}
+	return 0, NotFound
+}
+
+// match returns the pattern matching the fanned out relationships, as r, and
+// their targets, as t.
+func (f *FanOut) match() string {
+	return "START hub=node({hub}) MATCH (hub)-[:" + quoteIdent(FanOutRelType) + " {type: {type}}]->(b:" +
+		quoteIdent(FanOutLabel) + ")-[r:" + quoteIdent(f.Type) + "]->(t) "
+}
+
+func (f *FanOut) params() Props {
+	return Props{"hub": f.Hub, "type": f.Type}
+}
+
+// Targets returns the nodes to which the hub is related, across all buckets.
+func (f *FanOut) Targets() ([]*Node, error) {
+	return f.db.cypherNodes(f.match()+"RETURN t ORDER BY id(t)", f.params())
+}
+
+// Count returns the number of fanned out relationships.
+func (f *FanOut) Count() (int, error) {
+	return f.db.cypherCount(f.match()+"RETURN count(r) AS count", f.params())
+}
+
+// Unrelate deletes the relationships from the hub to the node with ID target,
+// returning how many were deleted.
+func (f *FanOut) Unrelate(target int) (int, error) {
+	p := f.params()
+	p["target"] = target
+	return f.db.cypherCount(f.match()+"WHERE id(t) = {target} DELETE r RETURN count(*) AS count", p)
+}
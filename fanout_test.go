@@ -0,0 +1,59 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestFanOutBuckets(t *testing.T) {
+	db := &Database{}
+	f := db.FanOut(1, "follows", 0)
+	assert.Equal(t, DefaultFanOutBuckets, f.Buckets)
+	f = db.FanOut(1, "follows", 4)
+	assert.Equal(t, 3, f.bucket(7))
+	assert.Equal(t, f.bucket(7), f.bucket(11))
+}
+
+func TestFanOut(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	hub, _ := db.CreateNode(Props{})
+	relType := rndStr(t)
+	f := db.FanOut(hub.Id(), relType, 3)
+	targets := []int{}
+	for i := 0; i < 7; i++ {
+		n, _ := db.CreateNode(Props{})
+		_, err := f.Relate(n.Id(), Props{"i": i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, n.Id())
+	}
+	out, err := hub.Outgoing(FanOutRelType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(out))
+	nodes, err := f.Targets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []int{}
+	for _, n := range nodes {
+		got = append(got, n.Id())
+	}
+	assert.Equal(t, targets, got)
+	c, _ := f.Count()
+	assert.Equal(t, 7, c)
+	removed, err := f.Unrelate(targets[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, removed)
+	c, _ = f.Count()
+	assert.Equal(t, 6, c)
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// An Anonymizer replaces the values of sensitive properties with
+// pseudonyms.  Pseudonyms are derived from the value and Salt alone, so equal
+// values - even under different keys - get equal pseudonyms, and references
+// between nodes by such values survive anonymization.  Salt must be set when
+// Keys is not empty, and kept secret: without it, a pseudonym of a guessable
+// value - an email address or a name - can be confirmed by a dictionary.
+type Anonymizer struct {
+	Salt string
+	Keys []string // Properties whose values are replaced with pseudonyms
+	Drop []string // Properties removed entirely
+}
+
+// pseudonym returns a value of the same kind as v, derived from v and the
+// salt.  Strings become 16 hex digits, numbers become integers, booleans
+// remain booleans, and collections are anonymized element by element.
+func (a *Anonymizer) pseudonym(v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = a.pseudonym(e)
+		}
+		return out
+	}
+	b, _ := json.Marshal(v)
+	mac := hmac.New(sha256.New, []byte(a.Salt))
+	mac.Write(b)
+	sum := mac.Sum(nil)
+	switch v.(type) {
+	case bool:
+		return sum[0]&1 == 1
+	case float64, float32, int, int64, int32, uint, uint64, uint32:
+		return float64(binary.BigEndian.Uint32(sum))
+	}
+	return hex.EncodeToString(sum[:8])
+}
+
+// Props returns a copy of p with the values of Keys replaced by pseudonyms
+// and Drop removed.  A nil Anonymizer copies p unchanged.  MissingSalt is
+// returned if there are Keys but no Salt.
+func (a *Anonymizer) Props(p Props) (Props, error) {
+	out := Props{}
+	for k, v := range p {
+		out[k] = v
+	}
+	if a == nil {
+		return out, nil
+	}
+	if len(a.Keys) > 0 && a.Salt == "" {
+		return nil, MissingSalt
+	}
+	for _, k := range a.Drop {
+		delete(out, k)
+	}
+	for _, k := range a.Keys {
+		if v, ok := out[k]; ok {
+			out[k] = a.pseudonym(v)
+		}
+	}
+	return out, nil
+}
+
+// A FixtureNode is a node of a Fixture.  Its Id is its position in the
+// fixture, not its ID in the database it was exported from.
+type FixtureNode struct {
+	Id     int      `json:"id"`
+	Labels []string `json:"labels"`
+	Props  Props    `json:"props"`
+}
+
+// A FixtureRel is a relationship of a Fixture, between the nodes with
+// fixture IDs Start and End.
+type FixtureRel struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Type  string `json:"type"`
+	Props Props  `json:"props"`
+}
+
+// A Fixture is a self-contained subgraph, for loading into a test database.
+// It encodes as JSON.
+type Fixture struct {
+	Nodes         []FixtureNode `json:"nodes"`
+	Relationships []FixtureRel  `json:"relationships"`
+}
+
+// ExportFixture exports as a Fixture the nodes returned by the Cypher
+// statement stmt - in any column, alone or in collections - together with
+// the relationships among them, anonymizing the properties of both with a,
+// which may be nil.  MissingSalt is returned, before anything is read, if a
+// has Keys but no Salt.  Nodes are numbered in order of their database IDs, so
+// exporting the same subgraph twice gives the same fixture.
+func (db *Database) ExportFixture(stmt string, params Props, a *Anonymizer) (*Fixture, error) {
+	_, err := a.Props(nil)
+	if err != nil {
+		return nil, err
+	}
+	cq := CypherQuery{Statement: stmt, Parameters: params}
+	err = db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cq.Rows()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[int]bool{}
+	ids := []int{}
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch x := v.(type) {
+		case *Node:
+			if id := x.Id(); !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		case []interface{}:
+			for _, e := range x {
+				collect(e)
+			}
+		}
+	}
+	for _, row := range rows {
+		for _, cell := range row {
+			collect(cell)
+		}
+	}
+	sort.Ints(ids)
+	f := &Fixture{Nodes: []FixtureNode{}, Relationships: []FixtureRel{}}
+	if len(ids) == 0 {
+		return f, nil
+	}
+	nodes := []struct {
+		Id     int      `json:"id"`
+		Labels []string `json:"labels"`
+		Node   Node     `json:"node"`
+	}{}
+	rels := []struct {
+		Start int          `json:"start"`
+		End   int          `json:"end"`
+		Rel   Relationship `json:"rel"`
+	}{}
+	err = db.CypherBatch([]*CypherQuery{
+		&CypherQuery{
+			Statement:  "START n=node({ids}) RETURN id(n) AS id, labels(n) AS labels, n AS node ORDER BY id",
+			Parameters: Props{"ids": ids},
+			Result:     &nodes,
+		},
+		&CypherQuery{
+			Statement: "START a=node({ids}) MATCH (a)-[r]->(b) WHERE id(b) IN {ids} " +
+				"RETURN id(a) AS start, id(b) AS end, r AS rel ORDER BY id(r)",
+			Parameters: Props{"ids": ids},
+			Result:     &rels,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pos := make(map[int]int, len(nodes))
+	for i, n := range nodes {
+		pos[n.Id] = i
+		p, err := a.Props(n.Node.Data)
+		if err != nil {
+			return nil, err
+		}
+		f.Nodes = append(f.Nodes, FixtureNode{Id: i, Labels: n.Labels, Props: p})
+	}
+	for _, r := range rels {
+		data, _ := r.Rel.Data.(map[string]interface{})
+		p, err := a.Props(data)
+		if err != nil {
+			return nil, err
+		}
+		f.Relationships = append(f.Relationships, FixtureRel{
+			Start: pos[r.Start],
+			End:   pos[r.End],
+			Type:  r.Rel.Type,
+			Props: p,
+		})
+	}
+	return f, nil
+}
+
+// Load creates the fixture's nodes and relationships in db in a single batch
+// request, returning the created nodes keyed by fixture ID.
+func (f *Fixture) Load(db *Database) (map[int]*Node, error) {
+	g := Build()
+	seeds := make(map[int]*SeedNode, len(f.Nodes))
+	for _, n := range f.Nodes {
+		seeds[n.Id] = g.Node("", n.Props).Label(n.Labels...)
+	}
+	for _, r := range f.Relationships {
+		start, ok := seeds[r.Start]
+		end, ok2 := seeds[r.End]
+		if !ok || !ok2 {
+			return nil, UnknownSeedNode
+		}
+		g.Rel(start, end, r.Type, r.Props)
+	}
+	err := g.Commit(db)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[int]*Node, len(seeds))
+	for id, s := range seeds {
+		nodes[id] = s.Node()
+	}
+	return nodes, nil
+}
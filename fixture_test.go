@@ -0,0 +1,95 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestAnonymizer(t *testing.T) {
+	a := &Anonymizer{Salt: "pepper", Keys: []string{"email", "age", "tags", "vip"}, Drop: []string{"ssn"}}
+	p := Props{"email": "kirk@enterprise", "age": 34.0, "tags": []interface{}{"a", "b"}, "vip": true, "ssn": "123", "rank": 3.0}
+	q, err := a.Props(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk@enterprise", p["email"])
+	_, ok := q["ssn"]
+	assert.T(t, !ok)
+	assert.Equal(t, 3.0, q["rank"])
+	email, _ := q["email"].(string)
+	assert.Equal(t, 16, len(email))
+	assert.NotEqual(t, "kirk@enterprise", email)
+	_, ok = q["age"].(float64)
+	assert.T(t, ok)
+	_, ok = q["vip"].(bool)
+	assert.T(t, ok)
+	tags := q["tags"].([]interface{})
+	assert.Equal(t, 2, len(tags))
+	// Pseudonyms are deterministic, and depend on the value and salt only
+	q2, _ := a.Props(p)
+	assert.Equal(t, q, q2)
+	q2, _ = a.Props(Props{"email": "kirk@enterprise"})
+	assert.Equal(t, email, q2["email"])
+	assert.Equal(t, tags[0], a.pseudonym("a"))
+	b := &Anonymizer{Salt: "salt", Keys: []string{"email"}}
+	q2, _ = b.Props(p)
+	assert.NotEqual(t, email, q2["email"])
+	q2, _ = (*Anonymizer)(nil).Props(p)
+	assert.Equal(t, p, q2)
+	// Without a salt, pseudonyms could be reversed by a dictionary
+	unsalted := &Anonymizer{Keys: []string{"email"}}
+	_, err = unsalted.Props(p)
+	assert.Equal(t, MissingSalt, err)
+	_, err = (&Database{}).ExportFixture("MATCH (n) RETURN n", nil, unsalted)
+	assert.Equal(t, MissingSalt, err)
+	q2, err = (&Anonymizer{Drop: []string{"ssn"}}).Props(p)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, len(q2))
+}
+
+func TestFixtureLoadUnknownNode(t *testing.T) {
+	f := &Fixture{
+		Nodes:         []FixtureNode{{Id: 0, Props: Props{}}},
+		Relationships: []FixtureRel{{Start: 0, End: 1, Type: "KNOWS"}},
+	}
+	_, err := f.Load(&Database{})
+	assert.Equal(t, UnknownSeedNode, err)
+}
+
+func TestExportFixture(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	g := Build()
+	kirk := g.Node(label, Props{"name": "Kirk", "email": "kirk@enterprise"})
+	spock := g.Node(label, Props{"name": "Spock", "email": "spock@enterprise"})
+	g.Rel(kirk, spock, "KNOWS", Props{"since": 2260})
+	outside := g.Node("", Props{"name": "Khan"})
+	g.Rel(kirk, outside, "FIGHTS")
+	err := g.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Anonymizer{Salt: "pepper", Keys: []string{"email"}}
+	f, err := db.ExportFixture("MATCH (n:"+quoteIdent(label)+") RETURN collect(n)", nil, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(f.Nodes))
+	assert.Equal(t, []string{label}, f.Nodes[0].Labels)
+	assert.Equal(t, "Kirk", f.Nodes[0].Props["name"])
+	assert.Equal(t, a.pseudonym("kirk@enterprise"), f.Nodes[0].Props["email"])
+	assert.Equal(t, []FixtureRel{{Start: 0, End: 1, Type: "KNOWS", Props: Props{"since": 2260.0}}}, f.Relationships)
+	cleanup(t, db)
+	nodes, err := f.Load(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := nodes[0].Outgoing("KNOWS")
+	assert.Equal(t, 1, len(out))
+	assert.Equal(t, nodes[1].Id(), out[0].EndId())
+}
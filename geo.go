@@ -0,0 +1,126 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"math"
+)
+
+// Locations are stored as decimal degrees in two plain properties, so simple
+// geographic queries can be made with Cypher alone, without the Spatial
+// plugin.  Queries scan every node with the given label, unless the
+// properties have a schema index.
+const (
+	LatitudeProp  = "lat"
+	LongitudeProp = "lon"
+)
+
+// EarthRadiusKm is the mean radius of the Earth used in distance
+// calculations.
+const EarthRadiusKm = 6371.0
+
+// SetLocation stores a latitude and longitude, in decimal degrees, as
+// properties LatitudeProp and LongitudeProp, in a single batch request.
+func (e *entity) SetLocation(lat, lon float64) error {
+	jobs := []*batchJob{
+		&batchJob{Method: "PUT", To: e.Db.relPath(join(e.HrefProperties, LatitudeProp)), Id: 0, Body: lat},
+		&batchJob{Method: "PUT", To: e.Db.relPath(join(e.HrefProperties, LongitudeProp)), Id: 1, Body: lon},
+	}
+	_, err := e.Db.sendBatch(jobs)
+	return err
+}
+
+// A BoundingBox is a region between two latitudes and two longitudes, in
+// decimal degrees.  If MinLon is greater than MaxLon, the box crosses the
+// 180th meridian.
+type BoundingBox struct {
+	MinLat, MinLon float64
+	MaxLat, MaxLon float64
+}
+
+// BoundingBoxAround returns the smallest BoundingBox containing every point
+// within km kilometres of lat, lon.
+func BoundingBoxAround(lat, lon, km float64) BoundingBox {
+	dLat := km / EarthRadiusKm * 180 / math.Pi
+	b := BoundingBox{MinLat: lat - dLat, MaxLat: lat + dLat, MinLon: -180, MaxLon: 180}
+	if b.MinLat <= -90 || b.MaxLat >= 90 {
+		// The circle contains a pole, so spans every longitude.
+		b.MinLat = math.Max(b.MinLat, -90)
+		b.MaxLat = math.Min(b.MaxLat, 90)
+		return b
+	}
+	dLon := math.Asin(math.Min(1, math.Sin(km/EarthRadiusKm)/math.Cos(lat*math.Pi/180))) * 180 / math.Pi
+	if dLon >= 180 {
+		return b
+	}
+	b.MinLon = wrapLongitude(lon - dLon)
+	b.MaxLon = wrapLongitude(lon + dLon)
+	return b
+}
+
+// wrapLongitude returns lon in the range [-180, 180).
+func wrapLongitude(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// where returns a Cypher predicate matching identifier n if its location is
+// within the box.
+func (b BoundingBox) where(n string) string {
+	lat := n + "." + LatitudeProp
+	lon := n + "." + LongitudeProp
+	s := lat + " >= {minLat} AND " + lat + " <= {maxLat}"
+	if b.MinLon <= b.MaxLon {
+		return s + " AND " + lon + " >= {minLon} AND " + lon + " <= {maxLon}"
+	}
+	return s + " AND (" + lon + " >= {minLon} OR " + lon + " <= {maxLon})"
+}
+
+func (b BoundingBox) params() Props {
+	return Props{
+		"minLat": b.MinLat,
+		"maxLat": b.MaxLat,
+		"minLon": b.MinLon,
+		"maxLon": b.MaxLon,
+	}
+}
+
+// HaversineKm returns the great-circle distance in kilometres between two
+// points given in decimal degrees.
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	hav := func(x float64) float64 { return (1 - math.Cos(x)) / 2 }
+	h := hav((lat2-lat1)*rad) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*hav((lon2-lon1)*rad)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// NodesInBoundingBox returns the nodes with label whose location is within
+// box.
+func (db *Database) NodesInBoundingBox(label string, box BoundingBox) ([]*Node, error) {
+	stmt := "MATCH (n:" + quoteIdent(label) + ") WHERE " + box.where("n") + " RETURN n"
+	return db.cypherNodes(stmt, box.params())
+}
+
+// NodesWithinDistance returns the nodes with label whose location is within
+// km kilometres of lat, lon, nearest first.  Candidates are first narrowed
+// to a bounding box, then filtered by haversine distance.
+func (db *Database) NodesWithinDistance(label string, lat, lon, km float64) ([]*Node, error) {
+	box := BoundingBoxAround(lat, lon, km)
+	stmt := `
+		MATCH (n:` + quoteIdent(label) + `)
+		WHERE ` + box.where("n") + `
+		WITH n, 2 * {radius} * asin(sqrt(
+			haversin(radians(n.` + LatitudeProp + ` - {lat})) +
+			cos(radians(n.` + LatitudeProp + `)) * cos(radians({lat})) *
+			haversin(radians(n.` + LongitudeProp + ` - {lon})))) AS distance
+		WHERE distance <= {km}
+		RETURN n ORDER BY distance
+	`
+	params := box.params()
+	params["radius"] = EarthRadiusKm
+	params["lat"] = lat
+	params["lon"] = lon
+	params["km"] = km
+	return db.cypherNodes(stmt, params)
+}
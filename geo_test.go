@@ -0,0 +1,66 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"math"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// London to Paris
+	d := HaversineKm(51.5074, -0.1278, 48.8566, 2.3522)
+	assert.T(t, math.Abs(d-343.5) < 1, d)
+	assert.Equal(t, 0.0, HaversineKm(10, 10, 10, 10))
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	b := BoundingBoxAround(0, 0, 111.195)
+	assert.T(t, math.Abs(b.MaxLat-1) < 0.001, b)
+	assert.T(t, math.Abs(b.MinLon+1) < 0.001, b)
+	// Crossing the 180th meridian
+	b = BoundingBoxAround(0, 179.5, 111.195)
+	assert.T(t, b.MinLon > b.MaxLon, b)
+	assert.T(t, math.Abs(b.MaxLon+179.5) < 0.001, b)
+	assert.Equal(t, "n.lat >= {minLat} AND n.lat <= {maxLat} AND (n.lon >= {minLon} OR n.lon <= {maxLon})", b.where("n"))
+	// Containing a pole
+	b = BoundingBoxAround(89.5, 0, 111.195)
+	assert.Equal(t, BoundingBox{MinLat: b.MinLat, MinLon: -180, MaxLat: 90, MaxLon: 180}, b)
+}
+
+func TestNodesWithinDistance(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	places := []struct {
+		name     string
+		lat, lon float64
+	}{
+		{"london", 51.5074, -0.1278},
+		{"paris", 48.8566, 2.3522},
+		{"oxford", 51.7520, -1.2577},
+	}
+	for _, p := range places {
+		n, _ := db.CreateNode(Props{"name": p.name})
+		n.AddLabel("City")
+		err := n.SetLocation(p.lat, p.lon)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	nodes, err := db.NodesWithinDistance("City", 51.5, -0.1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(nodes))
+	assert.Equal(t, "london", nodes[0].Data["name"])
+	assert.Equal(t, "oxford", nodes[1].Data["name"])
+	nodes, err = db.NodesInBoundingBox("City", BoundingBox{48, 2, 49, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(nodes))
+	assert.Equal(t, "paris", nodes[0].Data["name"])
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Graph is an in-memory copy of the structure of a subgraph, for analyses
+// which are impractical in Cypher.  Nodes are identified by their database
+// IDs; properties are not copied.
+type Graph struct {
+	out map[int][]int
+	in  map[int][]int
+}
+
+// NewGraph returns an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{
+		out: map[int][]int{},
+		in:  map[int][]int{},
+	}
+}
+
+// AddNode adds node id to the graph, if not already present.
+func (g *Graph) AddNode(id int) {
+	if _, ok := g.out[id]; !ok {
+		g.out[id] = nil
+		g.in[id] = nil
+	}
+}
+
+// AddEdge adds a directed edge between two nodes, adding the nodes if
+// necessary.  Parallel edges are kept.
+func (g *Graph) AddEdge(from, to int) {
+	g.AddNode(from)
+	g.AddNode(to)
+	g.out[from] = append(g.out[from], to)
+	g.in[to] = append(g.in[to], from)
+}
+
+// Nodes returns the IDs of the nodes in the graph, in ascending order.
+func (g *Graph) Nodes() []int {
+	ids := make([]int, 0, len(g.out))
+	for id := range g.out {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Out returns the nodes at the end of the edges leaving node id.
+func (g *Graph) Out(id int) []int {
+	return g.out[id]
+}
+
+// In returns the nodes at the start of the edges entering node id.
+func (g *Graph) In(id int) []int {
+	return g.in[id]
+}
+
+// ExtractGraph copies the subgraph of nodes with label, and the relationships
+// of relType between them, into a Graph.  If relType is empty, relationships
+// of every type are copied.
+func (db *Database) ExtractGraph(label, relType string) (*Graph, error) {
+	rel := relPattern(relType)
+	l := ":" + quoteIdent(label)
+	res := []struct {
+		Id  int   `json:"id"`
+		Out []int `json:"out"`
+	}{}
+	cq := CypherQuery{
+		Statement: "MATCH (n" + l + ") OPTIONAL MATCH (n)-" + rel + "->(m" + l + ") " +
+			"RETURN id(n) AS id, collect(id(m)) AS out",
+		Result: &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	g := NewGraph()
+	for _, r := range res {
+		g.AddNode(r.Id)
+		for _, m := range r.Out {
+			g.AddEdge(r.Id, m)
+		}
+	}
+	return g, nil
+}
+
+// relPattern returns a Cypher relationship pattern matching any of relTypes,
+// or any type if none is given.  Empty types are ignored.
+func relPattern(relTypes ...string) string {
+	quoted := []string{}
+	for _, t := range relTypes {
+		if t != "" {
+			quoted = append(quoted, quoteIdent(t))
+		}
+	}
+	if len(quoted) == 0 {
+		return "[]"
+	}
+	return "[:" + strings.Join(quoted, "|") + "]"
+}
+
+// setNodeValues sets property prop of each node keyed in values, in a single
+// Cypher statement.
+func (db *Database) setNodeValues(prop string, values map[int]interface{}) error {
+	rows := make([]Props, 0, len(values))
+	for id, v := range values {
+		rows = append(rows, Props{"id": id, "value": v})
+	}
+	cq := CypherQuery{
+		Statement: "UNWIND {rows} AS row MATCH (n) WHERE id(n) = row.id " +
+			"SET n." + quoteIdent(prop) + " = row.value",
+		Parameters: Props{"rows": rows},
+	}
+	return db.Cypher(&cq)
+}
+
+// WriteNodeScores stores scores, keyed by node ID, as property prop of the
+// nodes - for instance the results of PageRank.
+func (db *Database) WriteNodeScores(prop string, scores map[int]float64) error {
+	values := make(map[int]interface{}, len(scores))
+	for id, s := range scores {
+		values[id] = s
+	}
+	return db.setNodeValues(prop, values)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// pathGraph returns the graph 1 -> 2 -> 3 -> 4.
+func pathGraph() *Graph {
+	g := NewGraph()
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+	g.AddEdge(3, 4)
+	return g
+}
+
+func TestGraph(t *testing.T) {
+	g := pathGraph()
+	g.AddNode(5)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, g.Nodes())
+	assert.Equal(t, []int{3}, g.Out(2))
+	assert.Equal(t, []int{1}, g.In(2))
+	assert.Equal(t, map[int]int{1: 1, 2: 2, 3: 2, 4: 1, 5: 0}, g.Degree())
+}
+
+func TestPageRank(t *testing.T) {
+	// A star: every node links to the hub, which links back to node 1.
+	g := NewGraph()
+	for i := 1; i <= 4; i++ {
+		g.AddEdge(i, 0)
+	}
+	g.AddEdge(0, 1)
+	g.AddNode(5) // Dangling
+	pr := g.PageRank(0.85, 50)
+	sum := 0.0
+	for _, r := range pr {
+		sum += r
+	}
+	assert.T(t, math.Abs(sum-1) < 1e-9, sum)
+	assert.T(t, pr[0] > pr[1] && pr[1] > pr[2], pr)
+	assert.T(t, math.Abs(pr[2]-pr[3]) < 1e-12, pr)
+}
+
+func TestBetweenness(t *testing.T) {
+	bc := pathGraph().Betweenness(0, nil)
+	assert.Equal(t, map[int]float64{1: 0, 2: 2, 3: 2, 4: 0}, bc)
+	// Two equal shortest paths from 1 to 4 share the credit.
+	g := NewGraph()
+	g.AddEdge(1, 2)
+	g.AddEdge(1, 3)
+	g.AddEdge(2, 4)
+	g.AddEdge(3, 4)
+	bc = g.Betweenness(0, nil)
+	assert.Equal(t, 0.5, bc[2])
+	assert.Equal(t, 0.5, bc[3])
+	// Sampling every source is exact.
+	bc = pathGraph().Betweenness(4, rand.New(rand.NewSource(1)))
+	assert.Equal(t, 2.0, bc[2])
+}
+
+func TestExtractGraph(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	nodes := make([]*Node, 3)
+	for i := range nodes {
+		nodes[i], _ = db.CreateNode(Props{})
+		nodes[i].AddLabel("Page")
+	}
+	outsider, _ := db.CreateNode(Props{})
+	nodes[0].Relate("links", nodes[1].Id(), nil)
+	nodes[1].Relate("links", nodes[2].Id(), nil)
+	nodes[2].Relate("cites", nodes[0].Id(), nil)
+	nodes[2].Relate("links", outsider.Id(), nil)
+	g, err := db.ExtractGraph("Page", "links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(g.Nodes()))
+	assert.Equal(t, []int{nodes[1].Id()}, g.Out(nodes[0].Id()))
+	assert.Equal(t, 0, len(g.Out(nodes[2].Id())))
+	g, _ = db.ExtractGraph("Page", "")
+	assert.Equal(t, []int{nodes[0].Id()}, g.Out(nodes[2].Id()))
+	err = db.WriteNodeScores("rank", g.PageRank(0.85, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ := nodes[0].Properties()
+	assert.T(t, props["rank"].(float64) > 0)
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// A GraphDiff reports the differences between the nodes with a given label,
+// and the relationships between them, in two databases A and B.  Nodes are
+// matched by an external key property rather than by ID, since IDs are not
+// preserved by replication or migration.  Nodes sharing a key with another
+// node on the same side cannot be matched; they are reported as duplicates
+// and otherwise ignored.
+type GraphDiff struct {
+	OnlyInA     []string          // Keys of nodes found only in A
+	OnlyInB     []string          // Keys of nodes found only in B
+	Changed     []PropertyDiff    // Nodes whose properties differ
+	DuplicatesA []string          // Keys shared by several nodes in A
+	DuplicatesB []string          // Keys shared by several nodes in B
+	RelsOnlyInA []RelKey          // Relationships found only in A
+	RelsOnlyInB []RelKey          // Relationships found only in B
+	RelsChanged []RelPropertyDiff // Relationships whose properties differ
+}
+
+// Empty reports whether no differences were found.
+func (d *GraphDiff) Empty() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 && len(d.Changed) == 0 &&
+		len(d.DuplicatesA) == 0 && len(d.DuplicatesB) == 0 &&
+		len(d.RelsOnlyInA) == 0 && len(d.RelsOnlyInB) == 0 && len(d.RelsChanged) == 0
+}
+
+// A PropertyDiff holds the differing properties of a node present in both
+// databases.
+type PropertyDiff struct {
+	Key string
+	A   Props
+	B   Props
+}
+
+// A RelKey identifies a relationship by the external keys of its endpoints.
+type RelKey struct {
+	Start string
+	Type  string
+	End   string
+}
+
+// A RelPropertyDiff holds the differing properties of a relationship present
+// in both databases.
+type RelPropertyDiff struct {
+	Rel RelKey
+	A   Props
+	B   Props
+}
+
+// Diff compares the nodes with label in databases a and b, keyed by property
+// keyProp, along with the relationships between those nodes.
+func Diff(a, b *Database, label, keyProp string) (*GraphDiff, error) {
+	return DiffLabels(a, label, b, label, keyProp)
+}
+
+// DiffLabels is like Diff, but compares nodes with labelA in database a to
+// nodes with labelB in database b.  The databases may be the same.
+func DiffLabels(a *Database, labelA string, b *Database, labelB string, keyProp string) (*GraphDiff, error) {
+	d := &GraphDiff{
+		OnlyInA:     []string{},
+		OnlyInB:     []string{},
+		Changed:     []PropertyDiff{},
+		RelsOnlyInA: []RelKey{},
+		RelsOnlyInB: []RelKey{},
+		RelsChanged: []RelPropertyDiff{},
+	}
+	nodesA, dupsA, err := a.keyedNodes(labelA, keyProp)
+	if err != nil {
+		return nil, err
+	}
+	nodesB, dupsB, err := b.keyedNodes(labelB, keyProp)
+	if err != nil {
+		return nil, err
+	}
+	d.DuplicatesA = dupsA
+	d.DuplicatesB = dupsB
+	for k, pa := range nodesA {
+		pb, ok := nodesB[k]
+		if !ok {
+			d.OnlyInA = append(d.OnlyInA, k)
+			continue
+		}
+		if !reflect.DeepEqual(pa, pb) {
+			d.Changed = append(d.Changed, PropertyDiff{Key: k, A: pa, B: pb})
+		}
+	}
+	for k := range nodesB {
+		if _, ok := nodesA[k]; !ok {
+			d.OnlyInB = append(d.OnlyInB, k)
+		}
+	}
+	sort.Strings(d.OnlyInA)
+	sort.Strings(d.OnlyInB)
+	sort.Sort(propertyDiffs(d.Changed))
+	relsA, err := a.keyedRels(labelA, keyProp)
+	if err != nil {
+		return nil, err
+	}
+	relsB, err := b.keyedRels(labelB, keyProp)
+	if err != nil {
+		return nil, err
+	}
+	d.RelsOnlyInA, d.RelsOnlyInB, d.RelsChanged = diffRels(relsA, relsB)
+	return d, nil
+}
+
+// keyedNodes returns the properties of all nodes with label, keyed by the
+// string form of their keyProp property, plus the sorted keys shared by more
+// than one node, which are omitted from the map.
+func (db *Database) keyedNodes(label, keyProp string) (map[string]Props, []string, error) {
+	k := "n." + quoteIdent(keyProp)
+	cq := CypherQuery{
+		Statement: "MATCH (n:" + quoteIdent(label) + ") WHERE has(" + k + ") RETURN " + k + ", n",
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := make(map[string]Props, len(cq.cr.Data))
+	dups := map[string]bool{}
+	for _, row := range cq.cr.Data {
+		var key interface{}
+		n := Node{}
+		if err := db.codec().Unmarshal(*row[0], &key); err != nil {
+			return nil, nil, err
+		}
+		if err := db.codec().Unmarshal(*row[1], &n); err != nil {
+			return nil, nil, err
+		}
+		ks := fmt.Sprint(key)
+		if _, ok := m[ks]; ok {
+			dups[ks] = true
+		}
+		m[ks] = Props(n.Data)
+	}
+	dupKeys := []string{}
+	for ks := range dups {
+		delete(m, ks)
+		dupKeys = append(dupKeys, ks)
+	}
+	sort.Strings(dupKeys)
+	return m, dupKeys, nil
+}
+
+// keyedRels returns the properties of all relationships between nodes with
+// label, grouped by the keys of their endpoints.  Parallel relationships each
+// have an entry, in a canonical order.
+func (db *Database) keyedRels(label, keyProp string) (map[RelKey][]Props, error) {
+	l := quoteIdent(label)
+	k := quoteIdent(keyProp)
+	res := []struct {
+		Start interface{} `json:"start"`
+		Type  string      `json:"type"`
+		End   interface{} `json:"end"`
+		Rel   struct {
+			Data Props `json:"data"`
+		} `json:"rel"`
+	}{}
+	cq := CypherQuery{
+		Statement: "MATCH (a:" + l + ")-[r]->(b:" + l + ") WHERE has(a." + k + ") AND has(b." + k + ") " +
+			"RETURN a." + k + " AS start, type(r) AS type, b." + k + " AS end, r AS rel",
+		Result: &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[RelKey][]Props, len(res))
+	for _, r := range res {
+		rk := RelKey{Start: fmt.Sprint(r.Start), Type: r.Type, End: fmt.Sprint(r.End)}
+		p := r.Rel.Data
+		if p == nil {
+			p = Props{}
+		}
+		m[rk] = append(m[rk], p)
+	}
+	for _, ps := range m {
+		sort.Sort(propsList(ps))
+	}
+	return m, nil
+}
+
+// diffRels compares two sets of keyed relationships.  Parallel relationships
+// with identical properties are matched first; the remainder are paired up as
+// changed, and any excess is reported as present on one side only.
+func diffRels(a, b map[RelKey][]Props) (onlyA, onlyB []RelKey, changed []RelPropertyDiff) {
+	onlyA, onlyB, changed = relKeys{}, relKeys{}, []RelPropertyDiff{}
+	for rk, pa := range a {
+		ra, rb := propsMinus(pa, b[rk]), propsMinus(b[rk], pa)
+		for len(ra) > 0 && len(rb) > 0 {
+			changed = append(changed, RelPropertyDiff{Rel: rk, A: ra[0], B: rb[0]})
+			ra, rb = ra[1:], rb[1:]
+		}
+		for _ = range ra {
+			onlyA = append(onlyA, rk)
+		}
+		for _ = range rb {
+			onlyB = append(onlyB, rk)
+		}
+	}
+	for rk, pb := range b {
+		if _, ok := a[rk]; !ok {
+			for _ = range pb {
+				onlyB = append(onlyB, rk)
+			}
+		}
+	}
+	sort.Sort(relKeys(onlyA))
+	sort.Sort(relKeys(onlyB))
+	sort.Sort(relPropertyDiffs(changed))
+	return
+}
+
+// propsMinus returns the elements of a not matched by an equal element of b.
+func propsMinus(a, b []Props) []Props {
+	used := make([]bool, len(b))
+	rest := []Props{}
+outer:
+	for _, pa := range a {
+		for j, pb := range b {
+			if !used[j] && reflect.DeepEqual(pa, pb) {
+				used[j] = true
+				continue outer
+			}
+		}
+		rest = append(rest, pa)
+	}
+	return rest
+}
+
+type propertyDiffs []PropertyDiff
+
+func (p propertyDiffs) Len() int           { return len(p) }
+func (p propertyDiffs) Less(i, j int) bool { return p[i].Key < p[j].Key }
+func (p propertyDiffs) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+type relPropertyDiffs []RelPropertyDiff
+
+func (r relPropertyDiffs) Len() int           { return len(r) }
+func (r relPropertyDiffs) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r relPropertyDiffs) Less(i, j int) bool { return relKeys{r[i].Rel, r[j].Rel}.Less(0, 1) }
+
+// propsList sorts Props by their JSON encoding.
+type propsList []Props
+
+func (p propsList) Len() int      { return len(p) }
+func (p propsList) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p propsList) Less(i, j int) bool {
+	a, _ := json.Marshal(p[i])
+	b, _ := json.Marshal(p[j])
+	return string(a) < string(b)
+}
+
+type relKeys []RelKey
+
+func (r relKeys) Len() int      { return len(r) }
+func (r relKeys) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r relKeys) Less(i, j int) bool {
+	switch {
+	case r[i].Start != r[j].Start:
+		return r[i].Start < r[j].Start
+	case r[i].Type != r[j].Type:
+		return r[i].Type < r[j].Type
+	}
+	return r[i].End < r[j].End
+}
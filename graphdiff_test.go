@@ -0,0 +1,88 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestDiffRels(t *testing.T) {
+	ab := RelKey{"a", "knows", "b"}
+	bc := RelKey{"b", "knows", "c"}
+	cd := RelKey{"c", "knows", "d"}
+	a := map[RelKey][]Props{
+		ab: {Props{}, Props{"since": 1.0}},
+		bc: {Props{"since": 2.0}},
+	}
+	b := map[RelKey][]Props{
+		ab: {Props{"since": 1.0}},
+		bc: {Props{"since": 3.0}},
+		cd: {Props{}},
+	}
+	onlyA, onlyB, changed := diffRels(a, b)
+	assert.Equal(t, []RelKey{ab}, onlyA)
+	assert.Equal(t, []RelKey{cd}, onlyB)
+	assert.Equal(t, []RelPropertyDiff{RelPropertyDiff{bc, Props{"since": 2.0}, Props{"since": 3.0}}}, changed)
+}
+
+// Diff is normally used across two servers, but comparing two labels in one
+// database exercises the same code.
+func TestDiff(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	labelA := rndStr(t)
+	labelB := rndStr(t)
+	nodes := []ImportNode{
+		ImportNode{Key: "kirk", Props: Props{"rank": "captain"}},
+		ImportNode{Key: "spock", Props: Props{"rank": "commander"}},
+		ImportNode{Key: "sulu", Props: Props{"rank": "lieutenant"}},
+	}
+	edges := []ImportEdge{
+		ImportEdge{From: "kirk", To: "spock", Type: "knows"},
+		ImportEdge{From: "kirk", To: "sulu", Type: "commands", Props: Props{"since": 1.0}},
+	}
+	_, err := db.Import(labelA, "name", nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes[1].Props = Props{"rank": "captain"}
+	nodes = append(nodes, ImportNode{Key: "mccoy"})
+	edges = []ImportEdge{
+		ImportEdge{From: "kirk", To: "sulu", Type: "commands", Props: Props{"since": 2.0}},
+	}
+	_, err = db.Import(labelB, "name", nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second node keyed "kirk" in A
+	n0, _ := db.CreateNode(Props{"name": "kirk"})
+	n0.AddLabel(labelA)
+	d, err := DiffLabels(db, labelA, db, labelB, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"kirk"}, d.DuplicatesA)
+	assert.Equal(t, []string{}, d.DuplicatesB)
+	assert.Equal(t, []string{}, d.OnlyInA)
+	assert.Equal(t, []string{"kirk", "mccoy"}, d.OnlyInB)
+	assert.Equal(t, 1, len(d.Changed))
+	assert.Equal(t, "spock", d.Changed[0].Key)
+	assert.Equal(t, "commander", d.Changed[0].A["rank"])
+	assert.Equal(t, []RelKey{RelKey{"kirk", "knows", "spock"}}, d.RelsOnlyInA)
+	assert.Equal(t, []RelKey{}, d.RelsOnlyInB)
+	assert.Equal(t, 1, len(d.RelsChanged))
+	assert.Equal(t, Props{"since": 1.0}, d.RelsChanged[0].A)
+	assert.Equal(t, Props{"since": 2.0}, d.RelsChanged[0].B)
+	//
+	// Identical databases have no differences
+	//
+	n0.Delete()
+	d, err = Diff(db, db, labelA, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.T(t, d.Empty())
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/jmcvetta/restclient"
+	"net/http"
+)
+
+// WithHeaders returns a copy of db which adds h to the HTTP headers of every
+// request - for instance audit headers consumed by a proxy.  Values in h
+// replace those set by an earlier WithHeaders call; headers set by the
+// request itself take precedence.  Nodes and relationships fetched through
+// the copy use it for their own requests.
+func (db *Database) WithHeaders(h http.Header) *Database {
+	c := *db
+	c.headers = http.Header{}
+	for k, vs := range db.headers {
+		c.headers[k] = vs
+	}
+	for k, vs := range h {
+		c.headers[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+	}
+	return &c
+}
+
+// addHeaders merges the headers set by WithHeaders into rr.
+func (db *Database) addHeaders(rr *restclient.RequestResponse) {
+	if len(db.headers) == 0 {
+		return
+	}
+	h := http.Header{}
+	if rr.Header != nil {
+		for k, vs := range *rr.Header {
+			h[k] = vs
+		}
+	}
+	for k, vs := range db.headers {
+		if _, ok := h[k]; !ok {
+			h[k] = vs
+		}
+	}
+	rr.Header = &h
+}
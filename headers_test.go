@@ -0,0 +1,47 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/restclient"
+	"net/http"
+	"testing"
+)
+
+func TestAddHeaders(t *testing.T) {
+	db := &Database{}
+	rr := restclient.RequestResponse{}
+	db.addHeaders(&rr)
+	assert.Equal(t, (*http.Header)(nil), rr.Header)
+	audit := db.WithHeaders(http.Header{"x-audit-user": {"alice"}, "X-Trace": {"1"}})
+	c := audit.WithHeaders(http.Header{"X-Trace": {"2"}})
+	assert.Equal(t, http.Header(nil), db.headers)
+	assert.Equal(t, "1", audit.headers.Get("X-Trace"))
+	own := http.Header{"X-Trace": {"3"}}
+	rr.Header = &own
+	c.addHeaders(&rr)
+	assert.Equal(t, "alice", rr.Header.Get("X-Audit-User"))
+	assert.Equal(t, "3", rr.Header.Get("X-Trace"))
+	assert.Equal(t, 1, len(own))
+}
+
+func TestWithHeaders(t *testing.T) {
+	ct := &countingTransport{}
+	db, err := ConnectWithOptions("http://localhost:7474/db/data", &ConnectOptions{Transport: ct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup(t, db)
+	n, err := db.WithHeaders(http.Header{"X-Audit-User": {"alice"}}).CreateNode(Props{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "alice", ct.header.Get("X-Audit-User"))
+	n.SetProperty("foo", "bar")
+	assert.Equal(t, "alice", ct.header.Get("X-Audit-User"))
+	db.Node(n.Id())
+	assert.Equal(t, "", ct.header.Get("X-Audit-User"))
+}
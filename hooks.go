@@ -0,0 +1,136 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// hooks holds the lifecycle callbacks registered on a Database.
+type hooks struct {
+	sync.RWMutex
+	nodeCreated []func(*Node)
+	nodeDeleted []func(*Node)
+	relCreated  []func(*Relationship)
+	relDeleted  []func(*Relationship)
+}
+
+// Lifecycle callbacks are fired client-side, synchronously, after an
+// operation made through this package succeeds.  Changes made by Cypher
+// queries are not detected.  Callbacks must not block for long, since the
+// operation which triggered them does not return until they complete.
+//
+// A Database made by Connect, and every copy of it, shares one set of hooks,
+// created with it.  A Database made otherwise gets its hooks on the first
+// registration.
+
+// OnNodeCreated registers fn to be called after a node is created.
+func (db *Database) OnNodeCreated(fn func(*Node)) {
+	h := db.getHooks(true)
+	h.Lock()
+	defer h.Unlock()
+	h.nodeCreated = append(h.nodeCreated, fn)
+}
+
+// OnNodeDeleted registers fn to be called after a node is deleted by
+// Node.Delete or DeletionPlan.Execute.  Nodes deleted by Cypher statements -
+// including those of DeleteOrphans and SweepExpired - are not reported.
+func (db *Database) OnNodeDeleted(fn func(*Node)) {
+	h := db.getHooks(true)
+	h.Lock()
+	defer h.Unlock()
+	h.nodeDeleted = append(h.nodeDeleted, fn)
+}
+
+// OnRelationshipCreated registers fn to be called after a relationship is
+// created.
+func (db *Database) OnRelationshipCreated(fn func(*Relationship)) {
+	h := db.getHooks(true)
+	h.Lock()
+	defer h.Unlock()
+	h.relCreated = append(h.relCreated, fn)
+}
+
+// OnRelationshipDeleted registers fn to be called after a relationship is
+// deleted by Relationship.Delete or DeletionPlan.Execute.  Relationships
+// deleted by Cypher statements are not reported.
+func (db *Database) OnRelationshipDeleted(fn func(*Relationship)) {
+	h := db.getHooks(true)
+	h.Lock()
+	defer h.Unlock()
+	h.relDeleted = append(h.relDeleted, fn)
+}
+
+// getHooks returns db's hooks, creating them if create is true and there are
+// none yet.  It may return nil if create is false.  The pointer is read and
+// set atomically, so that firing hooks takes no lock but that of db's own
+// hooks, while concurrent first registrations still create only one set.
+func (db *Database) getHooks(create bool) *hooks {
+	p := (*unsafe.Pointer)(unsafe.Pointer(&db.hooks))
+	h := (*hooks)(atomic.LoadPointer(p))
+	if h == nil && create {
+		atomic.CompareAndSwapPointer(p, nil, unsafe.Pointer(new(hooks)))
+		h = (*hooks)(atomic.LoadPointer(p))
+	}
+	return h
+}
+
+// The fire functions copy the callbacks under the lock and call them after
+// releasing it, so that callbacks may themselves register hooks or make
+// requests which fire hooks.
+
+func (db *Database) nodeCreated(n *Node) {
+	h := db.getHooks(false)
+	if h == nil {
+		return
+	}
+	h.RLock()
+	fns := append([]func(*Node){}, h.nodeCreated...)
+	h.RUnlock()
+	for _, fn := range fns {
+		fn(n)
+	}
+}
+
+func (db *Database) nodeDeleted(n *Node) {
+	h := db.getHooks(false)
+	if h == nil {
+		return
+	}
+	h.RLock()
+	fns := append([]func(*Node){}, h.nodeDeleted...)
+	h.RUnlock()
+	for _, fn := range fns {
+		fn(n)
+	}
+}
+
+func (db *Database) relCreated(r *Relationship) {
+	h := db.getHooks(false)
+	if h == nil {
+		return
+	}
+	h.RLock()
+	fns := append([]func(*Relationship){}, h.relCreated...)
+	h.RUnlock()
+	for _, fn := range fns {
+		fn(r)
+	}
+}
+
+func (db *Database) relDeleted(r *Relationship) {
+	h := db.getHooks(false)
+	if h == nil {
+		return
+	}
+	h.RLock()
+	fns := append([]func(*Relationship){}, h.relDeleted...)
+	h.RUnlock()
+	for _, fn := range fns {
+		fn(r)
+	}
+}
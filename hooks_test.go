@@ -0,0 +1,60 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"sync"
+	"testing"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	defer func() { db.hooks = new(hooks) }()
+	events := []string{}
+	db.OnNodeCreated(func(n *Node) { events = append(events, "node created") })
+	db.OnNodeDeleted(func(n *Node) { events = append(events, "node deleted") })
+	db.OnRelationshipCreated(func(r *Relationship) { events = append(events, "rel created") })
+	db.OnRelationshipDeleted(func(r *Relationship) { events = append(events, "rel deleted") })
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	r0, _ := n0.Relate("knows", n1.Id(), nil)
+	db.CreateRelationships([]RelSpec{RelSpec{Start: n1.Id(), End: n0.Id(), Type: "knows"}})
+	r0.Delete()
+	n0.Delete() // Fails - n0 still has a relationship
+	exp := []string{
+		"node created",
+		"node created",
+		"rel created",
+		"rel created",
+		"rel deleted",
+	}
+	assert.Equal(t, exp, events)
+}
+
+func TestHooksReentrant(t *testing.T) {
+	db := &Database{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db.OnNodeDeleted(func(n *Node) {})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 10, len(db.hooks.nodeDeleted))
+	// A callback registering another callback must not deadlock
+	calls := 0
+	db.OnNodeCreated(func(n *Node) {
+		calls++
+		db.OnNodeCreated(func(n *Node) { calls++ })
+	})
+	db.nodeCreated(&Node{})
+	assert.Equal(t, 1, calls)
+	db.nodeCreated(&Node{})
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"errors"
+	"github.com/jmcvetta/restclient"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Endpoint URLs are taken from the service root document wherever the server
+// advertises them, so that a server mounted under any path - for instance
+// behind a reverse proxy - is reached correctly.  Only endpoints the root
+// document does not mention are derived from the URL passed to Connect.
+
+// parent returns href with its last path segment removed, or "" if href is
+// empty.
+func parent(href string) string {
+	href = strings.TrimRight(href, "/")
+	i := strings.LastIndex(href, "/")
+	if i < 0 {
+		return ""
+	}
+	return href[:i]
+}
+
+// hrefOr returns advertised if it is set, else the path fallback relative to
+// db.Url.
+func (db *Database) hrefOr(advertised, fallback string) string {
+	if advertised != "" {
+		return advertised
+	}
+	return join(db.Url, fallback)
+}
+
+func (db *Database) hrefIndexes() string {
+	return db.hrefOr(db.HrefIndexes, "schema/index")
+}
+
+func (db *Database) hrefConstraints() string {
+	return db.hrefOr(db.HrefConstraints, "schema/constraint")
+}
+
+func (db *Database) hrefNodeLabels() string {
+	return db.hrefOr(db.HrefNodeLabels, "labels")
+}
+
+// hrefPropertyKeys returns the URL of the list of property keys, a sibling of
+// the advertised list of labels.
+func (db *Database) hrefPropertyKeys() string {
+	return join(parent(db.hrefNodeLabels()), "propertykeys")
+}
+
+// hrefLabel returns the URL of the nodes with label, a sibling of the
+// advertised list of labels.
+func (db *Database) hrefLabel(label string) string {
+	return join(parent(db.hrefNodeLabels()), "label", PathEscape(label), "nodes")
+}
+
+// hrefRelationship returns the URL of the relationship with the given ID.  The
+// parent of the advertised relationship types endpoint is the relationship
+// collection.
+func (db *Database) hrefRelationship(id int) string {
+	base := parent(db.HrefRelTypes)
+	if base == "" {
+		base = join(db.Url, "relationship")
+	}
+	return join(base, strconv.Itoa(id))
+}
+
+// serverRoot returns the root URL the server believes it is serving, as
+// revealed by the hrefs it advertises.
+func (db *Database) serverRoot() string {
+	return parent(db.HrefNode)
+}
+
+// rewriteUrl points a request for a URL advertised by the server at db.Url
+// instead, if the server is reached through a proxy under a different URL
+// and db was connected with ConnectOptions.RewriteHrefs.
+func (db *Database) rewriteUrl(rr *restclient.RequestResponse) {
+	if db.rewriteFrom == "" || !strings.HasPrefix(rr.Url, db.rewriteFrom) {
+		return
+	}
+	rr.Url = strings.TrimRight(db.Url, "/") + rr.Url[len(db.rewriteFrom):]
+}
+
+// UnknownHref is returned by OverrideHref for a name which is not an endpoint
+// in the service root document.
+var UnknownHref = errors.New("Unknown service root endpoint name.")
+
+// rootField returns the field of db filled from the service root document
+// entry name, or an invalid Value if there is none.
+func (db *Database) rootField(name string) reflect.Value {
+	v := reflect.ValueOf(db).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if tag == name && tag != "-" {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// OverrideHref replaces the URL of the endpoint called name in the service
+// root document - e.g. "cypher" or "batch" - with href.  The override
+// survives Refresh.  Like the other fields of a Database, hrefs must not be
+// changed while requests are in flight.
+func (db *Database) OverrideHref(name, href string) error {
+	f := db.rootField(name)
+	if !f.IsValid() || f.Kind() != reflect.String {
+		return UnknownHref
+	}
+	f.SetString(href)
+	overrides := map[string]string{name: href}
+	for k, v := range db.overrides {
+		if k != name {
+			overrides[k] = v
+		}
+	}
+	db.overrides = overrides
+	return nil
+}
+
+// Refresh fetches the service root document again, updating the endpoint
+// URLs and Version - for instance after the server has been upgraded or
+// moved.  Hrefs set by OverrideHref are kept, and if the Database was
+// connected with ConnectOptions.RewriteHrefs, the advertised root to rewrite
+// is recomputed from the new URLs.  Connect calls Refresh once; after that
+// the document is only fetched when Refresh is called.  Refresh must not be
+// called while requests are in flight.
+func (db *Database) Refresh() error {
+	root := Database{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    db.Url,
+		Method: "GET",
+		Result: &root,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == 401:
+		return Unauthorized
+	case status == 403 && db.passwordChangeRequired():
+		return PasswordChangeRequired
+	case status != 200 || root.Version == "":
+		logPretty(rr.RawText)
+		log.Println("Status " + strconv.Itoa(status) + " trying to connect to " + db.Url)
+		return InvalidDatabase
+	}
+	v := reflect.ValueOf(db).Elem()
+	r := reflect.ValueOf(root)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("json") == "" || f.Tag.Get("json") == "-" {
+			continue
+		}
+		v.Field(i).Set(r.Field(i))
+	}
+	for name, href := range db.overrides {
+		db.rootField(name).SetString(href)
+	}
+	db.rewriteFrom = ""
+	if db.rewrite && db.serverRoot() != strings.TrimRight(db.Url, "/") {
+		db.rewriteFrom = db.serverRoot()
+	}
+	return nil
+}
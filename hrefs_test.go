@@ -0,0 +1,129 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/restclient"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHrefs(t *testing.T) {
+	// Without a root document, URLs are derived from Url.
+	db := &Database{Url: "http://localhost:7474/db/data/"}
+	assert.Equal(t, "http://localhost:7474/db/data/schema/index", db.hrefIndexes())
+	assert.Equal(t, "http://localhost:7474/db/data/label/a%2Fb/nodes", db.hrefLabel("a/b"))
+	assert.Equal(t, "http://localhost:7474/db/data/relationship/3", db.hrefRelationship(3))
+	assert.Equal(t, "http://localhost:7474/db/data/propertykeys", db.hrefPropertyKeys())
+	// Advertised URLs take precedence.
+	db = &Database{
+		Url:             "http://proxy/neo4j/db/data",
+		HrefNode:        "http://proxy/neo4j/db/data/node",
+		HrefRelTypes:    "http://proxy/neo4j/db/data/relationship/types",
+		HrefConstraints: "http://proxy/neo4j/db/data/schema/constraint",
+		HrefNodeLabels:  "http://proxy/neo4j/db/data/labels",
+	}
+	assert.Equal(t, "http://proxy/neo4j/db/data/schema/constraint", db.hrefConstraints())
+	assert.Equal(t, "http://proxy/neo4j/db/data/label/Person/nodes", db.hrefLabel("Person"))
+	assert.Equal(t, "http://proxy/neo4j/db/data/propertykeys", db.hrefPropertyKeys())
+	assert.Equal(t, "http://proxy/neo4j/db/data/relationship/3", db.hrefRelationship(3))
+	assert.Equal(t, "http://proxy/neo4j/db/data", db.serverRoot())
+}
+
+func TestRewriteUrl(t *testing.T) {
+	db := &Database{
+		Url:      "https://proxy.example.com/graph/db/data/",
+		HrefNode: "http://10.0.0.5:7474/db/data/node",
+	}
+	rr := restclient.RequestResponse{Url: "http://10.0.0.5:7474/db/data/node/7"}
+	db.rewriteUrl(&rr)
+	assert.Equal(t, "http://10.0.0.5:7474/db/data/node/7", rr.Url)
+	db.rewriteFrom = db.serverRoot()
+	db.rewriteUrl(&rr)
+	assert.Equal(t, "https://proxy.example.com/graph/db/data/node/7", rr.Url)
+	// Batch jobs refer to advertised URLs relative to the server's root.
+	assert.Equal(t, "/node/7", db.relPath("http://10.0.0.5:7474/db/data/node/7"))
+}
+
+func TestOverrideHref(t *testing.T) {
+	db := &Database{}
+	err := db.OverrideHref("cypher", "http://elsewhere/cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "http://elsewhere/cypher", db.HrefCypher)
+	assert.Equal(t, UnknownHref, db.OverrideHref("nonsense", "x"))
+	assert.Equal(t, UnknownHref, db.OverrideHref("-", "x"))
+	assert.Equal(t, UnknownHref, db.OverrideHref("extensions", "x"))
+	// Copies made by WithHeaders etc do not share overrides.
+	c := db.WithPriority(Bulk)
+	c.OverrideHref("batch", "http://elsewhere/batch")
+	assert.Equal(t, 1, len(db.overrides))
+}
+
+func TestSkipDiscovery(t *testing.T) {
+	db, err := ConnectWithOptions("http://127.0.0.1:1/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"node": "http://127.0.0.1:1/db/data/node"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "http://127.0.0.1:1/db/data/node", db.HrefNode)
+	assert.Equal(t, "", db.Version)
+	_, err = ConnectWithOptions("http://127.0.0.1:1/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"nodes": "x"},
+	})
+	assert.Equal(t, UnknownHref, err)
+}
+
+func TestRefresh(t *testing.T) {
+	db, err := ConnectWithOptions("http://localhost:7474/db/data", &ConnectOptions{
+		Hrefs: map[string]string{"batch": "http://localhost:7474/db/data/batch/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "http://localhost:7474/db/data/batch/", db.HrefBatch)
+	cypher := db.HrefCypher
+	db.HrefCypher = ""
+	db.Version = ""
+	err = db.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cypher, db.HrefCypher)
+	assert.NotEqual(t, "", db.Version)
+	assert.Equal(t, "http://localhost:7474/db/data/batch/", db.HrefBatch)
+}
+
+func TestRefreshRewrite(t *testing.T) {
+	advertised := "http://10.0.0.5:7474/db/data"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"neo4j_version": "2.3.0", "node": "`+advertised+`/node"}`)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{RewriteHrefs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "http://10.0.0.5:7474/db/data", db.rewriteFrom)
+	// The server moves behind the proxy
+	advertised = "http://10.0.0.6:7474/db/data"
+	err = db.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "http://10.0.0.6:7474/db/data", db.rewriteFrom)
+	// Until it is reached directly
+	advertised = srv.URL + "/db/data"
+	db.Refresh()
+	assert.Equal(t, "", db.rewriteFrom)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// An ImportNode is a node to be loaded by Import, identified by an external
+// key rather than by its Neo4j ID.
+type ImportNode struct {
+	Key   string
+	Props Props
+}
+
+// An ImportEdge is a relationship to be loaded by Import.  From and To are the
+// external keys of its start and end nodes.
+type ImportEdge struct {
+	From  string
+	To    string
+	Type  string
+	Props Props
+}
+
+// An ImportResult reports the outcome of an Import.
+type ImportResult struct {
+	Nodes         map[string]int // Node IDs keyed by external key
+	Relationships []*Relationship
+	Unresolved    []ImportEdge // Edges with an endpoint that could not be found
+}
+
+// Import loads a graph in two phases.  First each node is merged on its
+// external key, stored as property keyProp on nodes with the given label and
+// its ancestors in the Database's Vocabulary, so re-importing a node updates
+// it rather than duplicating it.  Then edges are resolved to node IDs and
+// created in a single batch.  Edges may refer to nodes appearing anywhere in
+// nodes, or to nodes already in the database; edges whose endpoints cannot be
+// found are skipped and reported in ImportResult.Unresolved.
+func (db *Database) Import(label, keyProp string, nodes []ImportNode, edges []ImportEdge) (*ImportResult, error) {
+	ir := &ImportResult{
+		Nodes:         make(map[string]int, len(nodes)),
+		Relationships: []*Relationship{},
+		Unresolved:    []ImportEdge{},
+	}
+	labels, err := db.Vocabulary.expandLabels(label)
+	if err != nil {
+		return ir, err
+	}
+	//
+	// Phase one - nodes
+	//
+	stmt := "MERGE (n:" + quoteIdent(label) + " {" + quoteIdent(keyProp) + ": {key}}) " +
+		"SET n = {props} "
+	if len(labels) > 1 {
+		stmt += "SET n" + labelExpr(labels[1:]) + " "
+	}
+	stmt += "RETURN id(n) AS id"
+	type idRow struct {
+		Id int `json:"id"`
+	}
+	qs := make([]*CypherQuery, len(nodes))
+	results := make([][]idRow, len(nodes))
+	for i, n := range nodes {
+		props := Props{}
+		for k, v := range n.Props {
+			props[k] = v
+		}
+		props[keyProp] = n.Key
+		qs[i] = &CypherQuery{
+			Statement:  stmt,
+			Parameters: Props{"key": n.Key, "props": props},
+			Result:     &results[i],
+		}
+	}
+	if len(qs) > 0 {
+		err = db.CypherBatch(qs)
+		if err != nil {
+			return ir, err
+		}
+	}
+	for i, n := range nodes {
+		if len(results[i]) == 1 {
+			ir.Nodes[n.Key] = results[i][0].Id
+		}
+	}
+	//
+	// Phase two - edges
+	//
+	ids, err := db.resolveKeys(label, keyProp, edges, ir.Nodes)
+	if err != nil {
+		return ir, err
+	}
+	specs := []RelSpec{}
+	for _, e := range edges {
+		start, ok0 := ids[e.From]
+		end, ok1 := ids[e.To]
+		if !ok0 || !ok1 {
+			ir.Unresolved = append(ir.Unresolved, e)
+			continue
+		}
+		specs = append(specs, RelSpec{Start: start, End: end, Type: e.Type, Props: e.Props})
+	}
+	rels, err := db.CreateRelationships(specs)
+	if err != nil {
+		return ir, err
+	}
+	ir.Relationships = rels
+	return ir, nil
+}
+
+// resolveKeys maps every external key referenced by edges to a node ID,
+// consulting known first and then looking up the remainder in the database.
+// Keys which cannot be found are omitted from the result.
+func (db *Database) resolveKeys(label, keyProp string, edges []ImportEdge, known map[string]int) (map[string]int, error) {
+	ids := make(map[string]int, len(known))
+	for k, id := range known {
+		ids[k] = id
+	}
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, e := range edges {
+		for _, k := range []string{e.From, e.To} {
+			if _, ok := ids[k]; !ok && !seen[k] {
+				seen[k] = true
+				missing = append(missing, k)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return ids, nil
+	}
+	res := []struct {
+		Key string `json:"key"`
+		Id  int    `json:"id"`
+	}{}
+	cq := CypherQuery{
+		Statement: "MATCH (n:" + quoteIdent(label) + ") WHERE n." + quoteIdent(keyProp) +
+			" IN {keys} RETURN n." + quoteIdent(keyProp) + " AS key, id(n) AS id",
+		Parameters: Props{"keys": missing},
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return ids, err
+	}
+	for _, r := range res {
+		ids[r.Key] = r.Id
+	}
+	return ids, nil
+}
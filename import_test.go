@@ -0,0 +1,49 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	// An existing node, referenced by an edge but not imported
+	existing := CypherQuery{
+		Statement:  "CREATE (n:" + label + " {ext: 'c'})",
+		Parameters: Props{},
+	}
+	db.Cypher(&existing)
+	nodes := []ImportNode{
+		ImportNode{Key: "a", Props: Props{"name": "Alice"}},
+		ImportNode{Key: "b", Props: Props{"name": "Bob"}},
+	}
+	edges := []ImportEdge{
+		ImportEdge{From: "a", To: "b", Type: "knows"},
+		ImportEdge{From: "b", To: "c", Type: "knows"},
+		ImportEdge{From: "a", To: "nobody", Type: "knows"},
+	}
+	ir, err := db.Import(label, "ext", nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(ir.Nodes))
+	assert.Equal(t, 2, len(ir.Relationships))
+	assert.Equal(t, []ImportEdge{edges[2]}, ir.Unresolved)
+	a, _ := db.Node(ir.Nodes["a"])
+	props, _ := a.Properties()
+	assert.Equal(t, Props{"name": "Alice", "ext": "a"}, props)
+	//
+	// Re-importing merges rather than duplicating nodes
+	//
+	ir2, err := db.Import(label, "ext", nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ir.Nodes, ir2.Nodes)
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strconv"
+)
+
+// JobLabel is the label of the nodes in which Jobs record their state.
+const JobLabel = "MaintenanceJob"
+
+// A JobState is the progress of a Job, as persisted in the graph.
+type JobState struct {
+	Checkpoint string `json:"checkpoint"` // Where the next step starts; empty at first
+	Processed  int    `json:"processed"`  // Items processed over all runs
+	Done       bool   `json:"done"`
+}
+
+// A Job is a long-running maintenance task made of steps, whose state is
+// saved after each step in a node with label JobLabel and property name.
+// A Job which is interrupted - by an error, or by the process exiting -
+// resumes from its last saved state when next run, by this or any other
+// process.  Jobs with the same name share their state, and must not be run
+// concurrently.
+type Job struct {
+	db   *Database
+	Name string
+	// DryRun, if set, starts the job from its saved state, if any, but never
+	// saves its progress, so that a later real run does all the work.
+	DryRun bool
+	// Step performs the next unit of work from s.Checkpoint, advancing s,
+	// and reports whether the job is complete.  If it returns an error s is
+	// not saved, so the step is retried when the job is next run.
+	Step func(s *JobState) (done bool, err error)
+}
+
+// Job returns the Job called name, performing step.
+func (db *Database) Job(name string, step func(s *JobState) (bool, error)) *Job {
+	return &Job{db: db, Name: name, Step: step}
+}
+
+// State fetches the saved state of the job, creating it if need be - unless
+// the job is a dry run.
+func (j *Job) State() (*JobState, error) {
+	clause := "MERGE"
+	if j.DryRun {
+		clause = "MATCH"
+	}
+	res := []JobState{}
+	cq := CypherQuery{
+		Statement: clause + " (j:" + quoteIdent(JobLabel) + " {name: {name}}) " +
+			"RETURN j.checkpoint AS checkpoint, j.processed AS processed, j.done AS done",
+		Parameters: Props{"name": j.Name},
+		Result:     &res,
+	}
+	err := j.db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		if j.DryRun {
+			return &JobState{}, nil
+		}
+		return nil, NotFound
+	}
+	return &res[0], nil
+}
+
+// save persists s as the state of the job.
+func (j *Job) save(s *JobState) error {
+	cq := CypherQuery{
+		Statement: "MERGE (j:" + quoteIdent(JobLabel) + " {name: {name}}) " +
+			"SET j.checkpoint = {checkpoint}, j.processed = {processed}, j.done = {done}, j.updated = timestamp()",
+		Parameters: Props{
+			"name":       j.Name,
+			"checkpoint": s.Checkpoint,
+			"processed":  s.Processed,
+			"done":       s.Done,
+		},
+	}
+	return j.db.Cypher(&cq)
+}
+
+// Run performs the remaining steps of the job, saving its state after each
+// unless the job is a dry run, and returns the state reached.  Running a
+// completed job does nothing.
+func (j *Job) Run() (*JobState, error) {
+	s, err := j.State()
+	if err != nil {
+		return nil, err
+	}
+	for !s.Done {
+		next := *s
+		done, err := j.Step(&next)
+		if err != nil {
+			return s, err
+		}
+		next.Done = done
+		if !j.DryRun {
+			err = j.save(&next)
+			if err != nil {
+				return s, err
+			}
+		}
+		s = &next
+	}
+	return s, nil
+}
+
+// Reset deletes the saved state of the job, so that it starts afresh when
+// next run.
+func (j *Job) Reset() error {
+	cq := CypherQuery{
+		Statement:  "MATCH (j:" + quoteIdent(JobLabel) + " {name: {name}}) DELETE j",
+		Parameters: Props{"name": j.Name},
+	}
+	return j.db.Cypher(&cq)
+}
+
+// Job returns a Job called name which runs the backfill one batch per step,
+// checkpointing the ID of the last node migrated.  The backfill's After is
+// overridden by the job's checkpoint.  If the backfill is a dry run, so is
+// the job, and its progress is not saved.
+func (b *Backfill) Job(name string) *Job {
+	j := b.db.Job(name, func(s *JobState) (bool, error) {
+		b.After = -1
+		if s.Checkpoint != "" {
+			after, err := strconv.Atoi(s.Checkpoint)
+			if err != nil {
+				return false, err
+			}
+			b.After = after
+		}
+		p := &BackfillProgress{Checkpoint: b.After}
+		done, err := b.step(p)
+		if err != nil {
+			return false, err
+		}
+		if p.Processed > 0 {
+			s.Checkpoint = strconv.Itoa(p.Checkpoint)
+			s.Processed += p.Processed
+		}
+		return done, nil
+	})
+	j.DryRun = b.DryRun
+	return j
+}
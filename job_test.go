@@ -0,0 +1,124 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bmizerany/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJob(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	fail := errors.New("fail")
+	steps := []string{}
+	j := db.Job(rndStr(t), func(s *JobState) (bool, error) {
+		i, _ := strconv.Atoi(s.Checkpoint)
+		if i == 2 && len(steps) == 2 {
+			steps = append(steps, "failed")
+			return false, fail
+		}
+		steps = append(steps, s.Checkpoint)
+		s.Checkpoint = strconv.Itoa(i + 1)
+		s.Processed++
+		return i+1 == 4, nil
+	})
+	defer j.Reset()
+	s, err := j.Run()
+	assert.Equal(t, fail, err)
+	assert.Equal(t, JobState{Checkpoint: "2", Processed: 2}, *s)
+	//
+	// Another Job of the same name resumes from the saved state
+	//
+	j2 := db.Job(j.Name, j.Step)
+	s, err = j2.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, JobState{Checkpoint: "4", Processed: 4, Done: true}, *s)
+	assert.Equal(t, []string{"", "1", "failed", "2", "3"}, steps)
+	s, _ = j2.Run()
+	assert.Equal(t, 5, len(steps))
+	err = j2.Reset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ = j2.State()
+	assert.Equal(t, JobState{}, *s)
+}
+
+func TestBackfillJobDryRun(t *testing.T) {
+	stmts := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := cypherRequest{}
+		json.NewDecoder(r.Body).Decode(&q)
+		stmts = append(stmts, q.Query)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"columns": [], "data": []}`)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"cypher": srv.URL + "/db/data/cypher"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := db.Backfill("Person")
+	b.Set = "n.done = true"
+	b.DryRun = true
+	j := b.Job("migrate")
+	assert.T(t, j.DryRun)
+	s, err := j.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, JobState{Done: true}, *s)
+	for _, stmt := range stmts {
+		assert.Tf(t, !strings.Contains(stmt, "MERGE") && !strings.Contains(stmt, "SET"), "Dry run wrote: %s", stmt)
+	}
+	assert.Equal(t, 2, len(stmts))
+}
+
+func TestBackfillJob(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	nodes := []*Node{}
+	for i := 0; i < 3; i++ {
+		n, _ := db.CreateNode(Props{"i": i})
+		n.AddLabel(label)
+		nodes = append(nodes, n)
+	}
+	name := rndStr(t)
+	// A dry run under the same name leaves the real run all the work
+	b := db.Backfill(label)
+	b.Set = "n.done = true"
+	b.DryRun = true
+	s, err := b.Job(name).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, s.Processed)
+	b = db.Backfill(label)
+	b.BatchSize = 2
+	b.Set = "n.done = true"
+	j := b.Job(name)
+	defer j.Reset()
+	s, err = j.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, JobState{Checkpoint: strconv.Itoa(nodes[2].Id()), Processed: 3, Done: true}, *s)
+	props, _ := nodes[2].Properties()
+	assert.Equal(t, Props{"i": 2.0, "done": true}, props)
+}
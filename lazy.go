@@ -0,0 +1,117 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strconv"
+)
+
+// NodeHandle returns a lightweight handle to the node with the given ID,
+// without making a request to the server.  The handle's hrefs are populated,
+// so it can be used for any operation, but its Data is nil until Fetch is
+// called.  NodeHandle does not check that the node exists.
+//
+// Only NodeHandle and NodeHandlesByLabel return handles.  Other lookups, such
+// as NodesByLabel, Find, Query and Relationships, always return hydrated
+// entities, because the server includes their properties in the response
+// whether or not they are wanted.
+func (db *Database) NodeHandle(id int) *Node {
+	self := join(db.HrefNode, strconv.Itoa(id))
+	n := Node{
+		HrefOutgoingRels:      join(self, "relationships/out"),
+		HrefTraverse:          join(self, "traverse/{returnType}"),
+		HrefAllTypedRels:      join(self, "relationships/all/{-list|&|types}"),
+		HrefOutgoing:          join(self, "relationships/out/{-list|&|types}"),
+		HrefIncomingRels:      join(self, "relationships/in"),
+		HrefCreateRel:         join(self, "relationships"),
+		HrefPagedTraverse:     join(self, "paged/traverse/{returnType}{?pageSize,leaseTime}"),
+		HrefAllRels:           join(self, "relationships/all"),
+		HrefIncomingTypedRels: join(self, "relationships/in/{-list|&|types}"),
+		HrefLabels:            join(self, "labels"),
+	}
+	n.Db = db
+	n.HrefSelf = self
+	n.HrefProperty = join(self, "properties/{key}")
+	n.HrefProperties = join(self, "properties")
+	return &n
+}
+
+// Hydrated reports whether the node's properties have been fetched.
+func (n *Node) Hydrated() bool {
+	return n.Data != nil
+}
+
+// Fetch hydrates the node, replacing its properties and hrefs with those
+// currently stored in the database.
+func (n *Node) Fetch() error {
+	fresh, err := n.Db.getNodeByUri(n.HrefSelf)
+	if err != nil {
+		return err
+	}
+	*n = *fresh
+	if n.Data == nil {
+		n.Data = map[string]interface{}{}
+	}
+	return nil
+}
+
+// FetchNodes hydrates many nodes with a single batch request.
+func (db *Database) FetchNodes(nodes []*Node) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	jobs := make([]*batchJob, len(nodes))
+	for i, n := range nodes {
+		jobs[i] = &batchJob{
+			Method: "GET",
+			To:     db.relPath(n.HrefSelf),
+			Id:     i,
+		}
+	}
+	res, err := db.batch(jobs)
+	if err != nil {
+		return err
+	}
+	for i, r := range res {
+		// Decode into a fresh Node, so properties deleted since a previous
+		// fetch do not linger.
+		fresh := Node{}
+		err := db.codec().Unmarshal(r.Body, &fresh)
+		if err != nil {
+			return err
+		}
+		fresh.Db = db
+		if fresh.Data == nil {
+			fresh.Data = map[string]interface{}{}
+		}
+		*nodes[i] = fresh
+	}
+	return nil
+}
+
+// NodeHandlesByLabel returns handles to all nodes with a given label.  Only
+// node IDs are transferred from the server, unless eager is true, in which
+// case the nodes are hydrated with a single additional batch request.
+func (db *Database) NodeHandlesByLabel(label string, eager bool) ([]*Node, error) {
+	res := []struct {
+		Id int `json:"id"`
+	}{}
+	cq := CypherQuery{
+		Statement: "MATCH (n:" + quoteIdent(label) + ") RETURN id(n) AS id",
+		Result:    &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*Node, len(res))
+	for i, r := range res {
+		nodes[i] = db.NodeHandle(r.Id)
+	}
+	if eager {
+		err = db.FetchNodes(nodes)
+	}
+	return nodes, err
+}
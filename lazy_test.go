@@ -0,0 +1,101 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestNodeHandleHrefs(t *testing.T) {
+	db := &Database{HrefNode: "http://localhost:7474/db/data/node"}
+	h := db.NodeHandle(5)
+	self := "http://localhost:7474/db/data/node/5"
+	assert.Equal(t, self, h.HrefSelf)
+	assert.Equal(t, self+"/properties", h.HrefProperties)
+	assert.Equal(t, self+"/relationships/out/{-list|&|types}", h.HrefOutgoing)
+	assert.Equal(t, self+"/labels", h.HrefLabels)
+	assert.Equal(t, self+"/properties/{key}", h.HrefProperty)
+	assert.Equal(t, self+"/paged/traverse/{returnType}{?pageSize,leaseTime}", h.HrefPagedTraverse)
+	assert.Equal(t, self+"/relationships/in", h.HrefIncomingRels)
+	assert.Equal(t, db, h.Db)
+	assert.Equal(t, 5, h.Id())
+	assert.T(t, !h.Hydrated())
+}
+
+func TestNodeHandle(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{"name": "kirk"})
+	n1, _ := db.CreateNode(Props{})
+	n0.Relate("knows", n1.Id(), nil)
+	h := db.NodeHandle(n0.Id())
+	assert.Equal(t, n0.HrefSelf, h.HrefSelf)
+	assert.Equal(t, n0.HrefLabels, h.HrefLabels)
+	assert.Equal(t, n0.HrefAllTypedRels, h.HrefAllTypedRels)
+	assert.Equal(t, n0.HrefProperty, h.HrefProperty)
+	assert.Equal(t, n0.HrefPagedTraverse, h.HrefPagedTraverse)
+	assert.Equal(t, n0.HrefCreateRel, h.HrefCreateRel)
+	assert.T(t, !h.Hydrated())
+	rels, err := h.Outgoing("knows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(rels))
+	err = h.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.T(t, h.Hydrated())
+	assert.Equal(t, "kirk", h.Data["name"])
+}
+
+func TestNodeHandlesByLabel(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	for i := 0; i < 3; i++ {
+		n, _ := db.CreateNode(Props{"i": i})
+		n.AddLabel(label)
+	}
+	lazy, err := db.NodeHandlesByLabel(label, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(lazy))
+	assert.T(t, !lazy[0].Hydrated())
+	eager, err := db.NodeHandlesByLabel(label, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(eager))
+	for _, n := range eager {
+		assert.T(t, n.Hydrated())
+		assert.NotEqual(t, nil, n.Data["i"])
+	}
+}
+
+func TestFetchNodesReplacesData(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{"name": "kirk", "rank": "captain"})
+	h := db.NodeHandle(n0.Id())
+	err := db.FetchNodes([]*Node{h})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "captain", h.Data["rank"])
+	err = n0.DeleteProperty("rank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.FetchNodes([]*Node{h})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok := h.Data["rank"]
+	assert.T(t, !ok)
+	assert.Equal(t, "kirk", h.Data["name"])
+}
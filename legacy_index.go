@@ -5,8 +5,12 @@
 package neo4j
 
 import (
+	"fmt"
 	"github.com/jmcvetta/restclient"
 	"net/url"
+	"reflect"
+	"strconv"
+	"time"
 )
 
 func (db *Database) createIndex(href, name, idxType, provider string) (*index, error) {
@@ -41,7 +45,7 @@ func (db *Database) createIndex(href, name, idxType, provider string) (*index, e
 		Error:          &ne,
 		ExpectedStatus: 201,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		logPretty(err)
 		return nil, err
@@ -64,7 +68,7 @@ func (db *Database) indexes(href string) ([]*index, error) {
 		Result: &res,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&req)
+	status, err := db.do(&req)
 	if err != nil {
 		return nis, err
 	}
@@ -76,6 +80,7 @@ func (db *Database) indexes(href string) ([]*index, error) {
 		n := index{}
 		n.db = db
 		n.Name = name
+		n.HrefIndex = href
 		n.populate(&r)
 		nis = append(nis, &n)
 	}
@@ -88,18 +93,18 @@ func (db *Database) index(href, name string) (*index, error) {
 	idx.Name = name
 	idx.HrefIndex = href
 	baseUri := href
-	rawurl := join(baseUri, name)
-	u, err := url.ParseRequestURI(rawurl)
+	rawurl := join(baseUri, PathEscape(name))
+	_, err := url.ParseRequestURI(rawurl)
 	if err != nil {
 		return idx, err
 	}
 	ne := NeoError{}
 	req := restclient.RequestResponse{
-		Url:    u.String(),
+		Url:    rawurl,
 		Method: "GET",
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&req)
+	status, err := db.do(&req)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +127,11 @@ type index struct {
 	IndexType     string
 	CaseSensitive bool
 	HrefIndex     string
+	// Raw, if set, has FindRange pass its key to Lucene as given, for callers
+	// which escape it already.  Otherwise it is escaped to match literally.
+	// Find and Contains look entries up by URL path, and need no escaping.
+	Raw  bool
+	poll time.Duration // How often WaitFor checks the index; 0 means indexPoll
 }
 
 func (idx *index) populate(res *indexResponse) {
@@ -143,9 +153,9 @@ type indexResponse struct {
 
 // uri returns the URI for this Index.
 func (idx *index) uri() (string, error) {
-	s := join(idx.HrefIndex, idx.Name)
-	u, err := url.ParseRequestURI(s)
-	return u.String(), err
+	s := join(idx.HrefIndex, PathEscape(idx.Name))
+	_, err := url.ParseRequestURI(s)
+	return s, err
 }
 
 // Delete removes a index from the database.
@@ -160,7 +170,7 @@ func (idx *index) Delete() error {
 		Method: "DELETE",
 		Error:  &ne,
 	}
-	status, err := idx.db.Rc.Do(&req)
+	status, err := idx.db.do(&req)
 	if err != nil {
 		return err
 	}
@@ -171,6 +181,259 @@ func (idx *index) Delete() error {
 	return nil // Success!
 }
 
+// removeUri returns the URI for removing index entries for the entity with
+// the given id, optionally restricted to a key or a key and value.
+func (idx *index) removeUri(id, key, value string) (string, error) {
+	uri, err := idx.uri()
+	if err != nil {
+		return "", err
+	}
+	// Since join() ignores fragments that are empty strings, joining an empty
+	// value with a non-empty key produces a valid URL.  But joining a non-empty
+	// value with an empty key would produce an invalid URL wherein they value is
+	// conflated with the key.
+	if key != "" {
+		uri = join(uri, PathEscape(key), PathEscape(value))
+	}
+	return join(uri, id), nil
+}
+
+// entityUri returns the URI of the node or relationship, as appropriate to
+// this index, with the given ID.
+func (idx *index) entityUri(id int) string {
+	if idx.HrefIndex == idx.db.HrefRelIndex {
+		return idx.db.hrefRelationship(id)
+	}
+	return join(idx.db.HrefNode, strconv.Itoa(id))
+}
+
+// An IndexEntry associates the node or relationship with the given ID with a
+// key/value pair in a legacy index.
+type IndexEntry struct {
+	Id    int
+	Key   string
+	Value interface{}
+}
+
+// indexValues returns the strings under which value v may be stored in an
+// index: that of v as given to Add, and for a number that of v as given to
+// AddNumeric.
+func indexValues(v interface{}) []string {
+	values := []string{fmt.Sprint(v)}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		values = append(values, SortableFloat(float64(rv.Int())))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		values = append(values, SortableFloat(float64(rv.Uint())))
+	case reflect.Float32, reflect.Float64:
+		values = append(values, SortableFloat(rv.Float()))
+	}
+	return values
+}
+
+// AddMany adds entries to the index in a single batch request.  Either all
+// entries are added or none are.
+func (idx *index) AddMany(entries []IndexEntry) error {
+	jobs := make([]*batchJob, len(entries))
+	for i, e := range entries {
+		j, err := idx.addJob(e)
+		if err != nil {
+			return err
+		}
+		j.Id = i
+		jobs[i] = j
+	}
+	return idx.runBatch(jobs)
+}
+
+// RemoveMany removes entries from the index in a single batch request.  As
+// with Remove, an entry with a blank Value removes all entries for its Id and
+// Key, and an entry with a blank Key removes all entries for its Id.
+func (idx *index) RemoveMany(entries []IndexEntry) error {
+	jobs := make([]*batchJob, len(entries))
+	for i, e := range entries {
+		j, err := idx.removeJob(e)
+		if err != nil {
+			return err
+		}
+		j.Id = i
+		jobs[i] = j
+	}
+	return idx.runBatch(jobs)
+}
+
+// addJob returns a batch job adding e to the index.
+func (idx *index) addJob(e IndexEntry) (*batchJob, error) {
+	uri, err := idx.uri()
+	if err != nil {
+		return nil, err
+	}
+	j := &batchJob{
+		Method: "POST",
+		To:     idx.db.relPath(uri),
+		Body: map[string]interface{}{
+			"uri":   idx.entityUri(e.Id),
+			"key":   e.Key,
+			"value": e.Value,
+		},
+	}
+	return j, nil
+}
+
+// removeJob returns a batch job removing e from the index.
+func (idx *index) removeJob(e IndexEntry) (*batchJob, error) {
+	value := ""
+	if e.Value != nil {
+		value = fmt.Sprint(e.Value)
+	}
+	uri, err := idx.removeUri(strconv.Itoa(e.Id), e.Key, value)
+	if err != nil {
+		return nil, err
+	}
+	return &batchJob{Method: "DELETE", To: idx.db.relPath(uri)}, nil
+}
+
+// set replaces any entries for the entity with the given id and key with one
+// for value, in a single batch request.
+func (idx *index) set(id int, key string, value interface{}) error {
+	rm, err := idx.removeJob(IndexEntry{Id: id, Key: key})
+	if err != nil {
+		return err
+	}
+	add, err := idx.addJob(IndexEntry{Id: id, Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	add.Id = 1
+	return idx.runBatch([]*batchJob{rm, add})
+}
+
+// GC removes every entry in the index for a node or relationship which has
+// been deleted, in a single batch request, and returns the IDs whose entries
+// were removed.  Stale entries otherwise make lookups return references to
+// entities which can no longer be fetched.
+func (idx *index) GC() ([]int, error) {
+	ids, err := idx.danglingIds()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]IndexEntry, len(ids))
+	for i, id := range ids {
+		entries[i] = IndexEntry{Id: id}
+	}
+	err = idx.RemoveMany(entries)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// numericRange returns the query for entries added with AddNumeric under key,
+// with values within the inclusive range [min, max].
+func (idx *index) numericRange(key string, min, max float64) string {
+	if idx.Raw {
+		return key + ":[" + SortableFloat(min) + " TO " + SortableFloat(max) + "]"
+	}
+	return LuceneNumericRange(key, min, max)
+}
+
+// queryScored runs a query ordered by relevance, decoding the hits, each with
+// its score, into result.
+func (idx *index) queryScored(query string, result interface{}) error {
+	uri, err := idx.uri()
+	if err != nil {
+		return err
+	}
+	v := url.Values{"query": {query}, "order": {"score"}}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    uri + "?" + v.Encode(),
+		Method: "GET",
+		Result: result,
+		Error:  &ne,
+	}
+	status, err := idx.db.do(&rr)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		logPretty(ne)
+		return ne
+	}
+	return nil
+}
+
+// indexPoll is how often WaitFor checks the index by default.
+const indexPoll = 50 * time.Millisecond
+
+// Contains reports whether the index has entry e.  A numeric Value matches
+// entries added with either Add or AddNumeric.
+func (idx *index) Contains(e IndexEntry) (bool, error) {
+	uri, err := idx.uri()
+	if err != nil {
+		return false, err
+	}
+	for _, value := range indexValues(e.Value) {
+		res := []struct {
+			Self string `json:"self"`
+		}{}
+		ne := NeoError{}
+		rr := restclient.RequestResponse{
+			Url:    join(uri, PathEscape(e.Key), PathEscape(value)),
+			Method: "GET",
+			Result: &res,
+			Error:  &ne,
+		}
+		status, err := idx.db.do(&rr)
+		if err != nil {
+			return false, err
+		}
+		if status != 200 {
+			logPretty(ne)
+			return false, ne
+		}
+		for _, r := range res {
+			id, err := idFromHref(r.Self)
+			if err == nil && id == e.Id {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// WaitFor polls the index until it has entry e, for reading through the index
+// straight after writing to it: additions are not always visible at once.  It
+// fails with IndexTimeout if the entry is not found within timeout.
+func (idx *index) WaitFor(e IndexEntry, timeout time.Duration) error {
+	poll := idx.poll
+	if poll <= 0 {
+		poll = indexPoll
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := idx.Contains(e)
+		if err != nil || ok {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return IndexTimeout
+		}
+		time.Sleep(poll)
+	}
+}
+
+// runBatch executes jobs in a single request, so that either all succeed or
+// none do, skipping the request entirely if there are none.
+func (idx *index) runBatch(jobs []*batchJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	_, err := idx.db.sendBatch(jobs)
+	return err
+}
+
 // Add associates a Node with the given key/value pair in the given index.
 func (idx *index) add(e entity, key string, value interface{}) error {
 	uri, err := idx.uri()
@@ -194,7 +457,7 @@ func (idx *index) add(e entity, key string, value interface{}) error {
 		Data:   data,
 		Error:  &ne,
 	}
-	status, err := idx.db.Rc.Do(&req)
+	status, err := idx.db.do(&req)
 	if err != nil {
 		return err
 	}
@@ -206,25 +469,17 @@ func (idx *index) add(e entity, key string, value interface{}) error {
 }
 
 func (idx *index) remove(e entity, id, key, value string) error {
-	uri, err := idx.uri()
+	uri, err := idx.removeUri(id, key, value)
 	if err != nil {
 		return err
 	}
-	// Since join() ignores fragments that are empty strings, joining an empty
-	// value with a non-empty key produces a valid URL.  But joining a non-empty
-	// value with an empty key would produce an invalid URL wherein they value is
-	// conflated with the key.
-	if key != "" {
-		uri = join(uri, key, value)
-	}
-	uri = join(uri, id)
 	ne := NeoError{}
 	req := restclient.RequestResponse{
 		Url:    uri,
 		Method: "DELETE",
 		Error:  &ne,
 	}
-	status, err := idx.db.Rc.Do(&req)
+	status, err := idx.db.do(&req)
 	if err != nil {
 		return err
 	}
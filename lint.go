@@ -0,0 +1,60 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"log"
+	"reflect"
+	"regexp"
+)
+
+var (
+	// lintIdent matches quoted identifiers, which may contain anything.
+	lintIdent = regexp.MustCompile("`[^`]*`")
+	// lintLiteral matches string literals, and numbers compared against.
+	lintLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|(?:=|<>|<=|>=|<|>)\s*-?\d+(?:\.\d+)?\b`)
+	// paramName matches valid parameter names.
+	paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// lint returns InlineLiteral, and logs the offending literal, if db.Lint is
+// set and any of qs has parameters, but also values written into its
+// statement - a sign that they were built by string concatenation, which
+// risks Cypher injection and defeats the server's plan cache.
+func (db *Database) lint(qs ...*CypherQuery) error {
+	if !db.Lint {
+		return nil
+	}
+	for _, q := range qs {
+		if len(q.Parameters) == 0 {
+			continue
+		}
+		stmt := lintIdent.ReplaceAllString(q.Statement, "``")
+		if lit := lintLiteral.FindString(stmt); lit != "" {
+			log.Printf("neo4j: inline literal %s in: %s", lit, q.Statement)
+			return InlineLiteral
+		}
+	}
+	return nil
+}
+
+// MustParam binds value to parameter name in params, returning the
+// placeholder to write in the statement in its place:
+//
+//	params := map[string]interface{}{}
+//	stmt := "MATCH (n:Person) WHERE n.name = " + MustParam(params, "name", name) + " RETURN n"
+//
+// It panics if name is not a valid parameter name, or is already bound to a
+// different value.
+func MustParam(params map[string]interface{}, name string, value interface{}) string {
+	if !paramName.MatchString(name) {
+		panic("neo4j: invalid parameter name " + name)
+	}
+	if old, ok := params[name]; ok && !reflect.DeepEqual(old, value) {
+		panic("neo4j: parameter " + name + " is already bound")
+	}
+	params[name] = value
+	return "{" + name + "}"
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	db := &Database{Lint: true}
+	params := map[string]interface{}{"age": 42}
+	bad := []string{
+		"MATCH (n) WHERE n.age = {age} AND n.name = 'Bob' RETURN n",
+		`MATCH (n) WHERE n.age = {age} AND n.name = "Bob" RETURN n`,
+		"MATCH (n) WHERE n.age = {age} AND id(n) = 17 RETURN n",
+		"MATCH (n) WHERE n.age >= -1.5 AND n.x = {age} RETURN n",
+	}
+	for _, stmt := range bad {
+		assert.Equal(t, InlineLiteral, db.lint(&CypherQuery{Statement: stmt, Parameters: params}), stmt)
+	}
+	good := []*CypherQuery{
+		&CypherQuery{Statement: "MATCH (n:`it's`) WHERE n.age = {age} RETURN n LIMIT 10", Parameters: params},
+		&CypherQuery{Statement: "MATCH (n) WHERE n.name = 'Bob' RETURN n"},
+	}
+	assert.Equal(t, nil, db.lint(good...))
+	db.Lint = false
+	assert.Equal(t, nil, db.lint(&CypherQuery{Statement: bad[0], Parameters: params}))
+}
+
+func TestMustParam(t *testing.T) {
+	params := map[string]interface{}{}
+	stmt := "MATCH (n) WHERE n.name = " + MustParam(params, "name", "Bob") + " RETURN n"
+	assert.Equal(t, "MATCH (n) WHERE n.name = {name} RETURN n", stmt)
+	assert.Equal(t, "{name}", MustParam(params, "name", "Bob"))
+	assert.Equal(t, map[string]interface{}{"name": "Bob"}, params)
+	panics := func(name string, value interface{}) (p bool) {
+		defer func() { p = recover() != nil }()
+		MustParam(params, name, value)
+		return
+	}
+	assert.T(t, panics("name", "Eve"))
+	assert.T(t, panics("x}) DETACH DELETE n //", 1))
+}
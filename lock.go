@@ -0,0 +1,81 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"time"
+)
+
+// LockLabel is the label of the nodes representing Locks.
+const LockLabel = "Lock"
+
+// A Lock is a named mutual exclusion lock held in the graph, for coordinating
+// processes which share a database.  Each lock is a node with label LockLabel,
+// unique by name, recording a random token identifying its holder and the
+// time it expires.  A lock which has expired may be taken by anyone, so a
+// holder which crashes does not block others for longer than the lock's TTL;
+// conversely, a holder must finish its work, or Refresh the lock, before the
+// lock expires.  Expiry is judged by the server's clock.
+type Lock struct {
+	db    *Database
+	Name  string
+	token string
+	ttl   time.Duration
+}
+
+// Lock tries to take the lock called name for ttl.  It does not wait: if the
+// lock is held by someone else, LockHeld is returned.
+func (db *Database) Lock(name string, ttl time.Duration) (*Lock, error) {
+	err := db.ensureUnique(LockLabel, "name")
+	if err != nil {
+		return nil, err
+	}
+	l := &Lock{db: db, Name: name, token: newRequestId(), ttl: ttl}
+	// Taking the node's write lock before its expiry is read means two
+	// processes cannot both take an expired lock.
+	ok, err := l.update("MERGE (l:" + quoteIdent(LockLabel) + " {name: {name}}) " + writeLock("l") +
+		"WITH l WHERE coalesce(l.expiresAt, 0) <= timestamp() OR l.token = {token} " +
+		"SET l.token = {token}, l.expiresAt = timestamp() + {ttl} RETURN count(l) AS count")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, LockHeld
+	}
+	return l, nil
+}
+
+// update executes stmt with the lock's parameters, reporting whether it
+// counted a node.
+func (l *Lock) update(stmt string) (bool, error) {
+	n, err := l.db.cypherCount(stmt, Props{
+		"name":  l.Name,
+		"token": l.token,
+		"ttl":   int64(l.ttl / time.Millisecond),
+	})
+	return n > 0, err
+}
+
+// Refresh extends the lock to expire ttl from now.  It returns LockLost if the
+// lock has expired and been taken by someone else, or been deleted.
+func (l *Lock) Refresh() error {
+	ok, err := l.update("MATCH (l:" + quoteIdent(LockLabel) + " {name: {name}}) " + writeLock("l") +
+		"WITH l WHERE l.token = {token} SET l.expiresAt = timestamp() + {ttl} RETURN count(l) AS count")
+	if err == nil && !ok {
+		err = LockLost
+	}
+	return err
+}
+
+// Unlock releases the lock.  It returns LockLost if the lock has expired and
+// been taken by someone else, or been deleted.
+func (l *Lock) Unlock() error {
+	ok, err := l.update("MATCH (l:" + quoteIdent(LockLabel) + " {name: {name}}) " +
+		"WHERE l.token = {token} DELETE l RETURN count(*) AS count")
+	if err == nil && !ok {
+		err = LockLost
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	name := rndStr(t)
+	l, err := db.Lock(name, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Lock(name, time.Minute)
+	assert.Equal(t, LockHeld, err)
+	assert.Equal(t, nil, l.Refresh())
+	assert.Equal(t, nil, l.Unlock())
+	assert.Equal(t, LockLost, l.Unlock())
+	l, err = db.Lock(name, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Unlock()
+}
+
+func TestLockExpires(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	name := rndStr(t)
+	l, err := db.Lock(name, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	l2, err := db.Lock(name, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, LockLost, l.Refresh())
+	assert.Equal(t, LockLost, l.Unlock())
+	assert.Equal(t, nil, l2.Unlock())
+}
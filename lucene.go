@@ -0,0 +1,111 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A LuceneQuery is a query for a legacy index's Query or QueryScored methods,
+// built with Term, Wildcard, Fuzzy, Phrase and Range and combined with And, Or
+// and Not.  Keys and values are escaped, so they may contain any character.
+type LuceneQuery string
+
+// String returns the query string.
+func (q LuceneQuery) String() string {
+	return string(q)
+}
+
+// luceneSpecial are the characters with meaning in Lucene query syntax.
+const luceneSpecial = `+-&|!(){}[]^"~*?:\/`
+
+// LuceneEscape escapes s so that a Lucene query matches it literally.
+func LuceneEscape(s string) string {
+	return luceneEscape(s, "")
+}
+
+// luceneEscape escapes the special characters and spaces in s, except for
+// those in keep.
+func luceneEscape(s, keep string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (strings.ContainsRune(luceneSpecial, r) || unicode.IsSpace(r)) && !strings.ContainsRune(keep, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Term matches entries with key whose value is value.
+func Term(key, value string) LuceneQuery {
+	return LuceneQuery(LuceneEscape(key) + ":" + LuceneEscape(value))
+}
+
+// Wildcard matches entries with key whose value matches pattern, in which
+// '*' stands for any characters and '?' for any one character.
+func Wildcard(key, pattern string) LuceneQuery {
+	return LuceneQuery(LuceneEscape(key) + ":" + luceneEscape(pattern, "*?"))
+}
+
+// Fuzzy matches entries with key whose value is similar to value.  Similarity
+// ranges from 0 to 1, higher being stricter; 0 uses Lucene's default of 0.5.
+func Fuzzy(key, value string, similarity float64) LuceneQuery {
+	q := LuceneEscape(key) + ":" + LuceneEscape(value) + "~"
+	if similarity > 0 {
+		q += strconv.FormatFloat(similarity, 'f', -1, 64)
+	}
+	return LuceneQuery(q)
+}
+
+// Phrase matches entries with key whose value contains the words of phrase in
+// order.  It requires a fulltext index.
+func Phrase(key, phrase string) LuceneQuery {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(phrase)
+	return LuceneQuery(LuceneEscape(key) + `:"` + escaped + `"`)
+}
+
+// Range matches entries with key whose value lies between min and max, which
+// are compared as strings; an empty bound is open.  Index numbers with
+// AddNumeric and give bounds with SortableFloat to compare them numerically.
+func Range(key, min, max string, inclusive bool) LuceneQuery {
+	bound := func(s string) string {
+		if s == "" {
+			return "*"
+		}
+		return LuceneEscape(s)
+	}
+	open, close := "{", "}"
+	if inclusive {
+		open, close = "[", "]"
+	}
+	return LuceneQuery(LuceneEscape(key) + ":" + open + bound(min) + " TO " + bound(max) + close)
+}
+
+// combine joins qs with op.
+func combine(op string, qs []LuceneQuery) LuceneQuery {
+	parts := make([]string, len(qs))
+	for i, q := range qs {
+		parts[i] = string(q)
+	}
+	return LuceneQuery("(" + strings.Join(parts, " "+op+" ") + ")")
+}
+
+// And matches entries matching every one of qs.
+func And(qs ...LuceneQuery) LuceneQuery {
+	return combine("AND", qs)
+}
+
+// Or matches entries matching any of qs.
+func Or(qs ...LuceneQuery) LuceneQuery {
+	return combine("OR", qs)
+}
+
+// Not matches entries which do not match q.
+func Not(q LuceneQuery) LuceneQuery {
+	return LuceneQuery("(*:* AND NOT " + string(q) + ")")
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestLuceneQuery(t *testing.T) {
+	assert.Equal(t, `a\+b\:c\ d`, LuceneEscape("a+b:c d"))
+	assert.Equal(t, LuceneQuery(`name:O'Brien`), Term("name", "O'Brien"))
+	assert.Equal(t, LuceneQuery(`url:http\:\/\/example.com\/*`), Wildcard("url", "http://example.com/*"))
+	assert.Equal(t, LuceneQuery(`name:alice~`), Fuzzy("name", "alice", 0))
+	assert.Equal(t, LuceneQuery(`name:alice~0.8`), Fuzzy("name", "alice", 0.8))
+	assert.Equal(t, LuceneQuery(`title:"the \"big\" sleep"`), Phrase("title", `the "big" sleep`))
+	assert.Equal(t, LuceneQuery(`age:[10 TO *]`), Range("age", "10", "", true))
+	assert.Equal(t, LuceneQuery(`name:{a TO m}`), Range("name", "a", "m", false))
+	q := And(Term("city", "Leeds"), Or(Term("name", "alice"), Not(Term("name", "bob"))))
+	assert.Equal(t, "(city:Leeds AND (name:alice OR (*:* AND NOT name:bob)))", q.String())
+}
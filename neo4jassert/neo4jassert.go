@@ -0,0 +1,122 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+// Package neo4jassert provides assertions about the state of a graph, for
+// integration tests:
+//
+//	neo4jassert.AssertNodeExists(t, db, "Person", neo4j.Props{"name": "Alice"})
+//	neo4jassert.AssertRelated(t, db, alice, bob, "KNOWS")
+//	neo4jassert.AssertCount(t, db, "MATCH (n:Person) RETURN n", 2)
+//
+// A failed assertion reports an error through t, without stopping the test,
+// and returns false.
+package neo4jassert
+
+import (
+	"fmt"
+	"github.com/jmcvetta/neo4j"
+	"sort"
+	"strings"
+)
+
+// T is the part of *testing.T used to report failed assertions.
+type T interface {
+	Errorf(format string, args ...interface{})
+}
+
+// quote quotes s as a Cypher identifier.
+func quote(s string) string {
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// count executes stmt, which must return a single column named count.
+func count(db *neo4j.Database, stmt string, params neo4j.Props) (int, error) {
+	res := []struct {
+		Count int `json:"count"`
+	}{}
+	cq := neo4j.CypherQuery{
+		Statement:  stmt,
+		Parameters: params,
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil || len(res) == 0 {
+		return 0, err
+	}
+	return res[0].Count, nil
+}
+
+// AssertNodeExists asserts that there is a node with label, unless it is
+// empty, having every one of props.
+func AssertNodeExists(t T, db *neo4j.Database, label string, props neo4j.Props) bool {
+	match := "(n)"
+	if label != "" {
+		match = "(n:" + quote(label) + ")"
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := neo4j.Props{}
+	where := []string{}
+	for i, k := range keys {
+		p := fmt.Sprintf("p%d", i)
+		params[p] = props[k]
+		where = append(where, "n."+quote(k)+" = {"+p+"}")
+	}
+	stmt := "MATCH " + match + " "
+	if len(where) > 0 {
+		stmt += "WHERE " + strings.Join(where, " AND ") + " "
+	}
+	n, err := count(db, stmt+"RETURN count(n) AS count", params)
+	if err != nil {
+		t.Errorf("neo4jassert: %s", err)
+		return false
+	}
+	if n == 0 {
+		t.Errorf("neo4jassert: no node :%s with properties %v", label, props)
+		return false
+	}
+	return true
+}
+
+// AssertRelated asserts that there is a relationship of type relType, or of
+// any type if it is empty, from a to b.
+func AssertRelated(t T, db *neo4j.Database, a, b *neo4j.Node, relType string) bool {
+	rel := "[r]"
+	if relType != "" {
+		rel = "[r:" + quote(relType) + "]"
+	}
+	stmt := "START a=node({a}), b=node({b}) MATCH (a)-" + rel + "->(b) RETURN count(r) AS count"
+	n, err := count(db, stmt, neo4j.Props{"a": a.Id(), "b": b.Id()})
+	if err != nil {
+		t.Errorf("neo4jassert: %s", err)
+		return false
+	}
+	if n == 0 {
+		t.Errorf("neo4jassert: node %d is not related to node %d by %s", a.Id(), b.Id(), rel)
+		return false
+	}
+	return true
+}
+
+// AssertCount asserts that the Cypher statement stmt returns n rows.
+func AssertCount(t T, db *neo4j.Database, stmt string, n int) bool {
+	cq := neo4j.CypherQuery{Statement: stmt}
+	err := db.Cypher(&cq)
+	if err == nil {
+		var rows [][]interface{}
+		rows, err = cq.Rows()
+		if err == nil && len(rows) != n {
+			t.Errorf("neo4jassert: expected %d rows, got %d: %s", n, len(rows), stmt)
+			return false
+		}
+	}
+	if err != nil {
+		t.Errorf("neo4jassert: %s", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4jassert
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jmcvetta/neo4j"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recorder is a T recording failures.
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// fakeServer answers Cypher queries with a single row holding result,
+// recording the queries received.
+func fakeServer(t *testing.T, result *string, queries *[]string) (*neo4j.Database, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := struct {
+			Query string `json:"query"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&q)
+		*queries = append(*queries, q.Query)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, *result)
+	}))
+	db, err := neo4j.ConnectWithOptions(srv.URL+"/db/data", &neo4j.ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs: map[string]string{
+			"cypher": srv.URL + "/db/data/cypher",
+			"node":   srv.URL + "/db/data/node",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, srv.Close
+}
+
+func TestAssertions(t *testing.T) {
+	result := `{"columns": ["count"], "data": [[1]]}`
+	queries := []string{}
+	db, done := fakeServer(t, &result, &queries)
+	defer done()
+	a := &neo4j.Node{}
+	a.Db = db
+	a.HrefSelf = db.HrefNode + "/1"
+	b := &neo4j.Node{}
+	b.Db = db
+	b.HrefSelf = db.HrefNode + "/2"
+	r := &recorder{}
+	if !AssertNodeExists(r, db, "Person", neo4j.Props{"name": "Alice", "age": 30}) {
+		t.Error(r.errors)
+	}
+	if !AssertRelated(r, db, a, b, "KNOWS") {
+		t.Error(r.errors)
+	}
+	if !AssertCount(r, db, "MATCH (n) RETURN n", 1) {
+		t.Error(r.errors)
+	}
+	want := []string{
+		"MATCH (n:`Person`) WHERE n.`age` = {p0} AND n.`name` = {p1} RETURN count(n) AS count",
+		"START a=node({a}), b=node({b}) MATCH (a)-[r:`KNOWS`]->(b) RETURN count(r) AS count",
+		"MATCH (n) RETURN n",
+	}
+	if strings.Join(queries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected queries: %q", queries)
+	}
+	result = `{"columns": ["count"], "data": [[0]]}`
+	r = &recorder{}
+	if AssertNodeExists(r, db, "", nil) || AssertRelated(r, db, a, b, "") || AssertCount(r, db, "MATCH (n) RETURN n", 2) {
+		t.Error("Expected assertions to fail")
+	}
+	if len(r.errors) != 3 {
+		t.Errorf("Expected 3 failures, got %q", r.errors)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+// Package neo4jtest provides helpers for running integration tests against a
+// throwaway Neo4j server.
+package neo4jtest
+
+import (
+	"errors"
+	"github.com/jmcvetta/neo4j"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// UrlEnv names the environment variable which, if set, points StartContainer
+// at an already-running server instead of launching a container.
+const UrlEnv = "NEO4J_TEST_URL"
+
+// DefaultVersion is the neo4j image tag StartContainer runs when no version
+// is given.  Images from 4.0 onward no longer serve the /db/data REST API this
+// package speaks, so the default is pinned to the last 2.x release.
+const DefaultVersion = "2.3.12"
+
+// Timeout is how long StartContainer waits for a new server to become ready.
+var Timeout = 60 * time.Second
+
+// StartContainer returns a Database connected to a throwaway Neo4j server,
+// plus a cleanup function which must be called when testing is complete.  If
+// NEO4J_TEST_URL is set, StartContainer connects to that server and cleanup
+// is a no-op.  Otherwise a Docker container running the given version of the
+// official neo4j image is launched, and removed again by cleanup.  An empty
+// version selects DefaultVersion.
+func StartContainer(version string) (*neo4j.Database, func(), error) {
+	if uri := os.Getenv(UrlEnv); uri != "" {
+		db, err := neo4j.Connect(uri)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, func() {}, nil
+	}
+	if version == "" {
+		version = DefaultVersion
+	}
+	image := "neo4j:" + version
+	out, err := exec.Command("docker", "run", "-d", "-P", "-e", "NEO4J_AUTH=none", image).Output()
+	if err != nil {
+		return nil, nil, err
+	}
+	id := strings.TrimSpace(string(out))
+	cleanup := func() {
+		exec.Command("docker", "rm", "-f", id).Run()
+	}
+	uri, err := containerUrl(id)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	db, err := waitForServer(uri, Timeout)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return db, cleanup, nil
+}
+
+// containerUrl returns the REST API root URL of the server in container id.
+func containerUrl(id string) (string, error) {
+	out, err := exec.Command("docker", "port", id, "7474/tcp").Output()
+	if err != nil {
+		return "", err
+	}
+	// Docker may list several bindings (e.g. IPv4 and IPv6) - use the first.
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	hostport := strings.TrimSpace(lines[0])
+	if hostport == "" {
+		return "", errors.New("Container has no binding for port 7474.")
+	}
+	hostport = strings.Replace(hostport, "0.0.0.0", "localhost", 1)
+	return "http://" + hostport + "/db/data", nil
+}
+
+// waitForServer polls uri until a connection succeeds or timeout elapses.
+func waitForServer(uri string, timeout time.Duration) (*neo4j.Database, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		db, err := neo4j.Connect(uri)
+		if err == nil {
+			return db, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("Timed out waiting for Neo4j server: " + err.Error())
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
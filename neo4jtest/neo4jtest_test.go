@@ -0,0 +1,27 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4jtest
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestStartContainer(t *testing.T) {
+	if os.Getenv(UrlEnv) == "" {
+		if _, err := exec.LookPath("docker"); err != nil {
+			t.Skip("Docker not available and " + UrlEnv + " not set")
+		}
+	}
+	db, cleanup, err := StartContainer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if db.Version == "" {
+		t.Error("Expected server version to be populated")
+	}
+}
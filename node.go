@@ -6,6 +6,7 @@ package neo4j
 
 import (
 	"github.com/jmcvetta/restclient"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -23,12 +24,13 @@ func (db *Database) CreateNode(p Props) (*Node, error) {
 		Error:          ne,
 		ExpectedStatus: 201,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		logPretty(status)
 		logPretty(ne)
 		return &n, err
 	}
+	db.nodeCreated(&n)
 	return &n, nil
 }
 
@@ -38,6 +40,13 @@ func (db *Database) Node(id int) (*Node, error) {
 	return db.getNodeByUri(uri)
 }
 
+// NodeExists reports whether there is a node with the given ID, without
+// fetching its properties.
+func (db *Database) NodeExists(id int) (bool, error) {
+	count, err := db.cypherCount("MATCH (n) WHERE id(n) = {id} RETURN count(n) AS count", Props{"id": id})
+	return count > 0, err
+}
+
 // getNodeByUri fetches a Node from the database based on its URI.
 func (db *Database) getNodeByUri(uri string) (*Node, error) {
 	ne := NeoError{}
@@ -49,7 +58,7 @@ func (db *Database) getNodeByUri(uri string) (*Node, error) {
 		Result: &n,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return nil, err
 	}
@@ -92,14 +101,44 @@ func (n *Node) Id() int {
 	return id
 }
 
+// Delete removes the node from the database.  The node must have no
+// relationships.
+func (n *Node) Delete() error {
+	err := n.entity.Delete()
+	if err == nil {
+		n.Db.nodeDeleted(n)
+	}
+	return err
+}
+
+// typedRelsUrl returns the URL listing relationships of any of types, given
+// the node's template for typed relationships - e.g.
+// ".../relationships/all/{-list|&|types}" - and its URL for relationships of
+// every type.  Types are escaped, so may contain '&' and '/', and duplicates
+// are dropped.  With no types, untyped is returned.
+func typedRelsUrl(template, untyped string, types []string) string {
+	escaped := []string{}
+	seen := map[string]bool{}
+	for _, t := range types {
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		escaped = append(escaped, PathEscape(t))
+	}
+	if len(escaped) == 0 {
+		return untyped
+	}
+	list := strings.Join(escaped, "&")
+	if i := strings.Index(template, "{-list|&|types}"); i >= 0 {
+		return template[:i] + list + template[i+len("{-list|&|types}"):]
+	}
+	return join(untyped, list)
+}
+
 // getRels makes an api call to the supplied uri and returns a map
 // keying relationship IDs to Rel objects.
-func (n *Node) getRels(uri string, types ...string) (Rels, error) {
-	if types != nil {
-		fragment := strings.Join(types, "&")
-		parts := []string{uri, fragment}
-		uri = strings.Join(parts, "/")
-	}
+func (n *Node) getRels(uri string) (Rels, error) {
 	rels := Rels{}
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
@@ -108,7 +147,7 @@ func (n *Node) getRels(uri string, types ...string) (Rels, error) {
 		Result: &rels,
 		Error:  &ne,
 	}
-	status, err := n.Db.Rc.Do(&rr)
+	status, err := n.Db.do(&rr)
 	if err != nil {
 		return rels, err
 	}
@@ -120,29 +159,94 @@ func (n *Node) getRels(uri string, types ...string) (Rels, error) {
 }
 
 // Rels gets all Rels for this Node, optionally filtered by
-// type, returning them as a map keyed on Rel ID.
+// type, returning them as a map keyed on Rel ID.  Relationships of several
+// types are fetched in a single request.
 func (n *Node) Relationships(types ...string) (Rels, error) {
-	return n.getRels(n.HrefAllRels, types...)
+	return n.getRels(typedRelsUrl(n.HrefAllTypedRels, n.HrefAllRels, types))
 }
 
-// Incoming gets all incoming Rels for this Node.
+// Incoming gets all incoming Rels for this Node, optionally filtered by
+// type.
 func (n *Node) Incoming(types ...string) (Rels, error) {
-	return n.getRels(n.HrefIncomingRels, types...)
+	return n.getRels(typedRelsUrl(n.HrefIncomingTypedRels, n.HrefIncomingRels, types))
 }
 
-// Outgoing gets all outgoing Rels for this Node.
+// Outgoing gets all outgoing Rels for this Node, optionally filtered by
+// type.
 func (n *Node) Outgoing(types ...string) (Rels, error) {
-	return n.getRels(n.HrefOutgoingRels, types...)
+	return n.getRels(typedRelsUrl(n.HrefOutgoing, n.HrefOutgoingRels, types))
+}
+
+// relsPage returns a page of the node's relationships matching pattern, in
+// order of relationship ID, fetched with Cypher so that dense nodes need not
+// return every relationship at once.
+func (n *Node) relsPage(pattern string, skip, limit int, types []string) (Rels, error) {
+	stmt := "START n=node({id}) MATCH " + pattern + " "
+	params := map[string]interface{}{"id": n.Id(), "skip": skip, "limit": limit}
+	if len(types) > 0 {
+		stmt += "WHERE type(r) IN {types} "
+		params["types"] = types
+	}
+	stmt += "RETURN r ORDER BY id(r) SKIP {skip} LIMIT {limit}"
+	res := []struct {
+		R Relationship `json:"r"`
+	}{}
+	cq := CypherQuery{
+		Statement:  stmt,
+		Parameters: params,
+		Result:     &res,
+	}
+	err := n.Db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	rels := make(Rels, len(res))
+	for i := range res {
+		r := res[i].R
+		r.Db = n.Db
+		rels[i] = &r
+	}
+	return rels, nil
+}
+
+// RelationshipsPage returns up to limit of the node's relationships,
+// optionally filtered by type, after skipping the first skip in order of
+// relationship ID.  A page shorter than limit is the last.
+func (n *Node) RelationshipsPage(skip, limit int, types ...string) (Rels, error) {
+	return n.relsPage("(n)-[r]-()", skip, limit, types)
+}
+
+// IncomingPage is as RelationshipsPage, for incoming relationships.
+func (n *Node) IncomingPage(skip, limit int, types ...string) (Rels, error) {
+	return n.relsPage("(n)<-[r]-()", skip, limit, types)
+}
+
+// OutgoingPage is as RelationshipsPage, for outgoing relationships.
+func (n *Node) OutgoingPage(skip, limit int, types ...string) (Rels, error) {
+	return n.relsPage("(n)-[r]->()", skip, limit, types)
 }
 
 // Relate creates a relationship of relType, with specified properties,
-// from this Node to the node identified by destId.
+// from this Node to the node identified by destId.  The properties are sent
+// with the creation request, so no further round trip is needed.
 func (n *Node) Relate(relType string, destId int, p Props) (*Relationship, error) {
+	return n.relate(relType, join(n.Db.HrefNode, strconv.Itoa(destId)), p)
+}
+
+// RelateTo creates a relationship of relType, with specified properties,
+// from this Node to dest.
+func (n *Node) RelateTo(relType string, dest *Node, p Props) (*Relationship, error) {
+	return n.relate(relType, dest.HrefSelf, p)
+}
+
+func (n *Node) relate(relType string, destUri string, p Props) (*Relationship, error) {
 	rel := Relationship{}
 	rel.Db = n.Db
+	if err := n.Db.Vocabulary.CheckRelTypes(relType); err != nil {
+		return &rel, err
+	}
 	ne := NeoError{}
 	srcUri := join(n.HrefSelf, "relationships")
-	destUri := join(n.Db.HrefNode, strconv.Itoa(destId))
 	content := map[string]interface{}{
 		"to":   destUri,
 		"type": relType,
@@ -157,7 +261,7 @@ func (n *Node) Relate(relType string, destId int, p Props) (*Relationship, error
 		Result: &rel,
 		Error:  &ne,
 	}
-	status, err := n.Db.Rc.Do(&c)
+	status, err := n.Db.do(&c)
 	if err != nil {
 		return &rel, err
 	}
@@ -165,11 +269,102 @@ func (n *Node) Relate(relType string, destId int, p Props) (*Relationship, error
 		logPretty(ne)
 		return &rel, ne
 	}
+	n.Db.relCreated(&rel)
 	return &rel, nil
 }
 
-// AddLabels adds one or more labels to a node.
+// Clone creates a new node with the same labels and properties as n,
+// returning it.  If withRelationships is true, every relationship of n is
+// copied too, with the clone in place of n.  The node, its labels and its
+// relationships are created in a single batch request, so either all are
+// created or none are.
+func (n *Node) Clone(withRelationships bool) (*Node, error) {
+	db := n.Db
+	props, err := n.Properties()
+	if err != nil {
+		return nil, err
+	}
+	labels, err := n.Labels()
+	if err != nil {
+		return nil, err
+	}
+	var rels Rels
+	if withRelationships {
+		rels, err = n.Relationships()
+		if err != nil {
+			return nil, err
+		}
+	}
+	jobs := []*batchJob{
+		&batchJob{Method: "POST", To: db.relPath(db.HrefNode), Id: 0, Body: props},
+	}
+	if len(labels) > 0 {
+		jobs = append(jobs, &batchJob{Method: "POST", To: "{0}/labels", Id: 1, Body: labels})
+	}
+	first := len(jobs) // Job id of the first relationship
+	for i, r := range rels {
+		// Self-relationships have the clone at both ends.
+		from, to := "{0}", "{0}"
+		if r.HrefStart != n.HrefSelf {
+			from = db.relPath(r.HrefStart)
+		}
+		if r.HrefEnd != n.HrefSelf {
+			to = r.HrefEnd
+		}
+		body := map[string]interface{}{"to": to, "type": r.Type}
+		if r.Data != nil {
+			body["data"] = r.Data
+		}
+		jobs = append(jobs, &batchJob{
+			Method: "POST",
+			To:     from + "/relationships",
+			Id:     first + i,
+			Body:   body,
+		})
+	}
+	res, err := db.sendBatch(jobs)
+	if err != nil {
+		return nil, err
+	}
+	c := Node{}
+	err = db.codec().Unmarshal(res[0].Body, &c)
+	if err != nil {
+		return nil, err
+	}
+	c.Db = db
+	db.nodeCreated(&c)
+	for _, r := range res[first:] {
+		rel := Relationship{}
+		err := db.codec().Unmarshal(r.Body, &rel)
+		if err != nil {
+			return &c, err
+		}
+		rel.Db = db
+		db.relCreated(&rel)
+	}
+	return &c, nil
+}
+
+// AddLabels adds one or more labels to a node, with their ancestors in the
+// Database's Vocabulary.  It fails with ExclusiveLabels if one of them belongs
+// to a label group of which the node already has another member; the check
+// is made against the node's labels as fetched beforehand, so SetStateLabel
+// should be used to move a node between the labels of a group.
 func (n *Node) AddLabel(labels ...string) error {
+	labels, err := n.Db.Vocabulary.expandLabels(labels...)
+	if err != nil {
+		return err
+	}
+	if n.Db.Vocabulary.grouped(labels) {
+		current, err := n.Labels()
+		if err != nil {
+			return err
+		}
+		err = n.Db.Vocabulary.checkExclusive(append(current, labels...))
+		if err != nil {
+			return err
+		}
+	}
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
 		Url:    n.HrefLabels,
@@ -177,7 +372,7 @@ func (n *Node) AddLabel(labels ...string) error {
 		Data:   labels,
 		Error:  &ne,
 	}
-	status, err := n.Db.Rc.Do(&rr)
+	status, err := n.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -200,7 +395,7 @@ func (n *Node) Labels() ([]string, error) {
 		Error:  &ne,
 		Result: &res,
 	}
-	status, err := n.Db.Rc.Do(&rr)
+	status, err := n.Db.do(&rr)
 	if err != nil {
 		return res, err
 	}
@@ -216,13 +411,13 @@ func (n *Node) Labels() ([]string, error) {
 // RemoveLabel removes a label from a node.
 func (n *Node) RemoveLabel(label string) error {
 	ne := NeoError{}
-	url := join(n.HrefLabels, label)
+	url := join(n.HrefLabels, PathEscape(label))
 	rr := restclient.RequestResponse{
 		Url:    url,
 		Method: "DELETE",
 		Error:  &ne,
 	}
-	status, err := n.Db.Rc.Do(&rr)
+	status, err := n.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -236,8 +431,14 @@ func (n *Node) RemoveLabel(label string) error {
 }
 
 // SetLabels removes any labels currently on a node, and replaces them with the
-// labels provided as argument.
+// labels provided as argument and their ancestors in the Database's Vocabulary.
+// Since the node's current labels are replaced, only the new ones are checked
+// for exclusivity.
 func (n *Node) SetLabels(labels []string) error {
+	labels, err := n.Db.Vocabulary.expandLabels(labels...)
+	if err != nil {
+		return err
+	}
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
 		Url:    n.HrefLabels,
@@ -245,7 +446,7 @@ func (n *Node) SetLabels(labels []string) error {
 		Data:   labels,
 		Error:  &ne,
 	}
-	status, err := n.Db.Rc.Do(&rr)
+	status, err := n.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -258,9 +459,49 @@ func (n *Node) SetLabels(labels []string) error {
 	return nil // Success
 }
 
+// SetStateLabel gives the node label and its ancestors, removing any other
+// label of the same group in the Database's Vocabulary, in a single
+// transaction.  It fails with
+// UnknownLabelGroup if the group is not registered, and NotInLabelGroup if
+// label is not one of its members.
+func (n *Node) SetStateLabel(group, label string) error {
+	members, err := n.Db.Vocabulary.LabelGroup(group)
+	if err != nil {
+		return err
+	}
+	others := removeString(members, label)
+	if len(others) == len(members) {
+		return NotInLabelGroup
+	}
+	labels, err := n.Db.Vocabulary.expandLabels(label)
+	if err != nil {
+		return err
+	}
+	stmt := "START n=node({id}) "
+	if len(others) > 0 {
+		stmt += "REMOVE n" + labelExpr(others) + " "
+	}
+	stmt += "SET n" + labelExpr(labels)
+	cq := CypherQuery{
+		Statement:  stmt,
+		Parameters: map[string]interface{}{"id": n.Id()},
+	}
+	return n.Db.Writes.Do([]string{NodeKey(n.Id())}, func() error {
+		return n.Db.Cypher(&cq)
+	})
+}
+
+// HasLabel reports whether the node has label, without fetching all its
+// labels.
+func (n *Node) HasLabel(label string) (bool, error) {
+	stmt := "START n=node({id}) WHERE n:" + quoteIdent(label) + " RETURN count(n) AS count"
+	count, err := n.Db.cypherCount(stmt, Props{"id": n.Id()})
+	return count > 0, err
+}
+
 // NodesByLabel gets all nodes with a given label.
 func (db *Database) NodesByLabel(label string) ([]*Node, error) {
-	url := join(db.Url, "label", label, "nodes")
+	url := db.hrefLabel(label)
 	ne := NeoError{}
 	res := []*Node{}
 	rr := restclient.RequestResponse{
@@ -269,7 +510,7 @@ func (db *Database) NodesByLabel(label string) ([]*Node, error) {
 		Result: &res,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return res, err
 	}
@@ -285,9 +526,20 @@ func (db *Database) NodesByLabel(label string) ([]*Node, error) {
 	return res, nil // Success
 }
 
+// NodesByLabelTree returns the nodes with label or any of its descendants in
+// the Database's Vocabulary, including those labelled before the taxonomy
+// was registered.
+func (db *Database) NodesByLabelTree(label string) ([]*Node, error) {
+	conds := []string{}
+	for _, l := range append([]string{label}, db.Vocabulary.Descendants(label)...) {
+		conds = append(conds, "n:"+quoteIdent(l))
+	}
+	return db.cypherNodes("MATCH (n) WHERE "+strings.Join(conds, " OR ")+" RETURN n", nil)
+}
+
 // Labels lists all labels.
 func (db *Database) Labels() ([]string, error) {
-	url := join(db.Url, "labels")
+	url := db.hrefNodeLabels()
 	ne := NeoError{}
 	labels := []string{}
 	rr := restclient.RequestResponse{
@@ -296,7 +548,7 @@ func (db *Database) Labels() ([]string, error) {
 		Result: &labels,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return labels, err
 	}
@@ -305,3 +557,25 @@ func (db *Database) Labels() ([]string, error) {
 	}
 	return labels, nil
 }
+
+// PropertyKeys lists every property key in use, in sorted order.
+func (db *Database) PropertyKeys() ([]string, error) {
+	ne := NeoError{}
+	keys := []string{}
+	rr := restclient.RequestResponse{
+		Url:    db.hrefPropertyKeys(),
+		Method: "GET",
+		Result: &keys,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return keys, err
+	}
+	if status != 200 {
+		logPretty(ne)
+		return keys, ne
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
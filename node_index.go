@@ -7,6 +7,7 @@ package neo4j
 import (
 	"github.com/jmcvetta/restclient"
 	"net/url"
+	"sort"
 	"strconv"
 )
 
@@ -53,6 +54,41 @@ func (nix *LegacyNodeIndex) Add(n *Node, key string, value interface{}) error {
 	return nix.add(n.entity, key, value)
 }
 
+// AddAll indexes a node under each of several key/value pairs, in a single
+// batch request.  Either all entries are added or none are.
+func (nix *LegacyNodeIndex) AddAll(n *Node, entries map[string]string) error {
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	es := make([]IndexEntry, len(keys))
+	for i, k := range keys {
+		es[i] = IndexEntry{Id: n.Id(), Key: k, Value: entries[k]}
+	}
+	return nix.AddMany(es)
+}
+
+// Set indexes a node with a key/value pair, replacing any values it was
+// already indexed with under key.  The removal and the addition are made in
+// one batch request, so either both happen or neither does.
+func (nix *LegacyNodeIndex) Set(n *Node, key string, value interface{}) error {
+	return nix.set(n.Id(), key, value)
+}
+
+// AddNumeric indexes a node with a key and numeric value.  The REST API stores
+// index values as strings, so the value is encoded with SortableFloat, allowing
+// entries added this way to be found by FindRange.
+func (nix *LegacyNodeIndex) AddNumeric(n *Node, key string, value float64) error {
+	return nix.add(n.entity, key, SortableFloat(value))
+}
+
+// FindRange locates Nodes added with AddNumeric under key, with values within
+// the inclusive range [min, max].
+func (nix *LegacyNodeIndex) FindRange(key string, min, max float64) (map[int]*Node, error) {
+	return nix.Query(nix.numericRange(key, min, max))
+}
+
 // Remove deletes all entries with a given node, key and value from the index.
 // If value or both key and value are the blank string, they are ignored.
 func (nix *LegacyNodeIndex) Remove(n *Node, key, value string) error {
@@ -67,20 +103,20 @@ func (idx *LegacyNodeIndex) Find(key, value string) (map[int]*Node, error) {
 	if err != nil {
 		return nm, err
 	}
-	rawurl = join(rawurl, key, value)
-	u, err := url.ParseRequestURI(rawurl)
+	rawurl = join(rawurl, PathEscape(key), PathEscape(value))
+	_, err = url.ParseRequestURI(rawurl)
 	if err != nil {
 		return nm, err
 	}
 	ne := NeoError{}
 	resp := []Node{}
 	req := restclient.RequestResponse{
-		Url:    u.String(),
+		Url:    rawurl,
 		Method: "GET",
 		Result: &resp,
 		Error:  &ne,
 	}
-	status, err := idx.db.Rc.Do(&req)
+	status, err := idx.db.do(&req)
 	if err != nil {
 		return nm, err
 	}
@@ -117,7 +153,7 @@ func (idx *index) Query(query string) (map[int]*Node, error) {
 		Result: &result,
 		Error:  &ne,
 	}
-	status, err := idx.db.Rc.Do(&req)
+	status, err := idx.db.do(&req)
 	if err != nil {
 		return nm, err
 	}
@@ -131,3 +167,29 @@ func (idx *index) Query(query string) (map[int]*Node, error) {
 	}
 	return nm, nil
 }
+
+// A ScoredNode is a node found by QueryScored, with the relevance of the match
+// as scored by Lucene.
+type ScoredNode struct {
+	Node  *Node
+	Score float64
+}
+
+// QueryScored finds nodes with a query, most relevant first.
+func (nix *LegacyNodeIndex) QueryScored(query string) ([]ScoredNode, error) {
+	res := []struct {
+		Node
+		Score float64 `json:"score"`
+	}{}
+	err := nix.queryScored(query, &res)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]ScoredNode, len(res))
+	for i := range res {
+		n := res[i].Node
+		n.Db = nix.db
+		hits[i] = ScoredNode{Node: &n, Score: res[i].Score}
+	}
+	return hits, nil
+}
@@ -7,8 +7,15 @@ package neo4j
 import (
 	"fmt"
 	"github.com/bmizerany/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // 18.9.1. Create node index
@@ -251,3 +258,281 @@ func TestFindNodeByQuery(t *testing.T) {
 	_, present = nodes1[n1.Id()]
 	assert.Tf(t, present, "Query() failed to return node with id "+strconv.Itoa(n1.Id()))
 }
+
+func TestFindNodeByNumericRange(t *testing.T) {
+	db := connectTest(t)
+	name := rndStr(t)
+	key := rndStr(t)
+	idx0, _ := db.CreateLegacyNodeIndex(name, "", "")
+	defer idx0.Delete()
+	nodes := []*Node{}
+	for _, v := range []float64{-5, 1, 5, 7.5, 10, 50} {
+		n, _ := db.CreateNode(Props{})
+		defer n.Delete()
+		err := idx0.AddNumeric(n, key, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+	found, err := idx0.FindRange(key, 5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(found))
+	_, ok := found[nodes[2].Id()]
+	assert.T(t, ok)
+	_, ok = found[nodes[3].Id()]
+	assert.T(t, ok)
+	found, err = idx0.FindRange(key, -10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(found))
+	_, ok = found[nodes[0].Id()]
+	assert.T(t, ok)
+}
+
+func TestAddRemoveManyNodes(t *testing.T) {
+	db := connectTest(t)
+	name := rndStr(t)
+	key := rndStr(t)
+	idx0, _ := db.CreateLegacyNodeIndex(name, "", "")
+	defer idx0.Delete()
+	entries := []IndexEntry{}
+	for i := 0; i < 10; i++ {
+		n, _ := db.CreateNode(Props{})
+		defer n.Delete()
+		entries = append(entries, IndexEntry{Id: n.Id(), Key: key, Value: "v"})
+	}
+	err := idx0.AddMany(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, _ := idx0.Find(key, "v")
+	assert.Equal(t, 10, len(found))
+	err = idx0.RemoveMany(entries[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, _ = idx0.Find(key, "v")
+	assert.Equal(t, 6, len(found))
+}
+
+func TestIndexExoticKeysAndValues(t *testing.T) {
+	db := connectTest(t)
+	name := "exotic " + rndStr(t)
+	idx0, err := db.CreateLegacyNodeIndex(name, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx0.Delete()
+	pairs := [][2]string{
+		{"with space", "also space"},
+		{"slash/key", "slash/value"},
+		{"query?", "hash#"},
+		{"ünïcødé", "日本語"},
+	}
+	for _, p := range pairs {
+		n0, _ := db.CreateNode(Props{})
+		defer n0.Delete()
+		err := idx0.Add(n0, p[0], p[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := idx0.Find(p[0], p[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok := found[n0.Id()]
+		assert.Tf(t, ok, "Could not find node by key %q and value %q", p[0], p[1])
+		err = idx0.Remove(n0, p[0], p[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, _ = idx0.Find(p[0], p[1])
+		assert.Equal(t, 0, len(found))
+	}
+}
+
+func TestLegacyNodeIndexGC(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	idx, _ := db.CreateLegacyNodeIndex(rndStr(t), "", "")
+	defer idx.Delete()
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	idx.Add(n0, "name", "n0")
+	idx.Add(n1, "name", "n1")
+	id := n1.Id()
+	// Delete out of band, leaving the index entry behind
+	err := db.Cypher(&CypherQuery{
+		Statement:  "MATCH (n) WHERE id(n) = {id} DELETE n",
+		Parameters: Props{"id": id},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := idx.GC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int{id}, ids)
+	ids, _ = idx.danglingIds()
+	assert.Equal(t, []int{}, ids)
+	found, _ := idx.Find("name", "n1")
+	_, ok := found[id]
+	assert.T(t, !ok)
+	found, _ = idx.Find("name", "n0")
+	assert.Equal(t, 1, len(found))
+}
+
+func TestIndexWaitFor(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	paths := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths[r.URL.Path] = true
+		w.Header().Set("Content-Type", "application/json")
+		polls++
+		if polls < 3 {
+			io.WriteString(w, `[]`)
+			return
+		}
+		io.WriteString(w, `[{"self": "http://`+r.Host+`/db/data/node/7"}]`)
+	}))
+	defer srv.Close()
+	db, _ := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{SkipDiscovery: true})
+	idx := &index{db: db, Name: "people", HrefIndex: srv.URL + "/db/data/index/node", poll: time.Millisecond}
+	e := IndexEntry{Id: 7, Key: "name", Value: "alice"}
+	assert.Equal(t, IndexTimeout, idx.WaitFor(e, 0))
+	assert.Equal(t, nil, idx.WaitFor(e, time.Second))
+	mu.Lock()
+	assert.Equal(t, 3, polls)
+	mu.Unlock()
+	ok, _ := idx.Contains(IndexEntry{Id: 8, Key: "name", Value: "alice"})
+	assert.T(t, !ok)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]bool{"/db/data/index/node/people/name/alice": true}, paths)
+}
+
+func TestIndexContainsNumeric(t *testing.T) {
+	var mu sync.Mutex
+	values := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		mu.Lock()
+		values = append(values, value)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if value != SortableFloat(2.5) {
+			io.WriteString(w, `[]`)
+			return
+		}
+		io.WriteString(w, `[{"self": "http://`+r.Host+`/db/data/node/7"}]`)
+	}))
+	defer srv.Close()
+	db, _ := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{SkipDiscovery: true})
+	idx := &index{db: db, Name: "sizes", HrefIndex: srv.URL + "/db/data/index/node"}
+	ok, err := idx.Contains(IndexEntry{Id: 7, Key: "size", Value: 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.T(t, ok)
+	ok, _ = idx.Contains(IndexEntry{Id: 7, Key: "size", Value: "big"})
+	assert.T(t, !ok)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"2.5", SortableFloat(2.5), "big"}, values)
+}
+
+func TestSetNodeInIndex(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	idx, _ := db.CreateLegacyNodeIndex(rndStr(t), "", "")
+	defer idx.Delete()
+	n0, _ := db.CreateNode(Props{})
+	idx.Add(n0, "name", "alice")
+	idx.Add(n0, "name", "alicia")
+	idx.Add(n0, "city", "Leeds")
+	err := idx.Set(n0, "name", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for value, want := range map[string]bool{"alice": false, "alicia": false, "bob": true} {
+		found, _ := idx.Find("name", value)
+		_, ok := found[n0.Id()]
+		assert.Equal(t, want, ok, value)
+	}
+	found, _ := idx.Find("city", "Leeds")
+	assert.Equal(t, 1, len(found))
+}
+
+func TestAddAllToIndex(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	idx, _ := db.CreateLegacyNodeIndex(rndStr(t), "", "")
+	defer idx.Delete()
+	n0, _ := db.CreateNode(Props{})
+	err := idx.AddAll(n0, map[string]string{"name": "alice", "city": "Leeds", "email": "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range [][2]string{{"name", "alice"}, {"city", "Leeds"}, {"email", "alice@example.com"}} {
+		found, _ := idx.Find(kv[0], kv[1])
+		_, ok := found[n0.Id()]
+		assert.T(t, ok, kv)
+	}
+}
+
+func TestQueryScored(t *testing.T) {
+	var params url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `[
+			{"self": "http://`+r.Host+`/db/data/node/7", "data": {"name": "alice"}, "score": 1.5},
+			{"self": "http://`+r.Host+`/db/data/node/3", "data": {"name": "alicia"}, "score": 0.25}]`)
+	}))
+	defer srv.Close()
+	db, _ := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{SkipDiscovery: true})
+	db.HrefNode = srv.URL + "/db/data/node"
+	idx := &LegacyNodeIndex{index{db: db, Name: "people", HrefIndex: srv.URL + "/db/data/index/node"}}
+	hits, err := idx.QueryScored("name:ali*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "score", params.Get("order"))
+	assert.Equal(t, "name:ali*", params.Get("query"))
+	assert.Equal(t, 2, len(hits))
+	assert.Equal(t, 7, hits[0].Node.Id())
+	assert.Equal(t, 1.5, hits[0].Score)
+	assert.Equal(t, "alicia", hits[1].Node.Data["name"])
+	assert.Equal(t, db, hits[1].Node.Db)
+}
+
+func TestFindEscapes(t *testing.T) {
+	var uri, query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri = r.RequestURI
+		query = r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `[]`)
+	}))
+	defer srv.Close()
+	db, _ := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{SkipDiscovery: true})
+	idx := &LegacyNodeIndex{index{db: db, Name: "pages", HrefIndex: srv.URL + "/db/data/index/node"}}
+	// Find and Contains both look the entry up by path
+	idx.Find("url path", "http://example.com/a b")
+	assert.Equal(t, "/db/data/index/node/pages/url%20path/http%3A%2F%2Fexample.com%2Fa%20b", uri)
+	idx.Contains(IndexEntry{Key: "url path", Value: "http://example.com/a b"})
+	assert.Equal(t, "/db/data/index/node/pages/url%20path/http%3A%2F%2Fexample.com%2Fa%20b", uri)
+	idx.FindRange("size:bytes", 1, 2)
+	assert.Equal(t, `size\:bytes:[`+SortableFloat(1)+" TO "+SortableFloat(2)+"]", query)
+	idx.Raw = true
+	idx.FindRange("size", 1, 2)
+	assert.Equal(t, "size:["+SortableFloat(1)+" TO "+SortableFloat(2)+"]", query)
+}
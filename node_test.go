@@ -260,6 +260,29 @@ func TestNodeProperty(t *testing.T) {
 	assert.Equal(t, NotFound, err)
 }
 
+func TestNodePropertyExoticKey(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{})
+	for _, key := range []string{"with space", "slash/key", "query?", "hash#", "ünïcødé"} {
+		err := n0.SetProperty(key, "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := n0.Property(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "v", value)
+		err = n0.DeleteProperty(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	props, _ := n0.Properties()
+	assert.Equal(t, 0, len(props))
+}
+
 func TestAddLabels(t *testing.T) {
 	db := connectTest(t)
 	defer cleanup(t, db)
@@ -375,3 +398,111 @@ func TestGetAllLabels(t *testing.T) {
 	}
 
 }
+
+func TestNodeClone(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{"name": "v1"})
+	n0.AddLabel("Document")
+	other, _ := db.CreateNode(Props{})
+	n0.Relate("cites", other.Id(), Props{"page": 4})
+	other.Relate("links", n0.Id(), nil)
+	n0.Relate("self", n0.Id(), nil)
+	c, err := n0.Clone(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, n0.Id(), c.Id())
+	assert.Equal(t, "v1", c.Data["name"])
+	labels, _ := c.Labels()
+	assert.Equal(t, []string{"Document"}, labels)
+	rels, _ := c.Relationships()
+	assert.Equal(t, 0, len(rels))
+	c, err = n0.Clone(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := c.Outgoing("cites")
+	assert.Equal(t, 1, len(out))
+	assert.Equal(t, other.Id(), out[0].EndId())
+	assert.Equal(t, map[string]interface{}{"page": float64(4)}, out[0].Data)
+	in, _ := c.Incoming("links")
+	assert.Equal(t, 1, len(in))
+	assert.Equal(t, other.Id(), in[0].StartId())
+	self, _ := c.Outgoing("self")
+	assert.Equal(t, 1, len(self))
+	assert.Equal(t, c.Id(), self[0].EndId())
+	// The original is untouched
+	rels, _ = n0.Relationships()
+	assert.Equal(t, 3, len(rels))
+}
+
+func TestTypedRelsUrl(t *testing.T) {
+	base := "http://localhost:7474/db/data/node/1/relationships/out"
+	template := base + "/{-list|&|types}"
+	assert.Equal(t, base, typedRelsUrl(template, base, nil))
+	assert.Equal(t, base, typedRelsUrl(template, base, []string{""}))
+	assert.Equal(t, base+"/knows&likes", typedRelsUrl(template, base, []string{"knows", "likes", "knows"}))
+	assert.Equal(t, base+"/R%26D&a%2Fb", typedRelsUrl(template, base, []string{"R&D", "a/b"}))
+	assert.Equal(t, base+"/knows", typedRelsUrl("", base, []string{"knows"}))
+}
+
+func TestExists(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	n0.AddLabel("Person")
+	r, _ := n0.Relate("knows", n1.Id(), nil)
+	ok, err := db.NodeExists(n0.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.T(t, ok)
+	ok, _ = db.NodeExists(n0.Id() + 1000)
+	assert.T(t, !ok)
+	ok, _ = db.RelationshipExists(r.Id())
+	assert.T(t, ok)
+	r.Delete()
+	ok, _ = db.RelationshipExists(r.Id())
+	assert.T(t, !ok)
+	ok, err = n0.HasLabel("Person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.T(t, ok)
+	ok, _ = n1.HasLabel("Person")
+	assert.T(t, !ok)
+}
+
+func TestRelationshipsPage(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	hub, _ := db.CreateNode(Props{})
+	ids := []int{}
+	for i := 0; i < 5; i++ {
+		n, _ := db.CreateNode(Props{})
+		r, _ := hub.Relate("links", n.Id(), nil)
+		ids = append(ids, r.Id())
+	}
+	other, _ := db.CreateNode(Props{})
+	other.Relate("points", hub.Id(), nil)
+	got := []int{}
+	for skip := 0; ; skip += 2 {
+		page, err := hub.OutgoingPage(skip, 2, "links")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range page {
+			got = append(got, r.Id())
+		}
+		if len(page) < 2 {
+			break
+		}
+	}
+	assert.Equal(t, ids, got)
+	page, _ := hub.IncomingPage(0, 10)
+	assert.Equal(t, 1, len(page))
+	page, _ = hub.RelationshipsPage(4, 10)
+	assert.Equal(t, 2, len(page))
+}
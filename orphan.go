@@ -0,0 +1,40 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// orphanMatch returns the start of a statement matching, as n, the nodes with
+// label - or every node, if label is empty - which have no relationships.
+func orphanMatch(label string) string {
+	if label == "" {
+		return "MATCH (n) WHERE NOT (n)--() "
+	}
+	return "MATCH (n:" + quoteIdent(label) + ") WHERE NOT (n)--() "
+}
+
+// FindOrphans returns the nodes with label which have no relationships.  If
+// label is empty, every node is considered.
+func (db *Database) FindOrphans(label string) ([]*Node, error) {
+	return db.cypherNodes(orphanMatch(label)+"RETURN n", nil)
+}
+
+// DeleteOrphans deletes the nodes with label which have no relationships,
+// batchSize at a time so that no single transaction grows too large, and
+// returns the number deleted.  If label is empty, every node is considered.
+// A batchSize below 1 means DefaultDeletionBatch.  The nodes are deleted by a
+// Cypher statement, so hooks registered with OnNodeDeleted are not called.
+func (db *Database) DeleteOrphans(label string, batchSize int) (int, error) {
+	if batchSize < 1 {
+		batchSize = DefaultDeletionBatch
+	}
+	stmt := orphanMatch(label) + "WITH n LIMIT {limit} DELETE n RETURN count(*) AS count"
+	total := 0
+	for {
+		count, err := db.cypherCount(stmt, Props{"limit": batchSize})
+		total += count
+		if err != nil || count < batchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestOrphans(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	var linked *Node
+	for i := 0; i < 7; i++ {
+		n, _ := db.CreateNode(Props{"i": i})
+		n.AddLabel(label)
+		if i == 0 {
+			linked = n
+		}
+	}
+	other, _ := db.CreateNode(Props{})
+	linked.Relate("knows", other.Id(), nil)
+	orphans, err := db.FindOrphans(label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 6, len(orphans))
+	count, err := db.DeleteOrphans(label, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 6, count)
+	orphans, _ = db.FindOrphans(label)
+	assert.Equal(t, 0, len(orphans))
+	ok, _ := db.NodeExists(linked.Id())
+	assert.T(t, ok)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// EventLabel is the label of the event nodes written by Publish.
+const EventLabel = "Event"
+
+// eventSequence is the name of the sequence numbering events.
+const eventSequence = "events"
+
+// An Event is a record of a change, written to the graph in the same
+// transaction as the change itself, awaiting publication downstream.
+type Event struct {
+	Seq     int64  `json:"seq"`     // Position in the outbox
+	Type    string `json:"type"`    // Kind of change, as given to Publish
+	Payload string `json:"payload"` // JSON encoding of the payload
+	Created int64  `json:"created"` // Server time in milliseconds since the epoch
+	codec   Codec  // Codec of the Database which read the event
+}
+
+// Decode decodes the event's payload into v, with the Codec of the Database
+// from which the event was read - that which encoded it, if both share one.
+func (e *Event) Decode(v interface{}) error {
+	c := e.codec
+	if c == nil {
+		c = stdCodec{}
+	}
+	return c.Unmarshal([]byte(e.Payload), v)
+}
+
+// EventQuery returns a statement recording an event of type typ carrying
+// payload, encoded as JSON by db's Codec, for execution in the same
+// transaction as the change it describes.  Events are numbered by a sequence
+// whose node stays locked until the transaction ends, so events become
+// visible in the order of their sequence numbers; transactions recording
+// events are therefore serialized with one another.
+func (db *Database) EventQuery(typ string, payload interface{}) (*CypherQuery, error) {
+	err := db.ensureUnique(SequenceLabel, "name")
+	if err != nil {
+		return nil, err
+	}
+	err = db.ensureUnique(EventLabel, "seq")
+	if err != nil {
+		return nil, err
+	}
+	b, err := db.codec().Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &CypherQuery{
+		Statement: "MERGE (s:" + quoteIdent(SequenceLabel) + " {name: {sequence}}) " + writeLock("s") +
+			"SET s.value = coalesce(s.value, 0) + 1 " +
+			"CREATE (e:" + quoteIdent(EventLabel) + " {seq: s.value, type: {type}, payload: {payload}, created: timestamp()})",
+		Parameters: Props{
+			"sequence": eventSequence,
+			"type":     typ,
+			"payload":  string(b),
+		},
+	}, nil
+}
+
+// Publish executes qs and records an event of type typ carrying payload, all
+// in one transaction: either the change and its event are both written, or
+// neither is.
+func (db *Database) Publish(qs []*CypherQuery, typ string, payload interface{}) error {
+	eq, err := db.EventQuery(typ, payload)
+	if err != nil {
+		return err
+	}
+	all := append(append([]*CypherQuery{}, qs...), eq)
+	tx, err := db.Begin(all)
+	if err != nil {
+		if tx != nil {
+			tx.Rollback()
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// PendingEvents returns up to limit events which have not been acknowledged,
+// in order.
+func (db *Database) PendingEvents(limit int) ([]*Event, error) {
+	res := []*Event{}
+	cq := CypherQuery{
+		Statement: "MATCH (e:" + quoteIdent(EventLabel) + ") " +
+			"RETURN e.seq AS seq, e.type AS type, e.payload AS payload, e.created AS created " +
+			"ORDER BY e.seq LIMIT {limit}",
+		Parameters: Props{"limit": limit},
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range res {
+		e.codec = db.codec()
+	}
+	return res, nil
+}
+
+// AckEvents acknowledges the events with the given sequence numbers,
+// removing them from the outbox.
+func (db *Database) AckEvents(seqs ...int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+	cq := CypherQuery{
+		Statement:  "MATCH (e:" + quoteIdent(EventLabel) + ") WHERE e.seq IN {seqs} DELETE e",
+		Parameters: Props{"seqs": seqs},
+	}
+	return db.Cypher(&cq)
+}
+
+// ConsumeEvents passes up to limit pending events to f in order,
+// acknowledging each once f has returned without error, and returns the
+// number consumed.  It stops at the first error, leaving that event pending,
+// so that events are delivered at least once and never out of order.
+func (db *Database) ConsumeEvents(limit int, f func(e *Event) error) (int, error) {
+	events, err := db.PendingEvents(limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, e := range events {
+		err := f(e)
+		if err != nil {
+			return i, err
+		}
+		err = db.AckEvents(e.Seq)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
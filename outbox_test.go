@@ -0,0 +1,98 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"errors"
+	"github.com/bmizerany/assert"
+	"net"
+	"testing"
+)
+
+func TestEventDecode(t *testing.T) {
+	e := Event{Payload: `{"name":"kirk","rank":3}`}
+	v := struct {
+		Name string
+		Rank int
+	}{}
+	err := e.Decode(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "kirk", v.Name)
+	assert.Equal(t, 3, v.Rank)
+}
+
+func TestEventDecodeCodec(t *testing.T) {
+	pt := testPropertyTypes()
+	b, err := pt.Marshal(ptHost{Name: "db1", Addr: net.ParseIP("10.0.0.1"), Level: levelHigh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Event{Payload: string(b), codec: pt}
+	h := ptHost{}
+	err = e.Decode(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "db1", h.Name)
+	assert.Equal(t, "10.0.0.1", h.Addr.String())
+	assert.Equal(t, levelHigh, h.Level)
+}
+
+func TestOutbox(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	create := func(name string) error {
+		cq := CypherQuery{
+			Statement:  "CREATE (n:" + quoteIdent(label) + " {name: {name}})",
+			Parameters: Props{"name": name},
+		}
+		return db.Publish([]*CypherQuery{&cq}, "created", Props{"name": name})
+	}
+	for _, name := range []string{"kirk", "spock", "mccoy"} {
+		err := create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	//
+	// A failed change records no event
+	//
+	bad := CypherQuery{Statement: "CREATE (n:" + quoteIdent(label) + " {name: {name}}"}
+	err := db.Publish([]*CypherQuery{&bad}, "created", nil)
+	assert.NotEqual(t, nil, err)
+	//
+	// Consume in order, stopping at a failure
+	//
+	fail := errors.New("fail")
+	names := []string{}
+	consume := func(e *Event) error {
+		p := Props{}
+		e.Decode(&p)
+		if p["name"] == "spock" && len(names) == 1 {
+			return fail
+		}
+		names = append(names, p["name"].(string))
+		return nil
+	}
+	n, err := db.ConsumeEvents(10, consume)
+	assert.Equal(t, fail, err)
+	assert.Equal(t, 1, n)
+	events, _ := db.PendingEvents(10)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "created", events[0].Type)
+	assert.T(t, events[0].Seq < events[1].Seq)
+	names = append(names, "retry")
+	n, err = db.ConsumeEvents(10, consume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"kirk", "retry", "spock", "mccoy"}, names)
+	events, _ = db.PendingEvents(10)
+	assert.Equal(t, 0, len(events))
+}
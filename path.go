@@ -0,0 +1,280 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/jmcvetta/restclient"
+	"strings"
+)
+
+// A Path is a sequence of nodes joined by relationships, as returned by the
+// graph algorithm endpoints, by traversals and by Cypher queries returning
+// paths.  Its nodes and relationships are fetched, in a single batch request,
+// when first asked for.
+type Path struct {
+	Db        *Database       `json:"-"`
+	HrefStart string          `json:"start"`
+	HrefEnd   string          `json:"end"`
+	HrefNodes []string        `json:"nodes"`
+	HrefRels  []string        `json:"relationships"`
+	Length    int             `json:"length"` // Number of relationships
+	Weight    float64         `json:"weight"` // Total cost, for weighted paths
+	nodes     []*Node         // Cached by hydrate()
+	rels      []*Relationship // Cached by hydrate()
+}
+
+// A PathStep is one relationship of a Path, with the nodes it joins in the
+// order the path visits them - which may be opposite to the relationship's
+// own direction.
+type PathStep struct {
+	From *Node
+	Rel  *Relationship
+	To   *Node
+}
+
+// hydrate fetches the nodes and relationships of the path, unless already
+// fetched.
+func (p *Path) hydrate() error {
+	if p.nodes != nil {
+		return nil
+	}
+	jobs := make([]*batchJob, 0, len(p.HrefNodes)+len(p.HrefRels))
+	for _, href := range append(append([]string{}, p.HrefNodes...), p.HrefRels...) {
+		jobs = append(jobs, &batchJob{Method: "GET", To: p.Db.relPath(href), Id: len(jobs)})
+	}
+	res, err := p.Db.batch(jobs)
+	if err != nil {
+		return err
+	}
+	nodes := make([]*Node, len(p.HrefNodes))
+	rels := make([]*Relationship, len(p.HrefRels))
+	for i, r := range res {
+		if r.Status == 404 {
+			return NotFound
+		}
+		var v interface{}
+		if i < len(nodes) {
+			nodes[i] = &Node{}
+			nodes[i].Db = p.Db
+			v = nodes[i]
+		} else {
+			rel := &Relationship{}
+			rel.Db = p.Db
+			rels[i-len(nodes)] = rel
+			v = rel
+		}
+		err := p.Db.codec().Unmarshal(r.Body, v)
+		if err != nil {
+			return err
+		}
+	}
+	p.nodes = nodes
+	p.rels = rels
+	return nil
+}
+
+// Nodes returns the nodes of the path, in order.
+func (p *Path) Nodes() ([]*Node, error) {
+	err := p.hydrate()
+	return p.nodes, err
+}
+
+// Relationships returns the relationships of the path, in order.
+func (p *Path) Relationships() ([]*Relationship, error) {
+	err := p.hydrate()
+	return p.rels, err
+}
+
+// Start returns the first node of the path.
+func (p *Path) Start() (*Node, error) {
+	err := p.hydrate()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.nodes) == 0 {
+		return p.Db.getNodeByUri(p.HrefStart)
+	}
+	return p.nodes[0], nil
+}
+
+// End returns the last node of the path.
+func (p *Path) End() (*Node, error) {
+	err := p.hydrate()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.nodes) == 0 {
+		return p.Db.getNodeByUri(p.HrefEnd)
+	}
+	return p.nodes[len(p.nodes)-1], nil
+}
+
+// Steps returns the relationships of the path, in order, each with the nodes
+// it joins.
+func (p *Path) Steps() ([]PathStep, error) {
+	err := p.hydrate()
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]PathStep, 0, len(p.rels))
+	for i, r := range p.rels {
+		if i+1 >= len(p.nodes) {
+			break
+		}
+		steps = append(steps, PathStep{From: p.nodes[i], Rel: r, To: p.nodes[i+1]})
+	}
+	return steps, nil
+}
+
+// Each calls fn for each step of the path in order, stopping at the first
+// error, which is returned.
+func (p *Path) Each(fn func(PathStep) error) error {
+	steps, err := p.Steps()
+	if err != nil {
+		return err
+	}
+	for _, s := range steps {
+		err := fn(s)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WeightedShortestPath finds the cheapest path from start to end following
+// outgoing relationships of relType, where the cost of each relationship is
+// its weightProp property.  It uses the server's Dijkstra implementation;
+// the total cost is returned as the path's Weight.  If there is no such path,
+// NotFound is returned.
+func (db *Database) WeightedShortestPath(start, end *Node, relType, weightProp string) (*Path, error) {
+	req := map[string]interface{}{
+		"to":            end.HrefSelf,
+		"algorithm":     "dijkstra",
+		"cost_property": weightProp,
+		"relationships": map[string]string{
+			"type":      relType,
+			"direction": "out",
+		},
+	}
+	p := Path{}
+	err := db.findPaths(join(start.HrefSelf, "path"), req, &p)
+	if err != nil {
+		return nil, err
+	}
+	p.Db = db
+	return &p, nil
+}
+
+// ShortestPath finds a shortest path from start to end following outgoing
+// relationships of relType, of at most maxDepth relationships.  If there is
+// no such path, NotFound is returned.
+func (db *Database) ShortestPath(start, end *Node, relType string, maxDepth int) (*Path, error) {
+	p := Path{}
+	err := db.findPaths(join(start.HrefSelf, "path"), shortestPathRequest(end, relType, maxDepth), &p)
+	if err != nil {
+		return nil, err
+	}
+	p.Db = db
+	return &p, nil
+}
+
+// AllShortestPaths finds every shortest path from start to end following
+// outgoing relationships of relType, of at most maxDepth relationships.
+func (db *Database) AllShortestPaths(start, end *Node, relType string, maxDepth int) ([]*Path, error) {
+	ps := []*Path{}
+	err := db.findPaths(join(start.HrefSelf, "paths"), shortestPathRequest(end, relType, maxDepth), &ps)
+	if err != nil && err != NotFound {
+		return nil, err
+	}
+	for _, p := range ps {
+		p.Db = db
+	}
+	return ps, nil
+}
+
+func shortestPathRequest(end *Node, relType string, maxDepth int) map[string]interface{} {
+	return map[string]interface{}{
+		"to":        end.HrefSelf,
+		"algorithm": "shortestPath",
+		"max_depth": maxDepth,
+		"relationships": map[string]string{
+			"type":      relType,
+			"direction": "out",
+		},
+	}
+}
+
+// findPaths POSTs a graph algorithm request to url, decoding the paths found
+// into result.
+func (db *Database) findPaths(url string, req map[string]interface{}, result interface{}) error {
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    url,
+		Method: "POST",
+		Data:   req,
+		Result: result,
+		Error:  &ne,
+	}
+	status, err := db.do(&rr)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 200:
+		return nil
+	case 404:
+		return NotFound
+	}
+	logPretty(ne)
+	return ne
+}
+
+// A Traversal describes the paths followed by Node.Traverse.  Zero values
+// leave the server's defaults in place: breadth first, node global
+// uniqueness, a depth of 1 and every relationship.
+type Traversal struct {
+	Order         string         `json:"order,omitempty"`      // "breadth_first" or "depth_first"
+	Uniqueness    string         `json:"uniqueness,omitempty"` // e.g. "node_global", "node_path" or "none"
+	MaxDepth      int            `json:"max_depth,omitempty"`
+	Relationships []TraversalRel `json:"relationships,omitempty"`
+}
+
+// A TraversalRel is a relationship type a Traversal may follow, in
+// Direction "in", "out" or "all".
+type TraversalRel struct {
+	Type      string `json:"type"`
+	Direction string `json:"direction,omitempty"`
+}
+
+// Traverse returns the paths from n found by the server's traversal
+// framework, as described by t.
+func (n *Node) Traverse(t Traversal) ([]*Path, error) {
+	ps := []*Path{}
+	ne := NeoError{}
+	rr := restclient.RequestResponse{
+		Url:    strings.Replace(n.HrefTraverse, "{returnType}", "path", 1),
+		Method: "POST",
+		Data:   &t,
+		Result: &ps,
+		Error:  &ne,
+	}
+	status, err := n.do(&rr)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case 200:
+	case 404:
+		return nil, NotFound
+	default:
+		logPretty(ne)
+		return nil, ne
+	}
+	for _, p := range ps {
+		p.Db = n.Db
+	}
+	return ps, nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestWeightedShortestPath(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	a, _ := db.CreateNode(Props{})
+	b, _ := db.CreateNode(Props{})
+	c, _ := db.CreateNode(Props{})
+	// The direct road is longer than the detour through b.
+	a.Relate("road", c.Id(), Props{"km": 10})
+	a.Relate("road", b.Id(), Props{"km": 3})
+	b.Relate("road", c.Id(), Props{"km": 4})
+	p, err := db.WeightedShortestPath(a, c, "road", "km")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 7.0, p.Weight)
+	assert.Equal(t, 2, p.Length)
+	assert.Equal(t, []string{a.HrefSelf, b.HrefSelf, c.HrefSelf}, p.HrefNodes)
+	_, err = db.WeightedShortestPath(c, a, "road", "km")
+	assert.Equal(t, NotFound, err)
+}
+
+func TestPathSteps(t *testing.T) {
+	a, b, c := &Node{}, &Node{}, &Node{}
+	r0, r1 := &Relationship{Type: "x"}, &Relationship{Type: "y"}
+	p := Path{Length: 2, nodes: []*Node{a, b, c}, rels: []*Relationship{r0, r1}}
+	steps, err := p.Steps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []PathStep{{a, r0, b}, {b, r1, c}}, steps)
+	end, _ := p.End()
+	assert.T(t, end == c)
+	types := ""
+	err = p.Each(func(s PathStep) error {
+		types += s.Rel.Type
+		if s.To == c {
+			return NotFound
+		}
+		return nil
+	})
+	assert.Equal(t, NotFound, err)
+	assert.Equal(t, "xy", types)
+}
+
+func TestShortestPathAndTraverse(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	a, _ := db.CreateNode(Props{"name": "a"})
+	b, _ := db.CreateNode(Props{"name": "b"})
+	c, _ := db.CreateNode(Props{"name": "c"})
+	d, _ := db.CreateNode(Props{"name": "d"})
+	a.Relate("road", b.Id(), nil)
+	a.Relate("road", c.Id(), nil)
+	b.Relate("road", d.Id(), nil)
+	c.Relate("road", d.Id(), nil)
+	p, err := db.ShortestPath(a, d, "road", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, p.Length)
+	start, _ := p.Start()
+	assert.Equal(t, "a", start.Data["name"])
+	steps, err := p.Steps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(steps))
+	assert.Equal(t, "d", steps[1].To.Data["name"])
+	ps, err := db.AllShortestPaths(a, d, "road", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(ps))
+	_, err = db.ShortestPath(d, a, "road", 5)
+	assert.Equal(t, NotFound, err)
+	ps, err = a.Traverse(Traversal{MaxDepth: 2, Relationships: []TraversalRel{{"road", "out"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a, a-b, a-c and one of a-b-d or a-c-d, as nodes are visited once.
+	assert.Equal(t, 4, len(ps))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// CountPattern returns the number of matches of a Cypher pattern, which may
+// be followed by a WHERE clause - e.g. "(a:Account)-[:SENT]->(b:Account)
+// WHERE a.country <> b.country".  Parameters are referred to as {name} in
+// the pattern, as in any Cypher statement.
+func (db *Database) CountPattern(pattern string, params Props) (int, error) {
+	return db.cypherCount("MATCH "+pattern+" RETURN count(*) AS count", params)
+}
+
+// CountTriangles returns the number of distinct triangles of relationships of
+// relType which include n, ignoring direction.  An empty relType matches
+// relationships of any type.
+func (db *Database) CountTriangles(n *Node, relType string) (int, error) {
+	rel := relPattern(relType)
+	stmt := "START a=node({id}) MATCH (a)-" + rel + "-(b)-" + rel + "-(c)-" + rel + "-(a) " +
+		"WHERE id(b) < id(c) WITH DISTINCT b, c RETURN count(*) AS count"
+	return db.cypherCount(stmt, Props{"id": n.Id()})
+}
+
+// CountSquares returns the number of distinct cycles of four nodes, joined by
+// relationships of relType, which include n, ignoring direction.  An empty
+// relType matches relationships of any type.
+func (db *Database) CountSquares(n *Node, relType string) (int, error) {
+	rel := relPattern(relType)
+	stmt := "START a=node({id}) MATCH (a)-" + rel + "-(b)-" + rel + "-(c)-" + rel + "-(d)-" + rel + "-(a) " +
+		"WHERE id(b) < id(d) AND a <> c AND b <> d WITH DISTINCT b, c, d RETURN count(*) AS count"
+	return db.cypherCount(stmt, Props{"id": n.Id()})
+}
+
+// cypherCount executes stmt, which must return a single row with a single
+// column named count.
+func (db *Database) cypherCount(stmt string, params Props) (int, error) {
+	res := []struct {
+		Count int `json:"count"`
+	}{}
+	cq := CypherQuery{
+		Statement:  stmt,
+		Parameters: params,
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil || len(res) == 0 {
+		return 0, err
+	}
+	return res[0].Count, nil
+}
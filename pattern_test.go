@@ -0,0 +1,41 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestCountPattern(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n := make([]*Node, 5)
+	for i := range n {
+		n[i], _ = db.CreateNode(Props{"i": i})
+	}
+	// Triangle 0-1-2, plus square 0-2-3-4.
+	edges := [][2]int{{0, 1}, {1, 2}, {2, 0}, {2, 3}, {3, 4}, {4, 0}}
+	for _, e := range edges {
+		n[e[0]].Relate("paid", n[e[1]].Id(), nil)
+	}
+	c, err := db.CountPattern("(a)-[:paid]->(b) WHERE a.i > b.i", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, c)
+	c, err = db.CountTriangles(n[0], "paid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, c)
+	c, _ = db.CountTriangles(n[3], "paid")
+	assert.Equal(t, 0, c)
+	c, err = db.CountSquares(n[0], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, c)
+}
@@ -0,0 +1,440 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// A PropertyTypes is a registry of property encodings for Go types which
+// Neo4j cannot store as they are - mapping net.IP to a string, say, or an
+// enumeration to an int.  It is a Codec: assigned to Database.Codec, it
+// applies the encodings wherever values are sent or received, in Props,
+// Cypher parameters, structs marshalled as properties and query results.
+// Struct fields are named as by encoding/json, honouring the name, omitempty
+// and "-" options of their json tags and promoting the fields of embedded
+// structs.  A PropertyTypes is safe for concurrent use.
+type PropertyTypes struct {
+	Codec    Codec // Underlying codec; encoding/json if nil
+	mu       sync.RWMutex
+	types    map[reflect.Type]propertyType
+	contains map[reflect.Type]bool // Cache of containsType
+}
+
+// A propertyType converts values of a Go type to and from property values.
+type propertyType struct {
+	encode func(v interface{}) (interface{}, error)
+	decode func(p interface{}) (interface{}, error)
+}
+
+// NewPropertyTypes returns an empty PropertyTypes.
+func NewPropertyTypes() *PropertyTypes {
+	return &PropertyTypes{
+		types:    map[reflect.Type]propertyType{},
+		contains: map[reflect.Type]bool{},
+	}
+}
+
+// Register maps values of the type of example to property values with
+// encode, which must return a string, number, bool or slice of one of those.
+// decode is given a property value as decoded from JSON - a string, float64,
+// bool or []interface{} - and must return a value of the registered type.
+func (pt *PropertyTypes) Register(example interface{}, encode func(v interface{}) (interface{}, error), decode func(p interface{}) (interface{}, error)) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.types[reflect.TypeOf(example)] = propertyType{encode: encode, decode: decode}
+	pt.contains = map[reflect.Type]bool{}
+}
+
+func (pt *PropertyTypes) base() Codec {
+	if pt.Codec != nil {
+		return pt.Codec
+	}
+	return stdCodec{}
+}
+
+func (pt *PropertyTypes) lookup(t reflect.Type) (propertyType, bool) {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+	p, ok := pt.types[t]
+	return p, ok
+}
+
+// containsType reports whether values of type t can hold values of a
+// registered type, other than inside interface values.
+func (pt *PropertyTypes) containsType(t reflect.Type) bool {
+	pt.mu.RLock()
+	c, ok := pt.contains[t]
+	pt.mu.RUnlock()
+	if ok {
+		return c
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	return pt.containsLocked(t)
+}
+
+func (pt *PropertyTypes) containsLocked(t reflect.Type) bool {
+	if c, ok := pt.contains[t]; ok {
+		return c
+	}
+	pt.contains[t] = false // Recursive types
+	c := false
+	if _, ok := pt.types[t]; ok {
+		c = true
+	} else {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			c = pt.containsLocked(t.Elem())
+		case reflect.Struct:
+			for _, f := range jsonFields(t) {
+				c = c || pt.containsLocked(t.FieldByIndex(f.index).Type)
+			}
+		}
+	}
+	pt.contains[t] = c
+	return c
+}
+
+// Marshal encodes v, with values of registered types replaced by their
+// property values.
+func (pt *PropertyTypes) Marshal(v interface{}) ([]byte, error) {
+	e, changed, err := pt.encode(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return pt.base().Marshal(v)
+	}
+	return pt.base().Marshal(e)
+}
+
+// encode returns v with values of registered types replaced by their property
+// values, and whether any were.
+func (pt *PropertyTypes) encode(v reflect.Value) (interface{}, bool, error) {
+	if !v.IsValid() {
+		return nil, false, nil
+	}
+	t := v.Type()
+	if p, ok := pt.lookup(t); ok {
+		e, err := p.encode(v.Interface())
+		return e, true, err
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		return pt.encode(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return nil, false, nil
+		}
+		if !pt.mayContain(t.Elem()) {
+			return nil, false, nil
+		}
+		res := make([]interface{}, v.Len())
+		changed := false
+		for i := range res {
+			e, c, err := pt.encode(v.Index(i))
+			if err != nil {
+				return nil, false, err
+			}
+			changed = changed || c
+			if c {
+				res[i] = e
+			} else {
+				res[i] = v.Index(i).Interface()
+			}
+		}
+		return res, changed, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String || v.IsNil() || !pt.mayContain(t.Elem()) {
+			return nil, false, nil
+		}
+		res := make(map[string]interface{}, v.Len())
+		changed := false
+		for _, k := range v.MapKeys() {
+			e, c, err := pt.encode(v.MapIndex(k))
+			if err != nil {
+				return nil, false, err
+			}
+			changed = changed || c
+			if c {
+				res[k.String()] = e
+			} else {
+				res[k.String()] = v.MapIndex(k).Interface()
+			}
+		}
+		return res, changed, nil
+	case reflect.Struct:
+		if !pt.mayContain(t) {
+			return nil, false, nil
+		}
+		res := map[string]interface{}{}
+		changed := false
+		for _, f := range jsonFields(t) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			e, c, err := pt.encode(fv)
+			if err != nil {
+				return nil, false, err
+			}
+			changed = changed || c
+			if c {
+				res[f.name] = e
+			} else {
+				res[f.name] = fv.Interface()
+			}
+		}
+		return res, changed, nil
+	}
+	return nil, false, nil
+}
+
+// mayContain reports whether values of type t might hold values of a
+// registered type, inside interface values or otherwise.
+func (pt *PropertyTypes) mayContain(t reflect.Type) bool {
+	return pt.containsType(t) || hasInterface(t, map[reflect.Type]bool{})
+}
+
+// hasInterface reports whether values of type t can hold interface values.
+func hasInterface(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasInterface(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range jsonFields(t) {
+			if hasInterface(t.FieldByIndex(f.index).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Unmarshal decodes data into v, converting property values into values of
+// registered types.  Numbers are kept as json.Number until their destination
+// is known, so that integers too large for a float64 survive intact.
+func (pt *PropertyTypes) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || !pt.containsType(rv.Type()) {
+		return pt.base().Unmarshal(data, v)
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&generic)
+	if err != nil {
+		return err
+	}
+	return pt.decode(generic, rv.Elem())
+}
+
+// decode stores the generic JSON value g in v, which must be settable.
+func (pt *PropertyTypes) decode(g interface{}, v reflect.Value) error {
+	t := v.Type()
+	if !pt.containsType(t) {
+		b, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		return pt.base().Unmarshal(b, v.Addr().Interface())
+	}
+	if g == nil {
+		v.Set(reflect.Zero(t))
+		return nil
+	}
+	if p, ok := pt.lookup(t); ok {
+		// Hand decode the property as encoding/json would, with float64s
+		b, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		var plain interface{}
+		err = pt.base().Unmarshal(b, &plain)
+		if err != nil {
+			return err
+		}
+		d, err := p.decode(plain)
+		if err != nil {
+			return err
+		}
+		dv := reflect.ValueOf(d)
+		if !dv.IsValid() || !dv.Type().AssignableTo(t) {
+			return &json.UnmarshalTypeError{Value: "property", Type: t}
+		}
+		v.Set(dv)
+		return nil
+	}
+	mismatch := &json.UnmarshalTypeError{Value: reflect.TypeOf(g).String(), Type: t}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return pt.decode(g, v.Elem())
+	case reflect.Slice, reflect.Array:
+		arr, ok := g.([]interface{})
+		if !ok {
+			return mismatch
+		}
+		if t.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(t, len(arr), len(arr)))
+		}
+		for i := 0; i < len(arr) && i < v.Len(); i++ {
+			err := pt.decode(arr[i], v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := g.(map[string]interface{})
+		if !ok || t.Key().Kind() != reflect.String {
+			return mismatch
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for k, e := range m {
+			ev := reflect.New(t.Elem()).Elem()
+			err := pt.decode(e, ev)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+		}
+	case reflect.Struct:
+		m, ok := g.(map[string]interface{})
+		if !ok {
+			return mismatch
+		}
+		for _, f := range jsonFields(t) {
+			e, ok := m[f.name]
+			if !ok {
+				for k, x := range m {
+					if strings.EqualFold(k, f.name) {
+						e, ok = x, true
+						break
+					}
+				}
+			}
+			if !ok {
+				continue
+			}
+			err := pt.decode(e, fieldByIndexAlloc(v, f.index))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// A jsonField is a struct field as seen by encoding/json.
+type jsonField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// jsonFields lists the fields of struct type t as encoded by encoding/json,
+// with the fields of embedded structs promoted.
+func jsonFields(t reflect.Type) []jsonField {
+	fields := []jsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && opts[0] == "" && ft.Kind() == reflect.Struct {
+			for _, sub := range jsonFields(ft) {
+				sub.index = append([]int{i}, sub.index...)
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := opts[0]
+		if name == "" {
+			name = f.Name
+		}
+		omit := false
+		for _, o := range opts[1:] {
+			omit = omit || o == "omitempty"
+		}
+		fields = append(fields, jsonField{name: name, index: []int{i}, omitEmpty: omit})
+	}
+	return fields
+}
+
+// fieldByIndex returns the field of struct v with the given index, or false if
+// it is inside a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndexAlloc returns the field of struct v with the given index,
+// allocating any nil embedded pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// isEmptyValue reports whether v is empty, as by the omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"errors"
+	"github.com/bmizerany/assert"
+	"net"
+	"testing"
+)
+
+type level int
+
+const (
+	levelLow level = iota
+	levelHigh
+)
+
+type ptHost struct {
+	Name  string   `json:"name"`
+	Addr  net.IP   `json:"addr"`
+	Alias *net.IP  `json:"alias,omitempty"`
+	Peers []net.IP `json:"peers"`
+	Level level    `json:"level"`
+	Notes string   `json:"-"`
+}
+
+type ptRecord struct {
+	ptHost
+	Id  int   `json:"id"`
+	Big int64 `json:"big,omitempty"`
+}
+
+func testPropertyTypes() *PropertyTypes {
+	pt := NewPropertyTypes()
+	pt.Register(net.IP{},
+		func(v interface{}) (interface{}, error) { return v.(net.IP).String(), nil },
+		func(p interface{}) (interface{}, error) {
+			s, _ := p.(string)
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, errors.New("bad IP")
+			}
+			return ip, nil
+		})
+	pt.Register(levelLow,
+		func(v interface{}) (interface{}, error) { return []string{"low", "high"}[v.(level)], nil },
+		func(p interface{}) (interface{}, error) {
+			if p == "high" {
+				return levelHigh, nil
+			}
+			return levelLow, nil
+		})
+	return pt
+}
+
+func TestPropertyTypesMarshal(t *testing.T) {
+	pt := testPropertyTypes()
+	ip := net.ParseIP("10.0.0.1")
+	b, err := pt.Marshal(Props{"addr": ip, "n": 1, "list": []interface{}{levelHigh}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `{"addr":"10.0.0.1","list":["high"],"n":1}`, string(b))
+	rec := ptRecord{ptHost: ptHost{Name: "db1", Addr: ip, Peers: []net.IP{ip}, Level: levelHigh, Notes: "x"}, Id: 3}
+	b, err = pt.Marshal(map[string]interface{}{"props": rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `{"props":{"addr":"10.0.0.1","id":3,"level":"high","name":"db1","peers":["10.0.0.1"]}}`, string(b))
+	// Values without registered types are left to the underlying codec.
+	b, _ = pt.Marshal(struct{ A []byte }{[]byte("hi")})
+	assert.Equal(t, `{"A":"aGk="}`, string(b))
+}
+
+func TestPropertyTypesUnmarshal(t *testing.T) {
+	pt := testPropertyTypes()
+	res := []ptRecord{}
+	err := pt.Unmarshal([]byte(`[{"name": "db1", "addr": "10.0.0.1", "alias": "10.0.0.2",
+		"peers": ["10.0.0.3"], "level": "high", "id": 3, "big": 9007199254740993}]`), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(res))
+	r := res[0]
+	assert.Equal(t, "db1", r.Name)
+	assert.Equal(t, 3, r.Id)
+	assert.Equal(t, int64(9007199254740993), r.Big)
+	assert.Equal(t, "10.0.0.1", r.Addr.String())
+	assert.Equal(t, "10.0.0.2", r.Alias.String())
+	assert.Equal(t, "10.0.0.3", r.Peers[0].String())
+	assert.Equal(t, levelHigh, r.Level)
+	err = pt.Unmarshal([]byte(`{"addr": "nonsense"}`), &ptHost{})
+	assert.NotEqual(t, nil, err)
+	var m map[string]interface{}
+	err = pt.Unmarshal([]byte(`{"a": 1}`), &m)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1.0, m["a"])
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// A QueryCache holds the results of Cypher reads made with CachedCypher,
+// each labelled with tags naming the data it depends on - e.g. "user:42" or
+// "permissions".  Writes made with InvalidatingCypher, or calls to
+// Invalidate, discard the results carrying any of their tags.  Results are
+// also discarded once older than the cache's TTL, and least recently used
+// results are discarded to keep within its size.  Writes made any other way
+// do not invalidate the cache.  A QueryCache is safe for concurrent use, and
+// may be shared by several Databases; results are cached separately for each
+// server.
+type QueryCache struct {
+	mu       sync.Mutex
+	max      int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List                 // Most recently used at the front
+	tagged   map[string]map[string]bool // Keys of entries by tag
+	gen      uint64                     // Incremented by each invalidation
+	invalid  map[string]uint64          // Generation of each tag's last invalidation
+	inflight map[uint64]int             // Reads in progress, by generation when sent
+	purged   uint64                     // Generation of the last Purge
+	now      func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	cr      cypherResult
+	tags    []string
+	expires time.Time
+}
+
+// NewQueryCache returns a QueryCache holding up to max results, or any
+// number if max < 1, for up to ttl, or until invalidated if ttl is zero.
+func NewQueryCache(max int, ttl time.Duration) *QueryCache {
+	return &QueryCache{
+		max:      max,
+		ttl:      ttl,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+		tagged:   map[string]map[string]bool{},
+		invalid:  map[string]uint64{},
+		inflight: map[uint64]int{},
+		now:      time.Now,
+	}
+}
+
+// cacheKey identifies a query by the server it is sent to, and by its
+// statement and parameters.
+func (db *Database) cacheKey(q *CypherQuery) (string, bool) {
+	b, err := json.Marshal(cypherRequest{Query: q.Statement, Parameters: q.Parameters})
+	return db.HrefCypher + " " + string(b), err == nil
+}
+
+// Len returns the number of results cached.
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get returns the cached result for key, if there is one.  Otherwise it
+// returns the generation of the read which must now be made, which must be
+// passed to put or, if the read fails, to release.
+func (c *QueryCache) get(key string) (cypherResult, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry)
+		if c.ttl <= 0 || c.now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			return e.cr, true, c.gen
+		}
+		c.remove(el)
+	}
+	c.inflight[c.gen]++
+	return cypherResult{}, false, c.gen
+}
+
+// release ends a read of generation gen.  The caller must hold c.mu.
+func (c *QueryCache) release(gen uint64) {
+	c.inflight[gen]--
+	if c.inflight[gen] <= 0 {
+		delete(c.inflight, gen)
+	}
+	c.prune()
+}
+
+// prune forgets invalidations which no read in progress predates, and so
+// can no longer prevent a result being cached.  The caller must hold c.mu.
+func (c *QueryCache) prune() {
+	oldest := c.gen
+	for gen := range c.inflight {
+		if gen < oldest {
+			oldest = gen
+		}
+	}
+	for t, gen := range c.invalid {
+		if gen <= oldest {
+			delete(c.invalid, t)
+		}
+	}
+}
+
+// put caches cr for key under tags, unless any of the tags has been
+// invalidated since generation gen, when the query was sent.
+func (c *QueryCache) put(key string, cr cypherResult, tags []string, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.release(gen)
+	if c.purged > gen {
+		return
+	}
+	for _, t := range tags {
+		if c.invalid[t] > gen {
+			return
+		}
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	e := &cacheEntry{key: key, cr: cr, tags: tags, expires: c.now().Add(c.ttl)}
+	c.entries[key] = c.order.PushFront(e)
+	for _, t := range tags {
+		if c.tagged[t] == nil {
+			c.tagged[t] = map[string]bool{}
+		}
+		c.tagged[t][key] = true
+	}
+	for c.max > 0 && c.order.Len() > c.max {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove discards a cached result.  The caller must hold c.mu.
+func (c *QueryCache) remove(el *list.Element) {
+	e := c.order.Remove(el).(*cacheEntry)
+	delete(c.entries, e.key)
+	for _, t := range e.tags {
+		delete(c.tagged[t], e.key)
+		if len(c.tagged[t]) == 0 {
+			delete(c.tagged, t)
+		}
+	}
+}
+
+// Invalidate discards the cached results carrying any of tags, including
+// those of reads still in progress.
+func (c *QueryCache) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, t := range tags {
+		c.invalid[t] = c.gen
+		for key := range c.tagged[t] {
+			c.remove(c.entries[key])
+		}
+	}
+	c.prune()
+}
+
+// Purge discards every cached result.
+func (c *QueryCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.purged = c.gen
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+	c.tagged = map[string]map[string]bool{}
+	c.prune()
+}
+
+// CachedCypher executes q, which should only read, as Cypher does - unless
+// db.Cache holds a result for the same statement and parameters, which is
+// used instead.  A result fetched from the server is cached under tags.
+// Without a Cache, CachedCypher is the same as Cypher.
+func (db *Database) CachedCypher(q *CypherQuery, tags ...string) error {
+	if db.Cache == nil {
+		return db.Cypher(q)
+	}
+	key, ok := db.cacheKey(q)
+	if !ok {
+		return db.Cypher(q)
+	}
+	cr, hit, gen := db.Cache.get(key)
+	if !hit {
+		err := db.Cypher(q)
+		if err != nil {
+			db.Cache.mu.Lock()
+			db.Cache.release(gen)
+			db.Cache.mu.Unlock()
+			return err
+		}
+		db.Cache.put(key, q.cr, tags, gen)
+		return nil
+	}
+	q.cr = cr
+	q.codec = db.Codec
+	q.db = db
+	if q.Result != nil {
+		return q.Unmarshal(q.Result)
+	}
+	return nil
+}
+
+// InvalidatingCypher executes q, a write, as Cypher does, then discards the
+// results in db.Cache carrying any of tags.  They are discarded even if q
+// fails, as it may have been applied regardless.
+func (db *Database) InvalidatingCypher(q *CypherQuery, tags ...string) error {
+	err := db.Cypher(q)
+	if db.Cache != nil {
+		db.Cache.Invalidate(tags...)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestQueryCacheEviction(t *testing.T) {
+	c := NewQueryCache(2, time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	c.put("a", cypherResult{Columns: []string{"a"}}, []string{"x"}, 0)
+	c.put("b", cypherResult{Columns: []string{"b"}}, []string{"x", "y"}, 0)
+	_, hit, _ := c.get("a")
+	assert.T(t, hit)
+	c.put("c", cypherResult{Columns: []string{"c"}}, nil, 0)
+	_, hit, _ = c.get("b")
+	assert.Tf(t, !hit, "Least recently used result was not evicted")
+	assert.Equal(t, 2, c.Len())
+	c.Invalidate("x")
+	assert.Equal(t, 1, c.Len())
+	cr, hit, _ := c.get("c")
+	assert.T(t, hit)
+	assert.Equal(t, []string{"c"}, cr.Columns)
+	now = now.Add(time.Minute)
+	_, hit, _ = c.get("c")
+	assert.Tf(t, !hit, "Expired result was returned")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestQueryCacheInFlight(t *testing.T) {
+	c := NewQueryCache(0, 0)
+	_, _, gen := c.get("a")
+	c.Invalidate("x")
+	c.put("a", cypherResult{}, []string{"x"}, gen)
+	assert.Equal(t, 0, c.Len())
+	_, _, gen = c.get("a")
+	c.put("a", cypherResult{}, []string{"x"}, gen)
+	assert.Equal(t, 1, c.Len())
+	_, _, gen = c.get("b")
+	c.Purge()
+	assert.Equal(t, 0, c.Len())
+	c.put("b", cypherResult{}, nil, gen)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestQueryCachePrune(t *testing.T) {
+	c := NewQueryCache(0, 0)
+	c.Invalidate("user:1", "user:2")
+	assert.Equal(t, 0, len(c.invalid))
+	_, _, gen := c.get("a")
+	c.Invalidate("user:3")
+	assert.Equal(t, 1, len(c.invalid))
+	c.put("a", cypherResult{}, []string{"user:3"}, gen)
+	assert.Equal(t, 0, len(c.invalid))
+	assert.Equal(t, 0, len(c.inflight))
+}
+
+func TestCachedCypher(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"columns": ["n"], "data": [[`+strconv.Itoa(calls)+`]]}`)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"cypher": srv.URL + "/db/data/cypher"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Cache = NewQueryCache(10, 0)
+	read := func(user int) int {
+		res := []struct {
+			N int `json:"n"`
+		}{}
+		cq := CypherQuery{
+			Statement:  "MATCH (u:User) WHERE id(u) = {id} RETURN count(*) AS n",
+			Parameters: Props{"id": user},
+			Result:     &res,
+		}
+		err := db.CachedCypher(&cq, "user:"+strconv.Itoa(user))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res[0].N
+	}
+	assert.Equal(t, 1, read(1))
+	assert.Equal(t, 1, read(1))
+	assert.Equal(t, 2, read(2))
+	err = db.InvalidatingCypher(&CypherQuery{Statement: "MATCH (u:User) SET u.x = 1"}, "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, read(1))
+	assert.Equal(t, 2, read(2))
+	assert.Equal(t, 4, calls)
+	// Results are not shared between servers
+	other := *db
+	other.HrefCypher = srv.URL + "/other/cypher"
+	db = &other
+	assert.Equal(t, 5, read(1))
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A Recommendation builds a collaborative filtering query, which ranks the
+// nodes reachable from a start node by a fixed number of hops over the given
+// relationship types.  For instance, with LIKES relationships from users to
+// items:
+//
+//	// Users who liked this item also liked...
+//	NewRecommendation("LIKES").Hops(2)
+//	// Items liked by users who like the same things as this user.
+//	NewRecommendation("LIKES").Hops(3)
+//
+// Relationship direction is ignored.  Nodes directly related to the start
+// node are excluded, so users are not recommended things they already like.
+type Recommendation struct {
+	relTypes []string
+	hops     int
+	label    string
+	scoreBy  string
+	limit    int
+}
+
+// NewRecommendation returns a Recommendation following relTypes, over 2 hops,
+// scoring by number of paths and returning the top 10.
+func NewRecommendation(relTypes ...string) *Recommendation {
+	return &Recommendation{relTypes: relTypes, hops: 2, limit: 10}
+}
+
+// Hops sets the number of relationships between the start node and a
+// recommended node.
+func (r *Recommendation) Hops(n int) *Recommendation {
+	r.hops = n
+	return r
+}
+
+// Label restricts recommendations to nodes with label.
+func (r *Recommendation) Label(label string) *Recommendation {
+	r.label = label
+	return r
+}
+
+// ScoreBy scores recommendations by the sum of property prop - a rating,
+// say - of the last relationship of each path reaching them, rather than by
+// the number of paths.  Relationships without the property count as 0.
+func (r *Recommendation) ScoreBy(prop string) *Recommendation {
+	r.scoreBy = prop
+	return r
+}
+
+// Limit sets the maximum number of recommendations returned.
+func (r *Recommendation) Limit(n int) *Recommendation {
+	r.limit = n
+	return r
+}
+
+// Statement returns the Cypher statement for the recommendation.  It takes
+// parameters start, the start node ID, and limit.
+func (r *Recommendation) Statement() string {
+	types := make([]string, len(r.relTypes))
+	for i, t := range r.relTypes {
+		types[i] = quoteIdent(t)
+	}
+	rel := ""
+	if len(types) > 0 {
+		rel = ":" + strings.Join(types, "|")
+	}
+	rec := "rec"
+	if r.label != "" {
+		rec += ":" + quoteIdent(r.label)
+	}
+	score := "count(*)"
+	if r.scoreBy != "" {
+		score = "sum(coalesce(last(rs)." + quoteIdent(r.scoreBy) + ", 0))"
+	}
+	return "START start=node({start}) " +
+		"MATCH (start)-[rs" + rel + "*" + strconv.Itoa(r.hops) + "]-(" + rec + ") " +
+		"WHERE rec <> start AND NOT (start)-[" + rel + "]-(rec) " +
+		"RETURN rec, " + score + " AS score " +
+		"ORDER BY score DESC, id(rec) LIMIT {limit}"
+}
+
+// A Recommended node, with its score.
+type Recommended struct {
+	Node  *Node
+	Score float64
+}
+
+// Recommend executes r from start, returning recommendations best first.
+func (db *Database) Recommend(start *Node, r *Recommendation) ([]Recommended, error) {
+	res := []struct {
+		Rec   Node    `json:"rec"`
+		Score float64 `json:"score"`
+	}{}
+	cq := CypherQuery{
+		Statement:  r.Statement(),
+		Parameters: Props{"start": start.Id(), "limit": r.limit},
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]Recommended, len(res))
+	for i := range res {
+		n := res[i].Rec
+		n.Db = db
+		recs[i] = Recommended{Node: &n, Score: res[i].Score}
+	}
+	return recs, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestRecommendationStatement(t *testing.T) {
+	r := NewRecommendation("LIKES", "BOUGHT").Hops(3).Label("Item").ScoreBy("rating").Limit(5)
+	exp := "START start=node({start}) " +
+		"MATCH (start)-[rs:`LIKES`|`BOUGHT`*3]-(rec:`Item`) " +
+		"WHERE rec <> start AND NOT (start)-[:`LIKES`|`BOUGHT`]-(rec) " +
+		"RETURN rec, sum(coalesce(last(rs).`rating`, 0)) AS score " +
+		"ORDER BY score DESC, id(rec) LIMIT {limit}"
+	assert.Equal(t, exp, r.Statement())
+	assert.Equal(t, 5, r.limit)
+}
+
+func TestRecommend(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	users := make([]*Node, 3)
+	items := make([]*Node, 4)
+	for i := range users {
+		users[i], _ = db.CreateNode(Props{"user": i})
+	}
+	for i := range items {
+		items[i], _ = db.CreateNode(Props{"item": i})
+		items[i].AddLabel("Item")
+	}
+	likes := [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 2}, {2, 0}, {2, 2}, {2, 3}}
+	for _, l := range likes {
+		users[l[0]].Relate("LIKES", items[l[1]].Id(), Props{"rating": l[1]})
+	}
+	// Users who liked item 0 also liked item 2 (twice), then 1 and 3.
+	recs, err := db.Recommend(items[0], NewRecommendation("LIKES"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(recs))
+	assert.Equal(t, items[2].Id(), recs[0].Node.Id())
+	assert.Equal(t, 2.0, recs[0].Score)
+	// User 0 has not yet liked items 2 or 3.
+	recs, err = db.Recommend(users[0], NewRecommendation("LIKES").Hops(3).Label("Item").ScoreBy("rating").Limit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(recs))
+	assert.Equal(t, items[2].Id(), recs[0].Node.Id())
+}
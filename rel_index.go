@@ -50,3 +50,49 @@ func (rix *LegacyRelationshipIndex) Remove(r *Relationship, key, value string) e
 	id := strconv.Itoa(r.Id())
 	return rix.remove(r.entity, id, key, value)
 }
+
+// AddNumeric indexes a relationship with a key and numeric value, encoded with
+// SortableFloat so that it can be found by FindRange.
+func (rix *LegacyRelationshipIndex) AddNumeric(r *Relationship, key string, value float64) error {
+	return rix.add(r.entity, key, SortableFloat(value))
+}
+
+// FindRange locates Relationships added with AddNumeric under key, with values
+// within the inclusive range [min, max].
+func (rix *LegacyRelationshipIndex) FindRange(key string, min, max float64) (map[int]*Relationship, error) {
+	hits, err := rix.QueryScored(rix.numericRange(key, min, max))
+	if err != nil {
+		return nil, err
+	}
+	rm := make(map[int]*Relationship, len(hits))
+	for _, h := range hits {
+		rm[h.Relationship.Id()] = h.Relationship
+	}
+	return rm, nil
+}
+
+// A ScoredRelationship is a relationship found by QueryScored, with the
+// relevance of the match as scored by Lucene.
+type ScoredRelationship struct {
+	Relationship *Relationship
+	Score        float64
+}
+
+// QueryScored finds relationships with a query, most relevant first.
+func (rix *LegacyRelationshipIndex) QueryScored(query string) ([]ScoredRelationship, error) {
+	res := []struct {
+		Relationship
+		Score float64 `json:"score"`
+	}{}
+	err := rix.queryScored(query, &res)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]ScoredRelationship, len(res))
+	for i := range res {
+		r := res[i].Relationship
+		r.Db = rix.db
+		hits[i] = ScoredRelationship{Relationship: &r, Score: res[i].Score}
+	}
+	return hits, nil
+}
@@ -43,6 +43,33 @@ func TestRelationshipIndexes(t *testing.T) {
 	assert.Equal(t, idx0.Name, idx2.Name)
 }
 
+func TestFindRelationshipByNumericRange(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	idx, _ := db.CreateLegacyRelIndex(rndStr(t), "", "")
+	defer idx.Delete()
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	rels := []*Relationship{}
+	for _, w := range []float64{-2, 0.5, 3, 12} {
+		r, _ := n0.Relate("knows", n1.Id(), nil)
+		err := idx.AddNumeric(r, "weight", w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, r)
+	}
+	found, err := idx.FindRange("weight", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(found))
+	_, ok := found[rels[1].Id()]
+	assert.T(t, ok)
+	_, ok = found[rels[2].Id()]
+	assert.T(t, ok)
+}
+
 func TestBadCreateLegacyRelIndex(t *testing.T) {
 	db := connectTest(t)
 	_, err := db.CreateLegacyRelIndex("", "", "")
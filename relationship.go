@@ -8,14 +8,13 @@ import (
 	"github.com/jmcvetta/restclient"
 	"sort"
 	"strconv"
-	"strings"
 )
 
 // Relationship fetches a Relationship from by id.
 func (db *Database) Relationship(id int) (*Relationship, error) {
 	rel := Relationship{}
 	rel.Db = db
-	uri := join(db.Url, "relationship", strconv.Itoa(id))
+	uri := db.hrefRelationship(id)
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
 		Url:    uri,
@@ -23,7 +22,7 @@ func (db *Database) Relationship(id int) (*Relationship, error) {
 		Result: &rel,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return &rel, err
 	}
@@ -39,6 +38,13 @@ func (db *Database) Relationship(id int) (*Relationship, error) {
 	return &rel, err
 }
 
+// RelationshipExists reports whether there is a relationship with the given
+// ID, without fetching its properties.
+func (db *Database) RelationshipExists(id int) (bool, error) {
+	count, err := db.cypherCount("MATCH ()-[r]->() WHERE id(r) = {id} RETURN count(r) AS count", Props{"id": id})
+	return count > 0, err
+}
+
 // Types lists all existing relationship types
 func (db *Database) RelTypes() ([]string, error) {
 	reltypes := []string{}
@@ -49,7 +55,7 @@ func (db *Database) RelTypes() ([]string, error) {
 		Result: &reltypes,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&c)
+	status, err := db.do(&c)
 	if err != nil {
 		return reltypes, err
 	}
@@ -61,6 +67,79 @@ func (db *Database) RelTypes() ([]string, error) {
 	return reltypes, ne
 }
 
+// A RelSpec describes a relationship to be created by CreateRelationships.
+type RelSpec struct {
+	Start int    // ID of the start node
+	End   int    // ID of the end node
+	Type  string // Relationship type
+	Props Props  // Optional properties
+}
+
+// CreateRelationships creates relationships as described by specs in a single
+// batch request, returning them in the same order as specs.  Either all the
+// relationships are created or none are.
+func (db *Database) CreateRelationships(specs []RelSpec) ([]*Relationship, error) {
+	rels := make([]*Relationship, len(specs))
+	if len(specs) == 0 {
+		return rels, nil
+	}
+	for _, s := range specs {
+		if err := db.Vocabulary.CheckRelTypes(s.Type); err != nil {
+			return nil, err
+		}
+	}
+	jobs := make([]*batchJob, len(specs))
+	for i, s := range specs {
+		body := map[string]interface{}{
+			"to":   join(db.HrefNode, strconv.Itoa(s.End)),
+			"type": s.Type,
+		}
+		if s.Props != nil {
+			body["data"] = s.Props
+		}
+		jobs[i] = &batchJob{
+			Method: "POST",
+			To:     db.relPath(join(db.HrefNode, strconv.Itoa(s.Start), "relationships")),
+			Id:     i,
+			Body:   body,
+		}
+	}
+	res, err := db.sendBatch(jobs)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range res {
+		rel := Relationship{}
+		err := db.codec().Unmarshal(r.Body, &rel)
+		if err != nil {
+			return nil, err
+		}
+		rel.Db = db
+		rels[i] = &rel
+	}
+	for _, rel := range rels {
+		db.relCreated(rel)
+	}
+	return rels, nil
+}
+
+// RelateBoth creates a pair of opposing relationships of relType between a
+// and b, each with properties p, in a single batch request.  Neo4j
+// relationships are always directed, so mutual connections such as
+// friendships are modelled as two relationships; both are created or
+// neither is.  The relationship from a to b is returned first.
+func (db *Database) RelateBoth(a, b *Node, relType string, p Props) (*Relationship, *Relationship, error) {
+	specs := []RelSpec{
+		RelSpec{Start: a.Id(), End: b.Id(), Type: relType, Props: p},
+		RelSpec{Start: b.Id(), End: a.Id(), Type: relType, Props: p},
+	}
+	rels, err := db.CreateRelationships(specs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rels[0], rels[1], nil
+}
+
 // A Relationship is a directional connection between two Nodes, with an
 // optional set of arbitrary properties.
 type Relationship struct {
@@ -70,6 +149,8 @@ type Relationship struct {
 	HrefEnd    string      `json:"end"`
 	Data       interface{} `json:"data"`
 	Extensions interface{} `json:"extensions"`
+	startNode  *Node       // Cached by StartNode()
+	endNode    *Node       // Cached by EndNode()
 }
 
 func (r *Relationship) hrefSelf() string {
@@ -78,16 +159,42 @@ func (r *Relationship) hrefSelf() string {
 
 // Id gets the ID number of this Relationship
 func (r *Relationship) Id() int {
-	parts := strings.Split(r.HrefSelf, "/")
-	s := parts[len(parts)-1]
-	id, err := strconv.Atoi(s)
+	id, err := idFromHref(r.HrefSelf)
 	if err != nil {
-		// Are both r.Info and r.Node valid?
 		panic(err)
 	}
 	return id
 }
 
+// StartId gets the ID number of the starting Node of this Relationship,
+// without fetching the node.
+func (r *Relationship) StartId() int {
+	id, err := idFromHref(r.HrefStart)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// EndId gets the ID number of the ending Node of this Relationship, without
+// fetching the node.
+func (r *Relationship) EndId() int {
+	id, err := idFromHref(r.HrefEnd)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// Delete removes the relationship from the database.
+func (r *Relationship) Delete() error {
+	err := r.entity.Delete()
+	if err == nil {
+		r.Db.relDeleted(r)
+	}
+	return err
+}
+
 // Start gets the starting Node of this Relationship.
 func (r *Relationship) Start() (*Node, error) {
 	// log.Println("INFO", r.Info)
@@ -99,6 +206,32 @@ func (r *Relationship) End() (*Node, error) {
 	return r.Db.getNodeByUri(r.HrefEnd)
 }
 
+// StartNode gets the starting Node of this Relationship.  Unlike Start, the
+// node is fetched only on the first call, and cached thereafter.
+func (r *Relationship) StartNode() (*Node, error) {
+	if r.startNode == nil {
+		n, err := r.Start()
+		if err != nil {
+			return nil, err
+		}
+		r.startNode = n
+	}
+	return r.startNode, nil
+}
+
+// EndNode gets the ending Node of this Relationship.  Unlike End, the node is
+// fetched only on the first call, and cached thereafter.
+func (r *Relationship) EndNode() (*Node, error) {
+	if r.endNode == nil {
+		n, err := r.End()
+		if err != nil {
+			return nil, err
+		}
+		r.endNode = n
+	}
+	return r.endNode, nil
+}
+
 // A Rels is a collection of relationships.
 type Rels []*Relationship
 
@@ -63,6 +63,27 @@ func TestCreateRelationshipWithProperties(t *testing.T) {
 	assert.Equalf(t, props0, props1, "Properties queried from relationship do not match properties it was created with.")
 }
 
+func TestRelateTo(t *testing.T) {
+	ct := &countingTransport{}
+	db, err := ConnectWithOptions("http://localhost:7474/db/data", &ConnectOptions{Transport: ct})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	before := ct.n
+	r0, err := n0.RelateTo("knows", n1, Props{"since": 1999})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Properties are sent with the creation request
+	assert.Equal(t, before+1, ct.n)
+	assert.Equal(t, n1.Id(), r0.EndId())
+	props, _ := r0.Properties()
+	assert.Equal(t, Props{"since": float64(1999)}, props)
+}
+
 // 18.5.4. Delete relationship
 func TestDeleteRelationship(t *testing.T) {
 	db := connectTest(t)
@@ -303,3 +324,75 @@ func TestRelationshipStartEnd(t *testing.T) {
 	}
 	assert.Equal(t, end, n)
 }
+
+func TestCreateRelationships(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	n2, _ := db.CreateNode(Props{})
+	specs := []RelSpec{
+		RelSpec{Start: n0.Id(), End: n1.Id(), Type: "knows", Props: Props{"since": 1999}},
+		RelSpec{Start: n1.Id(), End: n2.Id(), Type: "likes"},
+	}
+	rels, err := db.CreateRelationships(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(rels))
+	assert.Equal(t, "knows", rels[0].Type)
+	assert.Equal(t, "likes", rels[1].Type)
+	props, _ := rels[0].Properties()
+	assert.Equal(t, Props{"since": float64(1999)}, props)
+	rels, _ = n1.Outgoing("likes")
+	assert.Equal(t, 1, len(rels))
+	//
+	// Bad spec fails the whole batch
+	//
+	specs = append(specs, RelSpec{Start: n0.Id(), End: n0.Id() + 1000, Type: "knows"})
+	_, err = db.CreateRelationships(specs)
+	assert.NotEqual(t, nil, err)
+	rels, _ = n0.Outgoing("knows")
+	assert.Equal(t, 1, len(rels))
+}
+
+func TestRelateBoth(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	a, _ := db.CreateNode(Props{})
+	b, _ := db.CreateNode(Props{})
+	ab, ba, err := db.RelateBoth(a, b, "friend", Props{"since": 2001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, a.Id(), ab.StartId())
+	assert.Equal(t, b.Id(), ab.EndId())
+	assert.Equal(t, b.Id(), ba.StartId())
+	assert.Equal(t, a.Id(), ba.EndId())
+	props, _ := ba.Properties()
+	assert.Equal(t, Props{"since": float64(2001)}, props)
+	rels, _ := a.Relationships("friend")
+	assert.Equal(t, 2, len(rels))
+}
+
+func TestRelationshipStartEndIds(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	start, _ := db.CreateNode(Props{})
+	end, _ := db.CreateNode(Props{})
+	r0, _ := start.Relate("knows", end.Id(), Props{})
+	r1, _ := db.Relationship(r0.Id())
+	assert.Equal(t, start.Id(), r1.StartId())
+	assert.Equal(t, end.Id(), r1.EndId())
+	db.ResetStatistics()
+	n0, err := r1.StartNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1, _ := r1.StartNode()
+	assert.T(t, n0 == n1)
+	assert.Equal(t, start.Id(), n0.Id())
+	n2, _ := r1.EndNode()
+	assert.Equal(t, end.Id(), n2.Id())
+	assert.Equal(t, 2, db.Statistics()["GET /db/data/node/{id}"].Calls)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/jmcvetta/restclient"
+	"net/http"
+)
+
+// RequestIdHeader is the HTTP header carrying the ID of each request, so that
+// client errors can be correlated with the logs of the server or of proxies
+// in front of it.  The ID also appears in debug logs, in NeoError and in
+// EndpointStats.
+const RequestIdHeader = "X-Request-Id"
+
+// newRequestId returns a random 16 character hexadecimal ID.
+func newRequestId() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// setRequestId gives rr a new request ID, unless one has been set by the
+// request itself or by WithHeaders, and returns it.
+func setRequestId(rr *restclient.RequestResponse) string {
+	if rr.Header != nil {
+		if id := rr.Header.Get(RequestIdHeader); id != "" {
+			return id
+		}
+	}
+	h := http.Header{}
+	if rr.Header != nil {
+		for k, vs := range *rr.Header {
+			h[k] = vs
+		}
+	}
+	id := newRequestId()
+	h.Set(RequestIdHeader, id)
+	rr.Header = &h
+	return id
+}
+
+// requestId returns the ID of rr, as set by setRequestId.
+func requestId(rr *restclient.RequestResponse) string {
+	if rr.Header == nil {
+		return ""
+	}
+	return rr.Header.Get(RequestIdHeader)
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/jmcvetta/restclient"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	var seen string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(RequestIdHeader)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(400)
+		io.WriteString(w, `{"message": "Syntax error"}`)
+	}))
+	defer srv.Close()
+	db := &Database{Rc: restclient.New(), HrefCypher: srv.URL + "/db/data/cypher", stats: newStatsRegistry()}
+	err := db.Cypher(&CypherQuery{Statement: "foobar"})
+	ne, ok := err.(NeoError)
+	if !ok {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 16, len(seen))
+	assert.Equal(t, seen, ne.RequestId)
+	assert.Equal(t, "Syntax error (request "+seen+")", ne.Error())
+	assert.Equal(t, seen, db.Statistics()["POST /db/data/cypher"].LastError)
+	first := seen
+	db.Cypher(&CypherQuery{Statement: "foobar"})
+	assert.NotEqual(t, first, seen)
+	//
+	// An ID given by the caller is kept.
+	//
+	err = db.WithHeaders(http.Header{RequestIdHeader: {"job-42"}}).Cypher(&CypherQuery{Statement: "foobar"})
+	assert.Equal(t, "job-42", seen)
+	assert.Equal(t, "job-42", err.(NeoError).RequestId)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"strconv"
+)
+
+// Formats in which the transactional endpoint can return results, for
+// CypherQuery.ResultDataContents.
+const (
+	RowFormat   = "row"   // Plain values; nodes and relationships as property maps
+	GraphFormat = "graph" // The nodes and relationships of each row
+	RESTFormat  = "REST"  // Nodes and relationships as by the REST API
+)
+
+// A GraphNode is a node in the graph result format.
+type GraphNode struct {
+	Id         string                 `json:"id"`
+	Labels     []string               `json:"labels"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// A GraphRelationship is a relationship in the graph result format.
+type GraphRelationship struct {
+	Id         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	StartNode  string                 `json:"startNode"`
+	EndNode    string                 `json:"endNode"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// A ResultGraph is the nodes and relationships returned by a query in the
+// graph result format.
+type ResultGraph struct {
+	Nodes         []GraphNode         `json:"nodes"`
+	Relationships []GraphRelationship `json:"relationships"`
+}
+
+// Graph returns the distinct nodes and relationships of every row of the
+// query's result, in order of first appearance.  The query must have been
+// executed in a transaction with GraphFormat among its ResultDataContents;
+// otherwise the graph is empty.
+func (cq *CypherQuery) Graph() *ResultGraph {
+	g := &ResultGraph{Nodes: []GraphNode{}, Relationships: []GraphRelationship{}}
+	nodes := map[string]bool{}
+	rels := map[string]bool{}
+	for _, rg := range cq.cr.Graphs {
+		if rg == nil {
+			continue
+		}
+		for _, n := range rg.Nodes {
+			if !nodes[n.Id] {
+				nodes[n.Id] = true
+				g.Nodes = append(g.Nodes, n)
+			}
+		}
+		for _, r := range rg.Relationships {
+			if !rels[r.Id] {
+				rels[r.Id] = true
+				g.Relationships = append(g.Relationships, r)
+			}
+		}
+	}
+	return g
+}
+
+// Graph returns the structure of rg as a Graph, for analysis.
+func (rg *ResultGraph) Graph() *Graph {
+	g := NewGraph()
+	for _, n := range rg.Nodes {
+		id, err := strconv.Atoi(n.Id)
+		if err == nil {
+			g.AddNode(id)
+		}
+	}
+	for _, r := range rg.Relationships {
+		from, err := strconv.Atoi(r.StartNode)
+		if err != nil {
+			continue
+		}
+		to, err := strconv.Atoi(r.EndNode)
+		if err != nil {
+			continue
+		}
+		g.AddEdge(from, to)
+	}
+	return g
+}
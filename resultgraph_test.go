@@ -0,0 +1,56 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestResultDataContents(t *testing.T) {
+	b, _ := json.Marshal(txRequest{Statements: []*CypherQuery{
+		&CypherQuery{Statement: "RETURN 1", ResultDataContents: []string{RowFormat, GraphFormat}},
+		&CypherQuery{Statement: "RETURN 2"},
+	}})
+	assert.Equal(t, `{"statements":[{"statement":"RETURN 1","parameters":null,"resultDataContents":["row","graph"]},{"statement":"RETURN 2","parameters":null}]}`, string(b))
+	res := `{"columns": ["a", "r"], "data": [
+		{"row": [{"name": "a"}, {}], "graph": {
+			"nodes": [{"id": "1", "labels": ["P"], "properties": {"name": "a"}}, {"id": "2", "labels": ["P"], "properties": {}}],
+			"relationships": [{"id": "7", "type": "KNOWS", "startNode": "1", "endNode": "2", "properties": {}}]}},
+		{"row": [{"name": "a"}, {}], "graph": {
+			"nodes": [{"id": "1", "labels": ["P"], "properties": {"name": "a"}}, {"id": "3", "labels": [], "properties": {}}],
+			"relationships": [{"id": "8", "type": "KNOWS", "startNode": "1", "endNode": "3", "properties": {}}]}}
+	]}`
+	cq := CypherQuery{}
+	err := json.Unmarshal([]byte(res), &cq.cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := cq.Rows()
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, map[string]interface{}{"name": "a"}, rows[1][0])
+	g := cq.Graph()
+	assert.Equal(t, 3, len(g.Nodes))
+	assert.Equal(t, []string{"P"}, g.Nodes[0].Labels)
+	assert.Equal(t, 2, len(g.Relationships))
+	assert.Equal(t, []int{2, 3}, g.Graph().Out(1))
+	//
+	// Legacy array rows, and REST rows, are decoded too.
+	//
+	err = json.Unmarshal([]byte(`{"columns": ["x"], "data": [[1], [2]]}`), &cq.cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ = cq.Rows()
+	assert.Equal(t, [][]interface{}{{1.0}, {2.0}}, rows)
+	assert.Equal(t, 0, len(cq.Graph().Nodes))
+	err = json.Unmarshal([]byte(`{"columns": ["x"], "data": [{"row": [1], "rest": [2]}]}`), &cq.cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ = cq.Rows()
+	assert.Equal(t, [][]interface{}{{2.0}}, rows)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Rows returns the result data of an executed query, decoded into Go values
+// with no need for a result struct.  Cells holding nodes, relationships and
+// paths become *Node, *Relationship and *Path, bound to the Database which
+// executed the query; collections become []interface{} and maps become
+// map[string]interface{}, with any nodes, relationships and paths inside
+// them decoded the same way.  Other values are decoded as by encoding/json.
+func (cq *CypherQuery) Rows() ([][]interface{}, error) {
+	c := cq.codec
+	if c == nil {
+		c = stdCodec{}
+	}
+	rows := make([][]interface{}, len(cq.cr.Data))
+	for i, row := range cq.cr.Data {
+		rows[i] = make([]interface{}, len(row))
+		for j, col := range row {
+			if col == nil {
+				continue
+			}
+			var v interface{}
+			err := c.Unmarshal(*col, &v)
+			if err != nil {
+				return nil, err
+			}
+			rows[i][j], err = cq.hydrate(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rows, nil
+}
+
+// hasKeys reports whether m has every one of keys.
+func hasKeys(m map[string]interface{}, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := m[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hydrate replaces the JSON representations of nodes, relationships and paths
+// within v with their Go types.
+func (cq *CypherQuery) hydrate(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, elem := range v {
+			h, err := cq.hydrate(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = h
+		}
+		return v, nil
+	case map[string]interface{}:
+		var target interface{}
+		switch {
+		case hasKeys(v, "start", "end", "nodes", "relationships", "length"):
+			target = &Path{Db: cq.db}
+		case hasKeys(v, "self", "type", "start", "end"):
+			r := &Relationship{}
+			r.Db = cq.db
+			target = r
+		case hasKeys(v, "self", "data"):
+			n := &Node{}
+			n.Db = cq.db
+			target = n
+		default:
+			for k, elem := range v {
+				h, err := cq.hydrate(elem)
+				if err != nil {
+					return nil, err
+				}
+				v[k] = h
+			}
+			return v, nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(b, target)
+		if err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+	return v, nil
+}
+
+var (
+	nodeType = reflect.TypeOf(Node{})
+	relType  = reflect.TypeOf(Relationship{})
+	pathType = reflect.TypeOf(Path{})
+)
+
+// bind walks a decoded result, binding every Node, Relationship and Path
+// found to the Database which executed the query, and hydrating nodes,
+// relationships and paths decoded into interface{} values as Rows does.
+func (cq *CypherQuery) bind(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			cq.bind(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		h, err := cq.hydrate(v.Interface())
+		if err == nil && h != nil {
+			v.Set(reflect.ValueOf(h))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			cq.bind(v.Index(i))
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Interface {
+			return
+		}
+		for _, k := range v.MapKeys() {
+			e := v.MapIndex(k)
+			if e.IsNil() {
+				continue
+			}
+			h, err := cq.hydrate(e.Interface())
+			if err == nil && h != nil {
+				v.SetMapIndex(k, reflect.ValueOf(h))
+			}
+		}
+	case reflect.Struct:
+		switch v.Type() {
+		case nodeType, relType, pathType:
+			db := v.FieldByName("Db")
+			if db.CanSet() && db.IsNil() {
+				db.Set(reflect.ValueOf(cq.db))
+			}
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				cq.bind(v.Field(i))
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestRows(t *testing.T) {
+	db := &Database{HrefNode: "http://localhost:7474/db/data/node"}
+	node := `{"self": "http://localhost:7474/db/data/node/1", "outgoing_relationships": "x", "data": {"name": "Bob"}}`
+	rel := `{"self": "http://localhost:7474/db/data/relationship/5", "type": "KNOWS", "start": "a", "end": "b", "data": {}}`
+	path := `{"start": "a", "end": "b", "nodes": ["a", "b"], "relationships": ["r"], "length": 1}`
+	raw := func(s string) *json.RawMessage {
+		m := json.RawMessage(s)
+		return &m
+	}
+	cq := CypherQuery{db: db, cr: cypherResult{
+		Columns: []string{"n", "r", "p", "ns", "m", "x"},
+		Data: [][]*json.RawMessage{
+			{raw(node), raw(rel), raw(path), raw("[" + node + ", 2]"), raw(`{"friend": ` + node + `, "k": "v"}`), nil},
+		},
+	}}
+	rows, err := cq.Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(rows))
+	row := rows[0]
+	n := row[0].(*Node)
+	assert.Equal(t, "Bob", n.Data["name"])
+	assert.Equal(t, 1, n.Id())
+	r := row[1].(*Relationship)
+	assert.Equal(t, "KNOWS", r.Type)
+	assert.Equal(t, db, r.Db)
+	p := row[2].(*Path)
+	assert.Equal(t, 1, p.Length)
+	assert.Equal(t, []string{"a", "b"}, p.HrefNodes)
+	ns := row[3].([]interface{})
+	assert.Equal(t, "Bob", ns[0].(*Node).Data["name"])
+	assert.Equal(t, 2.0, ns[1])
+	m := row[4].(map[string]interface{})
+	assert.Equal(t, db, m["friend"].(*Node).Db)
+	assert.Equal(t, "v", m["k"])
+	assert.Equal(t, nil, row[5])
+}
+
+func TestUnmarshalBindsEntities(t *testing.T) {
+	db := &Database{HrefNode: "http://localhost:7474/db/data/node"}
+	node := `{"self": "http://localhost:7474/db/data/node/1", "data": {"name": "Bob"}}`
+	rel := `{"self": "http://localhost:7474/db/data/relationship/5", "type": "KNOWS", "start": "a", "end": "b", "data": {}}`
+	m := json.RawMessage(node)
+	r := json.RawMessage(rel)
+	ns := json.RawMessage("[" + node + "]")
+	cq := CypherQuery{db: db, cr: cypherResult{
+		Columns: []string{"n", "r", "x", "ns"},
+		Data:    [][]*json.RawMessage{{&m, &r, &m, &ns}},
+	}}
+	res := []struct {
+		N  Node          `json:"n"`
+		R  *Relationship `json:"r"`
+		X  interface{}   `json:"x"`
+		Ns []Node        `json:"ns"`
+	}{}
+	err := cq.Unmarshal(&res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, db, res[0].N.Db)
+	assert.Equal(t, 1, res[0].N.Id())
+	assert.Equal(t, db, res[0].R.Db)
+	assert.Equal(t, "Bob", res[0].X.(*Node).Data["name"])
+	assert.Equal(t, db, res[0].Ns[0].Db)
+	//
+	// Results which are not slices are bound too.
+	//
+	var all interface{}
+	err = cq.Unmarshal(&all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := all.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "KNOWS", row["r"].(*Relationship).Type)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// SampleNodes returns up to n nodes with label, chosen uniformly at random by
+// the server.  Only the sample is transferred, though the server still reads
+// every node with the label.
+func (db *Database) SampleNodes(label string, n int) ([]*Node, error) {
+	stmt := "MATCH (n:" + quoteIdent(label) + ") WITH n, rand() AS r ORDER BY r LIMIT {n} RETURN n"
+	return db.cypherNodes(stmt, Props{"n": n})
+}
+
+// RandomWalk walks up to steps relationships from start, each time moving to
+// a neighbour chosen uniformly at random among those related by any of
+// relTypes, ignoring direction.  With no relTypes, relationships of any type
+// are followed.  It returns the nodes visited, start first; the walk ends
+// early at a node with no neighbours.  Each step is a separate request, so
+// only the nodes visited are read.
+func (db *Database) RandomWalk(start *Node, steps int, relTypes ...string) ([]*Node, error) {
+	stmt := "START n=node({id}) MATCH (n)-" + relPattern(relTypes...) + "-(m) " +
+		"WITH m, rand() AS r ORDER BY r LIMIT 1 RETURN m"
+	walk := []*Node{start}
+	current := start
+	for i := 0; i < steps; i++ {
+		next, err := db.cypherNodes(stmt, Props{"id": current.Id()})
+		if err != nil {
+			return walk, err
+		}
+		if len(next) == 0 {
+			break
+		}
+		current = next[0]
+		walk = append(walk, current)
+	}
+	return walk, nil
+}
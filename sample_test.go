@@ -0,0 +1,58 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestRelPattern(t *testing.T) {
+	assert.Equal(t, "[]", relPattern())
+	assert.Equal(t, "[]", relPattern(""))
+	assert.Equal(t, "[:`a`|`b`]", relPattern("a", "", "b"))
+}
+
+func TestSampleNodes(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	for i := 0; i < 10; i++ {
+		n, _ := db.CreateNode(Props{"i": i})
+		n.AddLabel("Sample")
+	}
+	nodes, err := db.SampleNodes("Sample", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(nodes))
+	nodes, _ = db.SampleNodes("Sample", 20)
+	assert.Equal(t, 10, len(nodes))
+}
+
+func TestRandomWalk(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	// A chain a - b - c, plus c - d by another type.
+	a, _ := db.CreateNode(Props{})
+	b, _ := db.CreateNode(Props{})
+	c, _ := db.CreateNode(Props{})
+	d, _ := db.CreateNode(Props{})
+	a.Relate("next", b.Id(), nil)
+	b.Relate("next", c.Id(), nil)
+	c.Relate("other", d.Id(), nil)
+	walk, err := db.RandomWalk(a, 5, "next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 6, len(walk))
+	assert.Equal(t, a.Id(), walk[0].Id())
+	assert.Equal(t, b.Id(), walk[1].Id())
+	for _, n := range walk {
+		assert.NotEqual(t, d.Id(), n.Id())
+	}
+	lonely, _ := db.CreateNode(Props{})
+	walk, _ = db.RandomWalk(lonely, 5)
+	assert.Equal(t, 1, len(walk))
+}
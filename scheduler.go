@@ -0,0 +1,161 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sync"
+)
+
+// A Priority is the scheduling class of a request.  Lower values are served
+// first.  A Scheduler has one class per queue size given to NewScheduler, so
+// applications needing more than the two predefined classes may use
+// Priority(2) and upwards.
+type Priority int
+
+const (
+	Interactive Priority = iota // Default for reads
+	Bulk                        // Default for writes
+)
+
+// A Scheduler limits the number of requests a Database has in flight at once,
+// queueing the remainder by Priority.  Whenever a worker becomes free it
+// serves the oldest request of the most urgent non-empty class, so by default
+// interactive reads are not starved by background imports sharing the same
+// Database.
+type Scheduler struct {
+	mu      sync.Mutex
+	work    *sync.Cond // Signalled when a request is queued
+	space   *sync.Cond // Signalled when a request is dequeued
+	queues  [][]*scheduledRequest
+	sizes   []int
+	reject  bool
+	closed  bool
+	stopped sync.WaitGroup
+}
+
+type scheduledRequest struct {
+	fn   func()
+	done chan bool
+}
+
+// NewScheduler returns a Scheduler running at most workers requests
+// concurrently.  It has one priority class per element of queueSizes, each
+// queueing up to that many requests; sizes below 1 are treated as 1.  If no
+// sizes are given, Interactive and Bulk queues of 100 are used.  When a queue
+// is full, further requests of that class block until there is room - or, if
+// reject is true, immediately fail with QueueFull.
+func NewScheduler(workers int, reject bool, queueSizes ...int) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	if len(queueSizes) == 0 {
+		queueSizes = []int{100, 100}
+	}
+	s := &Scheduler{
+		queues: make([][]*scheduledRequest, len(queueSizes)),
+		sizes:  make([]int, len(queueSizes)),
+		reject: reject,
+	}
+	s.work = sync.NewCond(&s.mu)
+	s.space = sync.NewCond(&s.mu)
+	for i, size := range queueSizes {
+		if size < 1 {
+			size = 1
+		}
+		s.sizes[i] = size
+	}
+	s.stopped.Add(workers)
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+// Close stops the scheduler.  Requests already queued are still executed,
+// but new requests fail with SchedulerClosed.  Close returns once every
+// worker has exited.
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.work.Broadcast()
+	s.space.Broadcast()
+	s.mu.Unlock()
+	s.stopped.Wait()
+}
+
+// run queues fn with priority p and waits until it has been executed.
+func (s *Scheduler) run(p Priority, fn func()) error {
+	sr, err := s.enqueue(p, fn)
+	if err != nil {
+		return err
+	}
+	<-sr.done
+	return nil
+}
+
+// enqueue adds fn to the queue for priority p, without waiting for it to run.
+// Priorities outside the scheduler's range are clamped to the nearest class.
+func (s *Scheduler) enqueue(p Priority, fn func()) (*scheduledRequest, error) {
+	i := int(p)
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(s.queues) {
+		i = len(s.queues) - 1
+	}
+	sr := &scheduledRequest{fn: fn, done: make(chan bool)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.closed && len(s.queues[i]) >= s.sizes[i] {
+		if s.reject {
+			return nil, QueueFull
+		}
+		s.space.Wait()
+	}
+	if s.closed {
+		return nil, SchedulerClosed
+	}
+	s.queues[i] = append(s.queues[i], sr)
+	s.work.Signal()
+	return sr, nil
+}
+
+// next blocks until a request is queued, and returns the most urgent one.  It
+// returns nil when the scheduler is closed and all queues are empty.
+func (s *Scheduler) next() *scheduledRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for i, q := range s.queues {
+			if len(q) > 0 {
+				sr := q[0]
+				s.queues[i] = q[1:]
+				s.space.Broadcast()
+				return sr
+			}
+		}
+		if s.closed {
+			return nil
+		}
+		s.work.Wait()
+	}
+}
+
+func (s *Scheduler) worker() {
+	defer s.stopped.Done()
+	for sr := s.next(); sr != nil; sr = s.next() {
+		sr.fn()
+		close(sr.done)
+	}
+}
+
+// WithPriority returns a copy of db whose requests are all scheduled with
+// priority p, rather than by whether they read or write.  It has no effect
+// unless db has a Scheduler.
+func (db *Database) WithPriority(p Priority) *Database {
+	c := *db
+	c.priority = &p
+	return &c
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"sync"
+	"testing"
+)
+
+// occupy blocks the scheduler's only worker until the returned channel is
+// closed.
+func occupy(t *testing.T, s *Scheduler) chan bool {
+	block := make(chan bool)
+	started := make(chan bool)
+	_, err := s.enqueue(Bulk, func() {
+		close(started)
+		<-block
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	return block
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	s := NewScheduler(1, false, 10, 10)
+	defer s.Close()
+	block := occupy(t, s)
+	var mu sync.Mutex
+	order := []Priority{}
+	var queued []*scheduledRequest
+	for _, p := range []Priority{Bulk, Bulk, Interactive} {
+		p := p
+		sr, err := s.enqueue(p, func() {
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		queued = append(queued, sr)
+	}
+	close(block)
+	for _, sr := range queued {
+		<-sr.done
+	}
+	assert.Equal(t, []Priority{Interactive, Bulk, Bulk}, order)
+}
+
+func TestSchedulerReject(t *testing.T) {
+	s := NewScheduler(1, true, 1, 1)
+	defer s.Close()
+	block := occupy(t, s)
+	_, err := s.enqueue(Bulk, func() {})
+	assert.Equal(t, nil, err)
+	err = s.run(Bulk, func() {})
+	assert.Equal(t, QueueFull, err)
+	// Other classes have their own queues
+	_, err = s.enqueue(Interactive, func() {})
+	assert.Equal(t, nil, err)
+	close(block)
+}
+
+func TestSchedulerClose(t *testing.T) {
+	s := NewScheduler(2, false)
+	ran := false
+	err := s.run(Interactive, func() { ran = true })
+	assert.Equal(t, nil, err)
+	assert.T(t, ran)
+	s.Close()
+	err = s.run(Interactive, func() {})
+	assert.Equal(t, SchedulerClosed, err)
+}
+
+func TestSchedulerDatabase(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	db.Scheduler = NewScheduler(2, false)
+	defer func() {
+		db.Scheduler.Close()
+		db.Scheduler = nil
+	}()
+	bulk := db.WithPriority(Bulk)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := db.CreateNode(Props{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, err = bulk.Node(n.Id())
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
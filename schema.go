@@ -21,14 +21,14 @@ type Index struct {
 
 // Drop removes the index.
 func (idx *Index) Drop() error {
-	url := join(idx.db.Url, "schema/index", idx.Label, idx.PropertyKeys[0])
+	url := join(idx.db.hrefIndexes(), PathEscape(idx.Label), PathEscape(idx.PropertyKeys[0]))
 	ne := NeoError{}
 	rr := restclient.RequestResponse{
 		Url:    url,
 		Method: "DELETE",
 		Error:  &ne,
 	}
-	status, err := idx.db.Rc.Do(&rr)
+	status, err := idx.db.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -44,7 +44,7 @@ func (idx *Index) Drop() error {
 // CreateIndex starts a background job in the database that will create and
 // populate the new index of a specified property on nodes of a given label.
 func (db *Database) CreateIndex(label, property string) (*Index, error) {
-	url := join(db.Url, "schema/index", label)
+	url := join(db.hrefIndexes(), PathEscape(label))
 	payload := indexRequest{[]string{property}}
 	ne := NeoError{}
 	res := Index{db: db}
@@ -55,7 +55,7 @@ func (db *Database) CreateIndex(label, property string) (*Index, error) {
 		Result: &res,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +70,7 @@ func (db *Database) CreateIndex(label, property string) (*Index, error) {
 
 // Indexes lists indexes for a label.
 func (db *Database) Indexes(label string) ([]*Index, error) {
-	url := join(db.Url, "schema/index", label)
+	url := join(db.hrefIndexes(), PathEscape(label))
 	ne := NeoError{}
 	res := []*Index{}
 	rr := restclient.RequestResponse{
@@ -79,7 +79,7 @@ func (db *Database) Indexes(label string) ([]*Index, error) {
 		Result: &res,
 		Error:  &ne,
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return res, err
 	}
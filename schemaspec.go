@@ -0,0 +1,138 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"reflect"
+	"strings"
+)
+
+// A Labeler is a struct type which chooses its own label.  SchemaFor otherwise
+// uses the name of the type.
+type Labeler interface {
+	Label() string
+}
+
+// A SchemaSpec is the schema indexes and uniqueness constraints required by a
+// set of node types.
+type SchemaSpec struct {
+	Indexes     []Index
+	Constraints []Constraint
+}
+
+// SchemaFor derives a SchemaSpec from annotated structs.  Each of vs is a
+// struct or pointer to struct representing the properties of nodes with a
+// label.  A field tagged `neo4j:"index"` requires a schema index on its
+// property, and a field tagged `neo4j:"unique"` a uniqueness constraint,
+// which implies an index.  The property is named by the field's json tag, if
+// any, else by the field's name.
+//
+//	type Person struct {
+//		Email string `json:"email" neo4j:"unique"`
+//		Name  string `json:"name" neo4j:"index"`
+//	}
+func SchemaFor(vs ...interface{}) (*SchemaSpec, error) {
+	spec := &SchemaSpec{Indexes: []Index{}, Constraints: []Constraint{}}
+	for _, v := range vs {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil, InvalidSchemaType
+		}
+		label := t.Name()
+		if l, ok := v.(Labeler); ok {
+			label = l.Label()
+		}
+		if label == "" {
+			return nil, InvalidSchemaType
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			prop := f.Name
+			if name := strings.Split(f.Tag.Get("json"), ",")[0]; name == "-" {
+				continue
+			} else if name != "" {
+				prop = name
+			}
+			switch f.Tag.Get("neo4j") {
+			case "":
+			case "index":
+				spec.Indexes = append(spec.Indexes, Index{Label: label, PropertyKeys: []string{prop}})
+			case "unique":
+				spec.Constraints = append(spec.Constraints, Constraint{Label: label, Type: "UNIQUENESS", PropertyKeys: []string{prop}})
+			default:
+				return nil, InvalidSchemaType
+			}
+		}
+	}
+	return spec, nil
+}
+
+// Cypher returns the Cypher statements creating the schema.
+func (s *SchemaSpec) Cypher() []string {
+	stmts := []string{}
+	for _, c := range s.Constraints {
+		stmts = append(stmts, "CREATE CONSTRAINT ON (n:"+quoteIdent(c.Label)+") ASSERT n."+quoteIdent(c.PropertyKeys[0])+" IS UNIQUE")
+	}
+	for _, idx := range s.Indexes {
+		stmts = append(stmts, "CREATE INDEX ON :"+quoteIdent(idx.Label)+"("+quoteIdent(idx.PropertyKeys[0])+")")
+	}
+	return stmts
+}
+
+// ApplySchema creates those indexes and constraints of spec which the
+// database lacks, returning the number created.  Constraints are created
+// first, as a property which has an index cannot also be given a constraint.
+func (db *Database) ApplySchema(spec *SchemaSpec) (int, error) {
+	created := 0
+	for _, c := range spec.Constraints {
+		have, err := db.Constraints(c.Label)
+		if err != nil && err != NotFound {
+			return created, err
+		}
+		if hasProperty(have, c.PropertyKeys[0]) {
+			continue
+		}
+		_, err = db.CreateUniqueConstraint(c.Label, c.PropertyKeys[0])
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+	for _, idx := range spec.Indexes {
+		have, err := db.Indexes(idx.Label)
+		if err != nil && err != NotFound {
+			return created, err
+		}
+		found := false
+		for _, h := range have {
+			found = found || len(h.PropertyKeys) == 1 && h.PropertyKeys[0] == idx.PropertyKeys[0]
+		}
+		if found {
+			continue
+		}
+		_, err = db.CreateIndex(idx.Label, idx.PropertyKeys[0])
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// hasProperty reports whether one of cs constrains property alone.
+func hasProperty(cs []*Constraint, property string) bool {
+	for _, c := range cs {
+		if len(c.PropertyKeys) == 1 && c.PropertyKeys[0] == property {
+			return true
+		}
+	}
+	return false
+}
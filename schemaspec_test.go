@@ -0,0 +1,79 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+type specPerson struct {
+	Email   string `json:"email" neo4j:"unique"`
+	Name    string `json:"name,omitempty" neo4j:"index"`
+	Age     int    `neo4j:"index"`
+	Skipped string `json:"-" neo4j:"index"`
+	secret  string
+}
+
+type specPlace struct {
+	Code string `neo4j:"unique"`
+}
+
+func (specPlace) Label() string { return "Place" }
+
+func TestSchemaFor(t *testing.T) {
+	spec, err := SchemaFor(&specPerson{}, specPlace{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Index{
+		{Label: "specPerson", PropertyKeys: []string{"name"}},
+		{Label: "specPerson", PropertyKeys: []string{"Age"}},
+	}, spec.Indexes)
+	assert.Equal(t, []Constraint{
+		{Label: "specPerson", Type: "UNIQUENESS", PropertyKeys: []string{"email"}},
+		{Label: "Place", Type: "UNIQUENESS", PropertyKeys: []string{"Code"}},
+	}, spec.Constraints)
+	assert.Equal(t, []string{
+		"CREATE CONSTRAINT ON (n:`specPerson`) ASSERT n.`email` IS UNIQUE",
+		"CREATE CONSTRAINT ON (n:`Place`) ASSERT n.`Code` IS UNIQUE",
+		"CREATE INDEX ON :`specPerson`(`name`)",
+		"CREATE INDEX ON :`specPerson`(`Age`)",
+	}, spec.Cypher())
+	_, err = SchemaFor("Person")
+	assert.Equal(t, InvalidSchemaType, err)
+	_, err = SchemaFor(struct {
+		X string `neo4j:"primary"`
+	}{})
+	assert.Equal(t, InvalidSchemaType, err)
+}
+
+func TestApplySchema(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	spec := &SchemaSpec{
+		Indexes:     []Index{{Label: label, PropertyKeys: []string{"name"}}},
+		Constraints: []Constraint{{Label: label, Type: "UNIQUENESS", PropertyKeys: []string{"email"}}},
+	}
+	n, err := db.ApplySchema(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	n, err = db.ApplySchema(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, n)
+	cs, _ := db.Constraints(label)
+	for _, c := range cs {
+		c.Drop()
+	}
+	is, _ := db.Indexes(label)
+	for _, idx := range is {
+		idx.Drop()
+	}
+}
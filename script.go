@@ -0,0 +1,158 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// A ScriptStatement is one statement of a Cypher script.
+type ScriptStatement struct {
+	Line      int // Line of the script on which the statement starts
+	Statement string
+}
+
+// ParseScript splits a Cypher script into statements separated by
+// semicolons.  Comments - from // to the end of a line, or between /* and
+// */ - are removed, as are empty statements.  Semicolons and comment markers
+// within string literals and backquoted identifiers are left alone.
+func ParseScript(r io.Reader) ([]ScriptStatement, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	src := string(b)
+	stmts := []ScriptStatement{}
+	cur := []byte{}
+	line, start := 1, 0
+	flush := func() {
+		s := strings.TrimSpace(string(cur))
+		if s != "" {
+			stmts = append(stmts, ScriptStatement{Line: start, Statement: s})
+		}
+		cur = cur[:0]
+		start = 0
+	}
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i-- // The newline is kept
+			continue
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment on line %d", line)
+			}
+			comment := src[i : i+2+end+2]
+			line += strings.Count(comment, "\n")
+			cur = append(cur, ' ')
+			i += len(comment) - 1
+			continue
+		case c == ';':
+			flush()
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			if start == 0 {
+				start = line
+			}
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && c != '`' {
+					j++
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated quote on line %d", line)
+			}
+			lit := src[i : j+1]
+			line += strings.Count(lit, "\n")
+			cur = append(cur, lit...)
+			i = j
+			continue
+		case c == '\n':
+			line++
+		case start == 0 && c != ' ' && c != '\t' && c != '\r':
+			start = line
+		}
+		cur = append(cur, c)
+	}
+	flush()
+	return stmts, nil
+}
+
+// A ScriptError reports the statement of a script which failed.
+type ScriptError struct {
+	ScriptStatement
+	Err     error
+	Message string // The server's description of the error, if any
+}
+
+func (e *ScriptError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Err.Error()
+	}
+	return fmt.Sprintf("statement on line %d failed: %s: %s", e.Line, msg, e.Statement)
+}
+
+// RunScript executes the statements of a Cypher script, as parsed by
+// ParseScript, in order within a single transaction, which is committed only
+// if every statement succeeds.  On failure it returns a *ScriptError naming
+// the statement responsible.  Schema changes cannot share a transaction with
+// data changes; use RunScriptEach for scripts which mix them.
+func (db *Database) RunScript(r io.Reader) error {
+	stmts, err := ParseScript(r)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin([]*CypherQuery{})
+	if err != nil {
+		return err
+	}
+	for _, s := range stmts {
+		err := tx.Query([]*CypherQuery{&CypherQuery{Statement: s.Statement}})
+		if err != nil {
+			se := &ScriptError{ScriptStatement: s, Err: err}
+			if len(tx.Errors) > 0 {
+				se.Message = tx.Errors[len(tx.Errors)-1].Message
+			}
+			tx.Rollback()
+			return se
+		}
+	}
+	return tx.Commit()
+}
+
+// RunScriptEach executes the statements of a Cypher script, as parsed by
+// ParseScript, in order, each in a transaction of its own.  It stops at the
+// first failure, returning a *ScriptError, and the number of statements
+// which succeeded.
+func (db *Database) RunScriptEach(r io.Reader) (int, error) {
+	stmts, err := ParseScript(r)
+	if err != nil {
+		return 0, err
+	}
+	for i, s := range stmts {
+		tx, err := db.Begin([]*CypherQuery{&CypherQuery{Statement: s.Statement}})
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			se := &ScriptError{ScriptStatement: s, Err: err}
+			if tx != nil && len(tx.Errors) > 0 {
+				se.Message = tx.Errors[len(tx.Errors)-1].Message
+			}
+			return i, se
+		}
+	}
+	return len(stmts), nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"strings"
+	"testing"
+)
+
+const testScript = `// Seed the crew
+CREATE (n:Crew {name: 'kirk; captain'});
+
+/* Spock is
+   half human */ CREATE (n:Crew {name: "spock // science"});
+;
+CREATE (n:` + "`Odd;Label`" + ` {url: 'http://x/*y*/'}) // trailing
+RETURN n`
+
+func TestParseScript(t *testing.T) {
+	stmts, err := ParseScript(strings.NewReader(testScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []ScriptStatement{
+		{Line: 2, Statement: "CREATE (n:Crew {name: 'kirk; captain'})"},
+		{Line: 5, Statement: `CREATE (n:Crew {name: "spock // science"})`},
+		{Line: 7, Statement: "CREATE (n:`Odd;Label` {url: 'http://x/*y*/'}) \nRETURN n"},
+	}, stmts)
+	_, err = ParseScript(strings.NewReader("CREATE (n {name: 'kirk})"))
+	assert.NotEqual(t, nil, err)
+	_, err = ParseScript(strings.NewReader("/* open"))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestScriptError(t *testing.T) {
+	se := &ScriptError{
+		ScriptStatement: ScriptStatement{Line: 3, Statement: "CREAT (n)"},
+		Err:             TxQueryError,
+		Message:         "Invalid input",
+	}
+	assert.Equal(t, "statement on line 3 failed: Invalid input: CREAT (n)", se.Error())
+}
+
+func TestRunScript(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	err := db.RunScript(strings.NewReader("CREATE (:" + label + " {n: 1});\n// two\nCREATE (:" + label + " {n: 2});"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, _ := db.NodesByLabel(label)
+	assert.Equal(t, 2, len(nodes))
+	err = db.RunScript(strings.NewReader("CREATE (:" + label + " {n: 3});\n\nCREAT (n);"))
+	se, ok := err.(*ScriptError)
+	assert.Tf(t, ok, "Expected a ScriptError, got %v", err)
+	assert.Equal(t, 3, se.Line)
+	nodes, _ = db.NodesByLabel(label)
+	assert.Equal(t, 2, len(nodes))
+	n, err := db.RunScriptEach(strings.NewReader("CREATE (:" + label + " {n: 3});\nCREAT (n);"))
+	assert.Equal(t, 1, n)
+	se, ok = err.(*ScriptError)
+	assert.Tf(t, ok, "Expected a ScriptError, got %v", err)
+	assert.Equal(t, 2, se.Line)
+	nodes, _ = db.NodesByLabel(label)
+	assert.Equal(t, 3, len(nodes))
+}
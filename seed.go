@@ -0,0 +1,125 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// A GraphBuilder describes a graph - typically seed or fixture data - to be
+// created in a database by Commit:
+//
+//	g := neo4j.Build()
+//	alice := g.Node("Person", neo4j.Props{"name": "Alice"})
+//	bob := g.Node("Person", neo4j.Props{"name": "Bob"})
+//	g.Rel(alice, bob, "KNOWS")
+//	err := g.Commit(db)
+//	id := alice.Node().Id()
+type GraphBuilder struct {
+	nodes []*SeedNode
+	rels  []*SeedRel
+}
+
+// A SeedNode is a node described by a GraphBuilder.
+type SeedNode struct {
+	Labels []string
+	Props  Props
+	node   *Node
+}
+
+// Node returns the node created by the most recent Commit, or nil if the
+// builder has not been committed.
+func (n *SeedNode) Node() *Node {
+	return n.node
+}
+
+// Label adds labels to the node, returning it.
+func (n *SeedNode) Label(labels ...string) *SeedNode {
+	n.Labels = append(n.Labels, labels...)
+	return n
+}
+
+// A SeedRel is a relationship described by a GraphBuilder.
+type SeedRel struct {
+	Start *SeedNode
+	End   *SeedNode
+	Type  string
+	Props Props
+	rel   *Relationship
+}
+
+// Relationship returns the relationship created by the most recent Commit,
+// or nil if the builder has not been committed.
+func (r *SeedRel) Relationship() *Relationship {
+	return r.rel
+}
+
+// Build returns an empty GraphBuilder.
+func Build() *GraphBuilder {
+	return &GraphBuilder{}
+}
+
+// Node describes a node with label, unless it is empty, and properties p.
+func (g *GraphBuilder) Node(label string, p Props) *SeedNode {
+	n := &SeedNode{Labels: []string{}, Props: p}
+	if label != "" {
+		n.Labels = append(n.Labels, label)
+	}
+	g.nodes = append(g.nodes, n)
+	return n
+}
+
+// Rel describes a relationship of type relType from start to end, which must
+// have been described by the same builder.  Any properties given are merged.
+func (g *GraphBuilder) Rel(start, end *SeedNode, relType string, props ...Props) *SeedRel {
+	var p Props
+	for _, ps := range props {
+		if p == nil {
+			p = Props{}
+		}
+		for k, v := range ps {
+			p[k] = v
+		}
+	}
+	r := &SeedRel{Start: start, End: end, Type: relType, Props: p}
+	g.rels = append(g.rels, r)
+	return r
+}
+
+// Commit creates the described graph in db in a single batch request, so
+// that either all of it is created or none is, then populates the handles
+// returned by Node and Rel.  A builder may be committed more than once,
+// creating a new copy of the graph each time.
+func (g *GraphBuilder) Commit(db *Database) error {
+	b := db.NewBatch()
+	nodes := make(map[*SeedNode]*BatchNode, len(g.nodes))
+	for _, n := range g.nodes {
+		p := n.Props
+		if p == nil {
+			p = Props{}
+		}
+		bn := b.CreateNode(p)
+		if len(n.Labels) > 0 {
+			b.AddLabel(bn, n.Labels...)
+		}
+		nodes[n] = bn
+	}
+	rels := make([]*BatchRelationship, len(g.rels))
+	for i, r := range g.rels {
+		start, ok := nodes[r.Start]
+		end, ok2 := nodes[r.End]
+		if !ok || !ok2 {
+			return UnknownSeedNode
+		}
+		rels[i] = b.CreateRelationship(start, end, r.Type, r.Props)
+	}
+	err := b.Execute()
+	if err != nil {
+		return err
+	}
+	for _, n := range g.nodes {
+		n.node = nodes[n].Node()
+	}
+	for i, r := range g.rels {
+		r.rel = rels[i].Relationship()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestGraphBuilder(t *testing.T) {
+	g := Build()
+	alice := g.Node("Person", Props{"name": "Alice"}).Label("Admin")
+	bob := g.Node("", nil)
+	r := g.Rel(alice, bob, "KNOWS", Props{"since": 2001}, Props{"close": true})
+	assert.Equal(t, []string{"Person", "Admin"}, alice.Labels)
+	assert.Equal(t, []string{}, bob.Labels)
+	assert.Equal(t, Props{"since": 2001, "close": true}, r.Props)
+	assert.Equal(t, (Props)(nil), g.Rel(bob, alice, "KNOWS").Props)
+	assert.Equal(t, (*Node)(nil), alice.Node())
+	other := Build().Node("Person", nil)
+	g.Rel(alice, other, "KNOWS")
+	assert.Equal(t, UnknownSeedNode, g.Commit(&Database{}))
+}
+
+func TestGraphBuilderCommit(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	g := Build()
+	alice := g.Node(label, Props{"name": "Alice"})
+	bob := g.Node(label, Props{"name": "Bob"})
+	knows := g.Rel(alice, bob, "KNOWS")
+	err := g.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, alice.Node().Id(), knows.Relationship().StartId())
+	nodes, _ := db.NodesByLabel(label)
+	assert.Equal(t, 2, len(nodes))
+	props, _ := bob.Node().Properties()
+	assert.Equal(t, Props{"name": "Bob"}, props)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sync"
+)
+
+// SequenceLabel is the label of the counter nodes behind NextSequence.
+const SequenceLabel = "Sequence"
+
+// ensured records the uniqueness constraints known to exist, keyed by
+// database, label and property.
+var ensured = struct {
+	sync.Mutex
+	m map[string]bool
+}{m: map[string]bool{}}
+
+// ensureUnique creates a uniqueness constraint on property of nodes with
+// label, unless this process has already done so.
+func (db *Database) ensureUnique(label, property string) error {
+	key := db.HrefCypher + " " + quoteIdent(label) + "." + quoteIdent(property)
+	ensured.Lock()
+	defer ensured.Unlock()
+	if ensured.m[key] {
+		return nil
+	}
+	cq := CypherQuery{
+		Statement: "CREATE CONSTRAINT ON (n:" + quoteIdent(label) + ") ASSERT n." + quoteIdent(property) + " IS UNIQUE",
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return err
+	}
+	ensured.m[key] = true
+	return nil
+}
+
+// NextSequence atomically increments the sequence called name and returns
+// its new value.  A new sequence starts at 1.  Values are unique and
+// increasing, but may have gaps where the transaction taking a value failed
+// to commit.  Each sequence is a node with label SequenceLabel, unique by
+// name, whose value property holds the last value taken.
+func (db *Database) NextSequence(name string) (int64, error) {
+	err := db.ensureUnique(SequenceLabel, "name")
+	if err != nil {
+		return 0, err
+	}
+	// Taking the node's write lock before its value is read means concurrent
+	// increments cannot be lost.
+	res := []struct {
+		Value int64 `json:"value"`
+	}{}
+	cq := CypherQuery{
+		Statement: "MERGE (s:" + quoteIdent(SequenceLabel) + " {name: {name}}) " + writeLock("s") +
+			"SET s.value = coalesce(s.value, 0) + 1 RETURN s.value AS value",
+		Parameters: Props{"name": name},
+		Result:     &res,
+	}
+	err = db.Cypher(&cq)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, NotFound
+	}
+	return res[0].Value, nil
+}
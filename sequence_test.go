@@ -0,0 +1,45 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestNextSequence(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	name := rndStr(t)
+	v, err := db.NextSequence(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), v)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	values := []int{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := db.NextSequence(name)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			values = append(values, int(v))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Ints(values)
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, values)
+	v, _ = db.NextSequence(rndStr(t))
+	assert.Equal(t, int64(1), v)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// Neighbourhood similarity ignores relationship direction.  An empty relType
+// matches relationships of any type.
+
+// CommonNeighbors returns the nodes related by relType to both a and b.
+func (db *Database) CommonNeighbors(a, b *Node, relType string) ([]*Node, error) {
+	rel := relPattern(relType)
+	stmt := "START a=node({a}), b=node({b}) MATCH (a)-" + rel + "-(x)-" + rel + "-(b) RETURN DISTINCT x"
+	return db.cypherNodes(stmt, Props{"a": a.Id(), "b": b.Id()})
+}
+
+// Jaccard returns the Jaccard similarity of the neighbourhoods of a and b
+// through relationships of relType: the number of their common neighbours
+// divided by the number of nodes neighbouring either.  It is 0 if neither
+// has any neighbours.
+func (db *Database) Jaccard(a, b *Node, relType string) (float64, error) {
+	rel := relPattern(relType)
+	res := []struct {
+		Common int `json:"common"`
+		Total  int `json:"total"`
+	}{}
+	cq := CypherQuery{
+		Statement: `
+			START a=node({a}), b=node({b})
+			OPTIONAL MATCH (a)-` + rel + `-(x)
+			WITH b, collect(DISTINCT id(x)) AS na
+			OPTIONAL MATCH (b)-` + rel + `-(y)
+			WITH na, collect(DISTINCT id(y)) AS nb
+			RETURN size(filter(x IN na WHERE x IN nb)) AS common, size(na) + size(nb) AS total
+		`,
+		Parameters: Props{"a": a.Id(), "b": b.Id()},
+		Result:     &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, NotFound
+	}
+	return jaccard(res[0].Common, res[0].Total), nil
+}
+
+// jaccard returns the Jaccard index of two sets with common elements in
+// common, and total elements counting those in both twice.
+func jaccard(common, total int) float64 {
+	union := total - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
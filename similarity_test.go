@@ -0,0 +1,47 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestJaccardIndex(t *testing.T) {
+	assert.Equal(t, 0.0, jaccard(0, 0))
+	assert.Equal(t, 0.5, jaccard(2, 6))
+	assert.Equal(t, 1.0, jaccard(3, 6))
+}
+
+func TestSimilarity(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	alice, _ := db.CreateNode(Props{"name": "alice"})
+	bob, _ := db.CreateNode(Props{"name": "bob"})
+	items := make([]*Node, 4)
+	for i := range items {
+		items[i], _ = db.CreateNode(Props{"item": i})
+	}
+	// alice likes 0, 1, 2; bob likes 1, 2, 3 and is followed by alice.
+	for _, i := range []int{0, 1, 2} {
+		alice.Relate("likes", items[i].Id(), nil)
+	}
+	for _, i := range []int{1, 2, 3} {
+		bob.Relate("likes", items[i].Id(), nil)
+	}
+	alice.Relate("follows", bob.Id(), nil)
+	common, err := db.CommonNeighbors(alice, bob, "likes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(common))
+	j, err := db.Jaccard(alice, bob, "likes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0.5, j)
+	j, _ = db.Jaccard(alice, bob, "follows")
+	assert.Equal(t, 0.0, j)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sync"
+)
+
+// A Snapshot runs a series of reads in one long-lived transaction.  Neo4j
+// transactions are read committed, and reads take no locks, so a Snapshot is
+// not isolated from concurrent changes: changes committed by others while it
+// is open, deletions included, may become visible to later reads.  The server
+// abandons the transaction after a period of inactivity - 60 seconds by
+// default - unless KeepAlive is called.  A Snapshot is safe for concurrent
+// use, but its requests are made one at a time.
+type Snapshot struct {
+	db *Database
+	mu sync.Mutex
+	tx *Tx
+}
+
+// Snapshot opens a Snapshot.  It must be closed with Close.
+func (db *Database) Snapshot() (*Snapshot, error) {
+	tx, err := db.Begin([]*CypherQuery{})
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{db: db, tx: tx}, nil
+}
+
+// CypherBatch executes qs in the snapshot's transaction.  Statements should
+// only read: the transaction is rolled back on Close.
+func (s *Snapshot) CypherBatch(qs []*CypherQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tx.Query(qs)
+}
+
+// Cypher executes q in the snapshot's transaction.
+func (s *Snapshot) Cypher(q *CypherQuery) error {
+	return s.CypherBatch([]*CypherQuery{q})
+}
+
+// nodes executes a statement returning a single column of nodes.
+func (s *Snapshot) nodes(stmt string, params Props) ([]*Node, error) {
+	cq := CypherQuery{
+		Statement:          stmt,
+		Parameters:         params,
+		ResultDataContents: []string{RESTFormat},
+	}
+	err := s.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cq.Rows()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*Node, 0, len(rows))
+	for _, row := range rows {
+		if n, ok := row[0].(*Node); ok {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+// Node fetches the node with the given ID as seen by the snapshot.
+func (s *Snapshot) Node(id int) (*Node, error) {
+	nodes, err := s.nodes("MATCH (n) WHERE id(n) = {id} RETURN n", Props{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, NotFound
+	}
+	return nodes[0], nil
+}
+
+// NodesByLabel returns the nodes with label as seen by the snapshot.
+func (s *Snapshot) NodesByLabel(label string) ([]*Node, error) {
+	return s.nodes("MATCH (n:"+quoteIdent(label)+") RETURN n", nil)
+}
+
+// KeepAlive resets the server's inactivity timeout for the snapshot's
+// transaction.
+func (s *Snapshot) KeepAlive() error {
+	return s.CypherBatch([]*CypherQuery{})
+}
+
+// Close ends the snapshot, rolling back its transaction.
+func (s *Snapshot) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tx.Rollback()
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{"name": "a"})
+	n0.AddLabel("Report")
+	s, err := db.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.Node(n0.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a", n.Data["name"])
+	assert.Equal(t, n0.Id(), n.Id())
+	nodes, err := s.NodesByLabel("Report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(nodes))
+	_, err = s.Node(n0.Id() + 1000)
+	assert.Equal(t, NotFound, err)
+	err = s.KeepAlive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
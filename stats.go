@@ -0,0 +1,151 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An EndpointStats summarizes the requests made to a single endpoint.
+type EndpointStats struct {
+	Calls     int           // Number of requests made
+	Errors    int           // Requests failing in transport or with status >= 400
+	TotalTime time.Duration // Cumulative latency
+	MaxTime   time.Duration // Slowest single request
+	LastError string        // Request ID of the most recent error, if any
+}
+
+// MeanTime returns the average latency of requests to the endpoint.
+func (es EndpointStats) MeanTime() time.Duration {
+	if es.Calls == 0 {
+		return 0
+	}
+	return es.TotalTime / time.Duration(es.Calls)
+}
+
+// A statsRegistry accumulates EndpointStats.  It is safe for concurrent use.
+type statsRegistry struct {
+	sync.Mutex
+	endpoints map[string]*EndpointStats
+}
+
+func newStatsRegistry() *statsRegistry {
+	return &statsRegistry{endpoints: make(map[string]*EndpointStats)}
+}
+
+// maxEndpoints caps the number of distinct endpoints a statsRegistry tracks.
+// Requests to further endpoints are counted together under otherEndpoint.
+const maxEndpoints = 1000
+
+const otherEndpoint = "(other)"
+
+// placeholders maps a path segment to names for the user-supplied segments
+// which follow it in the REST API's URL scheme.
+var placeholders = map[string][]string{
+	"properties":    {"{key}"},
+	"labels":        {"{label}"},
+	"label":         {"{label}"},
+	"relationships": {"", "{types}"}, // Direction is kept
+	"node":          {"{name}", "{key}", "{value}"},
+	"relationship":  {"{name}", "{key}", "{value}"},
+	"constraint":    {"{label}", "", "{property}"},
+	"schema/index":  {"{label}", "{property}"},
+}
+
+func (sr *statsRegistry) record(method, rawurl string, elapsed time.Duration, failed bool, id string) {
+	key := method + " " + endpoint(rawurl)
+	sr.Lock()
+	defer sr.Unlock()
+	es, ok := sr.endpoints[key]
+	if !ok && len(sr.endpoints) >= maxEndpoints {
+		key = method + " " + otherEndpoint
+		es, ok = sr.endpoints[key]
+	}
+	if !ok {
+		es = new(EndpointStats)
+		sr.endpoints[key] = es
+	}
+	es.Calls++
+	if failed {
+		es.Errors++
+		es.LastError = id
+	}
+	es.TotalTime += elapsed
+	if elapsed > es.MaxTime {
+		es.MaxTime = elapsed
+	}
+}
+
+// endpoint reduces a request URL to the path of the endpoint it addresses,
+// replacing numeric path segments such as node IDs with "{id}", and
+// user-supplied segments such as property keys, labels and legacy index
+// names, keys and values with placeholders, so that e.g. all node fetches are
+// counted together.
+func endpoint(rawurl string) string {
+	path := rawurl
+	if u, err := url.Parse(rawurl); err == nil {
+		path = u.Path
+	}
+	parts := strings.Split(path, "/")
+	for i := 0; i < len(parts); i++ {
+		p := parts[i]
+		if p != "" && strings.Trim(p, "0123456789") == "" {
+			parts[i] = "{id}"
+			continue
+		}
+		names := placeholders[p]
+		switch {
+		case p == "index" && i > 0 && parts[i-1] == "schema":
+			names = placeholders["schema/index"]
+		case p == "index":
+			// Legacy index: the entity type follows, then the user's segments.
+			i++
+			if i < len(parts) {
+				names = placeholders[parts[i]]
+			}
+		case p == "node" || p == "relationship":
+			// Entity collection, e.g. /node/{id}
+			names = nil
+		}
+		for _, name := range names {
+			i++
+			if i >= len(parts) {
+				break
+			}
+			if name != "" && parts[i] != "" {
+				parts[i] = name
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Statistics returns a snapshot of request statistics for this Database,
+// keyed by HTTP method and endpoint path - e.g. "GET /db/data/node/{id}".
+func (db *Database) Statistics() map[string]EndpointStats {
+	m := make(map[string]EndpointStats)
+	if db.stats == nil {
+		return m
+	}
+	db.stats.Lock()
+	defer db.stats.Unlock()
+	for key, es := range db.stats.endpoints {
+		m[key] = *es
+	}
+	return m
+}
+
+// ResetStatistics discards all request statistics gathered so far.
+func (db *Database) ResetStatistics() {
+	if db.stats == nil {
+		return
+	}
+	db.stats.Lock()
+	defer db.stats.Unlock()
+	db.stats.endpoints = make(map[string]*EndpointStats)
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"strconv"
+	"testing"
+)
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "/db/data/node/{id}/properties", endpoint("http://localhost:7474/db/data/node/42/properties"))
+	assert.Equal(t, "/db/data/cypher", endpoint("http://localhost:7474/db/data/cypher"))
+	assert.Equal(t, "/db/data/node/{id}/properties/{key}", endpoint("http://localhost:7474/db/data/node/42/properties/name"))
+	assert.Equal(t, "/db/data/node/{id}/labels/{label}", endpoint("http://localhost:7474/db/data/node/42/labels/Person"))
+	assert.Equal(t, "/db/data/node/{id}/relationships/out/{types}", endpoint("http://localhost:7474/db/data/node/42/relationships/out/KNOWS&LIKES"))
+	assert.Equal(t, "/db/data/label/{label}/nodes", endpoint("http://localhost:7474/db/data/label/Person/nodes"))
+	assert.Equal(t, "/db/data/index/node/{name}/{key}/{value}", endpoint("http://localhost:7474/db/data/index/node/people/name/kirk"))
+	assert.Equal(t, "/db/data/index/node/{name}/{key}/{value}/{id}", endpoint("http://localhost:7474/db/data/index/node/people/name/kirk/42"))
+	assert.Equal(t, "/db/data/schema/index/{label}/{property}", endpoint("http://localhost:7474/db/data/schema/index/Person/name"))
+	assert.Equal(t, "/db/data/schema/constraint/{label}/uniqueness/{property}", endpoint("http://localhost:7474/db/data/schema/constraint/Person/uniqueness/name"))
+	assert.Equal(t, "/db/data/transaction/{id}/commit", endpoint("http://localhost:7474/db/data/transaction/7/commit"))
+}
+
+func TestStatisticsCap(t *testing.T) {
+	sr := newStatsRegistry()
+	for i := 0; i < maxEndpoints+10; i++ {
+		sr.record("GET", "http://localhost:7474/db/data/x"+strconv.Itoa(i), 0, false, "")
+	}
+	assert.Equal(t, maxEndpoints+1, len(sr.endpoints))
+	assert.Equal(t, 10, sr.endpoints["GET "+otherEndpoint].Calls)
+}
+
+func TestStatistics(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	db.ResetStatistics()
+	n0, err := db.CreateNode(Props{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Node(n0.Id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Node(n0.Id() + 1000)
+	assert.Equal(t, NotFound, err)
+	stats := db.Statistics()
+	create := stats["POST /db/data/node"]
+	assert.Equal(t, 1, create.Calls)
+	assert.Equal(t, 0, create.Errors)
+	get := stats["GET /db/data/node/{id}"]
+	assert.Equal(t, 2, get.Calls)
+	assert.Equal(t, 1, get.Errors)
+	assert.T(t, get.MaxTime >= get.MeanTime())
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"time"
+)
+
+// StreamIdleCommit is how long a CypherStream leaves a transaction open
+// without executing a chunk in it before committing it, so that the server
+// does not expire it between sparse chunks.  Rows received but not yet
+// executed do not count, since the server sees nothing of them.  It should be
+// well below the server's transaction timeout
+// (org.neo4j.server.transaction.timeout, 60s by default).
+var StreamIdleCommit = 10 * time.Second
+
+// A CypherStream executes a Cypher statement over rows of parameters received
+// on a channel, for continuous ingestion of events into the graph.  Rows are
+// gathered into chunks, and each chunk is executed as a single statement with
+// the chunk supplied as the parameter {rows} - so the statement should begin
+// with something like "UNWIND {rows} AS row".  Chunks are executed inside
+// rolling transactions, which are committed every chunksPerTx chunks, or
+// after StreamIdleCommit passes without a chunk being executed.
+//
+// A CypherStream applies backpressure: while a chunk is being executed, at
+// most one further chunk of rows is buffered, after which sends on the input
+// channel block.
+type CypherStream struct {
+	db          *Database
+	statement   string
+	chunkSize   int
+	chunksPerTx int
+	interval    time.Duration
+	in          chan Props
+	done        chan bool
+	err         error
+}
+
+// NewCypherStream starts a CypherStream executing statement.  A chunk is
+// executed once chunkSize rows have been received.  If flushInterval is
+// non-zero, any partial chunk is also executed, and the open transaction
+// committed, at that interval - bounding the delay before rows become visible.
+func (db *Database) NewCypherStream(statement string, chunkSize, chunksPerTx int, flushInterval time.Duration) *CypherStream {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	if chunksPerTx < 1 {
+		chunksPerTx = 1
+	}
+	s := &CypherStream{
+		db:          db,
+		statement:   statement,
+		chunkSize:   chunkSize,
+		chunksPerTx: chunksPerTx,
+		interval:    flushInterval,
+		in:          make(chan Props, chunkSize),
+		done:        make(chan bool),
+	}
+	go s.run()
+	return s
+}
+
+// Input returns the channel on which rows are sent to the stream.  It must
+// not be sent to after Close is called.
+func (s *CypherStream) Input() chan<- Props {
+	return s.in
+}
+
+// Close flushes any buffered rows, commits the open transaction, and stops
+// the stream.  It returns the first error encountered by the stream, if any.
+// After an error, the failed transaction is rolled back and further rows are
+// discarded until the stream is closed.
+func (s *CypherStream) Close() error {
+	close(s.in)
+	<-s.done
+	return s.err
+}
+
+func (s *CypherStream) run() {
+	defer close(s.done)
+	var tx *Tx
+	chunks := 0
+	rows := make([]Props, 0, s.chunkSize)
+	var tick, idle <-chan time.Time
+	if s.interval > 0 {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	commit := func() {
+		if tx != nil && s.err == nil {
+			s.err = tx.Commit()
+		}
+		tx = nil
+		chunks = 0
+		idle = nil
+	}
+	flush := func() {
+		if len(rows) == 0 || s.err != nil {
+			rows = rows[:0]
+			return
+		}
+		qs := []*CypherQuery{&CypherQuery{
+			Statement:  s.statement,
+			Parameters: Props{"rows": rows},
+		}}
+		if tx == nil {
+			tx, s.err = s.db.Begin(qs)
+		} else {
+			s.err = tx.Query(qs)
+		}
+		rows = make([]Props, 0, s.chunkSize)
+		if s.err != nil {
+			if tx != nil {
+				tx.Rollback()
+			}
+			tx = nil
+			idle = nil
+			return
+		}
+		chunks++
+		if chunks >= s.chunksPerTx {
+			commit()
+			return
+		}
+		idle = time.After(StreamIdleCommit)
+	}
+	for {
+		select {
+		case p, ok := <-s.in:
+			if !ok {
+				flush()
+				commit()
+				return
+			}
+			if s.err != nil {
+				continue // Drain input so senders don't block forever
+			}
+			rows = append(rows, p)
+			if len(rows) >= s.chunkSize {
+				flush()
+			}
+		case <-tick:
+			flush()
+			commit()
+		case <-idle:
+			commit()
+		}
+	}
+}
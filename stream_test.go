@@ -0,0 +1,94 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+	"time"
+)
+
+func TestCypherStream(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	stmt := "UNWIND {rows} AS row CREATE (n:" + label + ") SET n = row"
+	s := db.NewCypherStream(stmt, 10, 3, time.Second)
+	for i := 0; i < 95; i++ {
+		s.Input() <- Props{"i": i}
+	}
+	err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, _ := db.NodesByLabel(label)
+	assert.Equal(t, 95, len(nodes))
+}
+
+func TestCypherStreamBadStatement(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	s := db.NewCypherStream("foobar {rows}", 2, 1, 0)
+	for i := 0; i < 5; i++ {
+		s.Input() <- Props{"i": i}
+	}
+	err := s.Close()
+	assert.NotEqual(t, nil, err)
+}
+
+func TestCypherStreamIdleCommit(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	defer func(d time.Duration) { StreamIdleCommit = d }(StreamIdleCommit)
+	StreamIdleCommit = 100 * time.Millisecond
+	label := rndStr(t)
+	stmt := "UNWIND {rows} AS row CREATE (n:" + label + ") SET n = row"
+	s := db.NewCypherStream(stmt, 2, 100, 0)
+	for i := 0; i < 4; i++ {
+		s.Input() <- Props{"i": i}
+	}
+	time.Sleep(time.Second)
+	// Committed without waiting for Close
+	nodes, err := db.NodesByLabel(label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, len(nodes))
+	err = s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCypherStreamIdleTrickle(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	defer func(d time.Duration) { StreamIdleCommit = d }(StreamIdleCommit)
+	StreamIdleCommit = 200 * time.Millisecond
+	label := rndStr(t)
+	stmt := "UNWIND {rows} AS row CREATE (n:" + label + ") SET n = row"
+	s := db.NewCypherStream(stmt, 3, 100, 0)
+	for i := 0; i < 3; i++ {
+		s.Input() <- Props{"i": i}
+	}
+	// Rows trickling in without completing a chunk do not keep the
+	// transaction open, since nothing reaches the server.
+	for i := 3; i < 5; i++ {
+		time.Sleep(100 * time.Millisecond)
+		s.Input() <- Props{"i": i}
+	}
+	time.Sleep(150 * time.Millisecond)
+	nodes, err := db.NodesByLabel(label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(nodes))
+	err = s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, _ = db.NodesByLabel(label)
+	assert.Equal(t, 5, len(nodes))
+}
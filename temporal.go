@@ -0,0 +1,95 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Neo4j has no native time type, so times are stored as integer milliseconds
+// since the Unix epoch.  Epoch values sort and compare correctly, which makes
+// them suitable for indexing and range queries.
+
+// EpochMillis converts t into milliseconds since the Unix epoch.
+func EpochMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// FromEpochMillis converts milliseconds since the Unix epoch into a time.
+func FromEpochMillis(ms int64) time.Time {
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+}
+
+// SetTime stores t as property key, in epoch milliseconds.  SetTime does not
+// index the property; see IndexTimeProperty.
+func (e *entity) SetTime(key string, t time.Time) error {
+	return e.setProperty(key, EpochMillis(t))
+}
+
+// IndexTimeProperty ensures there is a schema index on property prop of nodes
+// with the given label, so that FindNodesInTimeRange can use it rather than
+// scanning every node with the label.
+func (db *Database) IndexTimeProperty(label, prop string) error {
+	indexes, err := db.Indexes(label)
+	if err != nil && err != NotFound {
+		return err
+	}
+	for _, idx := range indexes {
+		if len(idx.PropertyKeys) == 1 && idx.PropertyKeys[0] == prop {
+			return nil
+		}
+	}
+	_, err = db.CreateIndex(label, prop)
+	return err
+}
+
+// FindNodesInTimeRange returns nodes with the given label whose property prop,
+// in epoch milliseconds, falls within the half-open range [from, to).  Unless
+// the property has been indexed with IndexTimeProperty, the query scans every
+// node with the label.
+func (db *Database) FindNodesInTimeRange(label, prop string, from, to time.Time) ([]*Node, error) {
+	p := "n." + quoteIdent(prop)
+	stmt := "MATCH (n:" + quoteIdent(label) + ") WHERE " + p + " >= {from} AND " + p + " < {to} " +
+		"RETURN n ORDER BY " + p
+	params := Props{
+		"from": EpochMillis(from),
+		"to":   EpochMillis(to),
+	}
+	return db.cypherNodes(stmt, params)
+}
+
+// LuceneTimeRange returns a Lucene query string matching legacy index entries
+// under key whose epoch millisecond values, added with AddNumeric, fall within
+// the inclusive range [from, to].
+func LuceneTimeRange(key string, from, to time.Time) string {
+	return LuceneNumericRange(key, float64(EpochMillis(from)), float64(EpochMillis(to)))
+}
+
+// LuceneNumericRange returns a Lucene query string matching legacy index
+// entries under key, added with AddNumeric, whose values fall within the
+// inclusive range [min, max].
+func LuceneNumericRange(key string, min, max float64) string {
+	return Range(key, SortableFloat(min), SortableFloat(max), true).String()
+}
+
+// SortableFloat encodes v as a fixed-width string whose lexicographic order
+// matches the numeric order of the numbers, negative values included.  Legacy
+// indexes added to through the REST API store every value as a string, so
+// numbers must be encoded like this to be found by range queries.  Integers
+// beyond 2^53 lose precision, as they do in JSON.
+func SortableFloat(v float64) string {
+	if v == 0 {
+		v = 0 // Encode -0 as 0
+	}
+	b := math.Float64bits(v)
+	if b&(1<<63) != 0 {
+		b = ^b
+	} else {
+		b |= 1 << 63
+	}
+	return fmt.Sprintf("%020d", b)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEpochMillis(t *testing.T) {
+	t0 := time.Date(2013, 8, 26, 12, 30, 0, 123000000, time.UTC)
+	ms := EpochMillis(t0)
+	assert.Equal(t, int64(1377520200123), ms)
+	assert.T(t, t0.Equal(FromEpochMillis(ms)))
+	assert.Equal(t, "ts:["+SortableFloat(1000)+" TO "+SortableFloat(2000)+"]", LuceneTimeRange("ts", FromEpochMillis(1000), FromEpochMillis(2000)))
+}
+
+func TestSortableFloat(t *testing.T) {
+	vals := []float64{math.Inf(-1), -1e300, -1000, -1.5, -1, -0.25, 0, 0.25, 1, 1.5, 9, 10, 1000, 1e300, math.Inf(1)}
+	for i := 1; i < len(vals); i++ {
+		a, b := SortableFloat(vals[i-1]), SortableFloat(vals[i])
+		assert.Equal(t, 20, len(b))
+		assert.Tf(t, a < b, "%v encoded as %s, not below %v as %s", vals[i-1], a, vals[i], b)
+	}
+	assert.Equal(t, SortableFloat(0), SortableFloat(math.Copysign(0, -1)))
+}
+
+func TestFindNodesInTimeRange(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	defer cleanupIndexes(t, db)
+	err := db.IndexTimeProperty(label, "created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Indexing twice is harmless
+	err = db.IndexTimeProperty(label, "created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)
+	nodes := []*Node{}
+	for i := 0; i < 5; i++ {
+		n, _ := db.CreateNode(Props{})
+		n.AddLabel(label)
+		err := n.SetTime("created", t0.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+	found, err := db.FindNodesInTimeRange(label, "created", t0.Add(time.Hour), t0.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(found))
+	assert.Equal(t, nodes[1].Id(), found[0].Id())
+	assert.Equal(t, nodes[2].Id(), found[1].Id())
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TenantProp is the property recording the tenant of every node created
+// through a TenantDatabase, and the Cypher parameter holding the tenant's
+// prefix.
+const TenantProp = "tenant"
+
+// A TenantDatabase lets one database serve several tenants.  Labels and index
+// names are namespaced with the tenant's prefix, and the statements it
+// generates only match nodes whose TenantProp is the prefix, so one tenant's
+// data is not visible to another through it.  Nodes and relationships
+// returned are not scoped: requests made through them reach the whole
+// database.
+type TenantDatabase struct {
+	Db     *Database
+	Prefix string
+}
+
+// Tenant returns a TenantDatabase scoping db to the tenant identified by
+// prefix.
+func Tenant(db *Database, prefix string) *TenantDatabase {
+	return &TenantDatabase{Db: db, Prefix: prefix}
+}
+
+// Label returns the tenant's name for label, as stored in the database.
+func (t *TenantDatabase) Label(label string) string {
+	return t.Prefix + "_" + label
+}
+
+// IndexName returns the tenant's name for the legacy index name, as stored
+// in the database.
+func (t *TenantDatabase) IndexName(name string) string {
+	return t.Prefix + "_" + name
+}
+
+// labels returns the Cypher label expression for the tenant's labels.
+func (t *TenantDatabase) labels(labels []string) string {
+	s := ""
+	for _, l := range labels {
+		s += ":" + quoteIdent(t.Label(l))
+	}
+	return s
+}
+
+// CreateNode creates a node belonging to the tenant, with properties p,
+// labels and their ancestors in the Database's Vocabulary.
+func (t *TenantDatabase) CreateNode(p Props, labels ...string) (*Node, error) {
+	labels, err := t.Db.Vocabulary.expandLabels(labels...)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = Props{}
+	}
+	stmt := "CREATE (n" + t.labels(labels) + " {props}) SET n." + quoteIdent(TenantProp) + " = {" + TenantProp + "} RETURN n"
+	nodes, err := t.Db.cypherNodes(stmt, Props{"props": p, TenantProp: t.Prefix})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, NotFound
+	}
+	return nodes[0], nil
+}
+
+// match returns a statement, and its parameters, matching the tenant's nodes
+// with label and every property in p.
+func (t *TenantDatabase) match(label string, p Props) (string, Props) {
+	params := Props{TenantProp: t.Prefix}
+	where := []string{"n." + quoteIdent(TenantProp) + " = {" + TenantProp + "}"}
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		param := "p" + strconv.Itoa(i)
+		where = append(where, "n."+quoteIdent(k)+" = {"+param+"}")
+		params[param] = p[k]
+	}
+	stmt := "MATCH (n" + t.labels([]string{label}) + ") WHERE " + strings.Join(where, " AND ") + " RETURN n"
+	return stmt, params
+}
+
+// NodesByLabel returns the tenant's nodes with label.
+func (t *TenantDatabase) NodesByLabel(label string) ([]*Node, error) {
+	return t.FindNodes(label, nil)
+}
+
+// FindNodes returns the tenant's nodes with label and every property in p.
+func (t *TenantDatabase) FindNodes(label string, p Props) ([]*Node, error) {
+	stmt, params := t.match(label, p)
+	return t.Db.cypherNodes(stmt, params)
+}
+
+// Cypher executes q with the tenant's prefix as parameter TenantProp, so that
+// statements can filter on it - e.g. "MATCH (n) WHERE n.tenant = {tenant}".
+// q.Parameters is replaced by a copy including it; a parameter of the same
+// name given by the caller is overridden, so it cannot widen the statement to
+// another tenant's data.  Labels in the statement should be named with Label.
+func (t *TenantDatabase) Cypher(q *CypherQuery) error {
+	params := map[string]interface{}{}
+	for k, v := range q.Parameters {
+		params[k] = v
+	}
+	params[TenantProp] = t.Prefix
+	q.Parameters = params
+	return t.Db.Cypher(q)
+}
+
+// CreateIndex creates a schema index on property of the tenant's nodes with
+// label.
+func (t *TenantDatabase) CreateIndex(label, property string) (*Index, error) {
+	return t.Db.CreateIndex(t.Label(label), property)
+}
+
+// CreateUniqueConstraint requires property to be unique among the tenant's
+// nodes with label.
+func (t *TenantDatabase) CreateUniqueConstraint(label, property string) (*Constraint, error) {
+	return t.Db.CreateUniqueConstraint(t.Label(label), property)
+}
+
+// CreateLegacyNodeIndex creates the tenant's legacy node index called name.
+func (t *TenantDatabase) CreateLegacyNodeIndex(name, idxType, provider string) (*LegacyNodeIndex, error) {
+	return t.Db.CreateLegacyNodeIndex(t.IndexName(name), idxType, provider)
+}
+
+// LegacyNodeIndex returns the tenant's legacy node index called name.
+func (t *TenantDatabase) LegacyNodeIndex(name string) (*LegacyNodeIndex, error) {
+	return t.Db.LegacyNodeIndex(t.IndexName(name))
+}
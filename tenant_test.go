@@ -0,0 +1,94 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenantMatch(t *testing.T) {
+	acme := Tenant(nil, "acme")
+	assert.Equal(t, "acme_Person", acme.Label("Person"))
+	stmt, params := acme.match("Person", Props{"name": "Bob", "age": 42})
+	assert.Equal(t, "MATCH (n:`acme_Person`) WHERE n.`tenant` = {tenant} AND n.`age` = {p0} AND n.`name` = {p1} RETURN n", stmt)
+	assert.Equal(t, Props{"tenant": "acme", "p0": 42, "p1": "Bob"}, params)
+}
+
+func TestTenantCypherParameter(t *testing.T) {
+	var got cypherRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"columns": [], "data": []}`)
+	}))
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{
+		SkipDiscovery: true,
+		Hrefs:         map[string]string{"cypher": srv.URL + "/db/data/cypher"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A caller's tenant parameter cannot select another tenant's data
+	cq := CypherQuery{
+		Statement:  "MATCH (n) WHERE n.tenant = {tenant} RETURN n",
+		Parameters: map[string]interface{}{TenantProp: "globex", "x": 1},
+	}
+	err = Tenant(db, "acme").Cypher(&cq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]interface{}{TenantProp: "acme", "x": 1.0}, got.Parameters)
+}
+
+func TestTenantCreateNodeVocabulary(t *testing.T) {
+	db := &Database{Vocabulary: NewVocabulary(true)}
+	_, err := Tenant(db, "acme").CreateNode(nil, "Person")
+	assert.Equal(t, UnknownLabel, err)
+}
+
+func TestTenant(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	acme := Tenant(db, "acme")
+	globex := Tenant(db, "globex")
+	for _, tenant := range []*TenantDatabase{acme, globex} {
+		_, err := tenant.CreateNode(Props{"name": "Bob"}, "Person")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Another tenant's node, even if mislabelled, is not matched.
+	n, _ := globex.CreateNode(Props{"name": "Eve"}, "Person")
+	n.AddLabel("acme_Person")
+	nodes, err := acme.FindNodes("Person", Props{"name": "Bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(nodes))
+	assert.Equal(t, "acme", nodes[0].Data[TenantProp])
+	nodes, _ = acme.NodesByLabel("Person")
+	assert.Equal(t, 1, len(nodes))
+	nodes, _ = globex.NodesByLabel("Person")
+	assert.Equal(t, 2, len(nodes))
+	res := []struct {
+		N int `json:"n"`
+	}{}
+	cq := CypherQuery{
+		Statement:  "MATCH (n) WHERE n.tenant = {tenant} AND n.name = {name} RETURN count(n) AS n",
+		Parameters: map[string]interface{}{"name": "Eve"},
+		Result:     &res,
+	}
+	err = globex.Cypher(&cq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, res[0].N)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"time"
+)
+
+// A time tree indexes nodes by date through a hierarchy of Year, Month and
+// Day nodes, each holding its number in property "value":
+//
+//	(:Year)-[:CONTAINS]->(:Month)-[:CONTAINS]->(:Day)<-[:OCCURRED_ON]-(event)
+//
+// The tree is built lazily, as nodes are attached to it.
+const (
+	YearLabel     = "Year"
+	MonthLabel    = "Month"
+	DayLabel      = "Day"
+	TimeTreeRel   = "CONTAINS"
+	OccurredOnRel = "OCCURRED_ON"
+)
+
+// dateKey returns the date of t, in its own location, as an integer YYYYMMDD.
+func dateKey(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+// AttachToDate attaches n to the Day node of the time tree for the date of t,
+// in t's location, creating any missing Year, Month and Day nodes.
+// Attaching a node twice to the same date has no further effect.
+func (db *Database) AttachToDate(n *Node, t time.Time) error {
+	stmt := `
+		START e=node({id})
+		MERGE (y:` + YearLabel + ` {value: {year}})
+		MERGE (y)-[:` + TimeTreeRel + `]->(m:` + MonthLabel + ` {value: {month}})
+		MERGE (m)-[:` + TimeTreeRel + `]->(d:` + DayLabel + ` {value: {day}})
+		MERGE (e)-[:` + OccurredOnRel + `]->(d)
+	`
+	cq := CypherQuery{
+		Statement: stmt,
+		Parameters: Props{
+			"id":    n.Id(),
+			"year":  t.Year(),
+			"month": int(t.Month()),
+			"day":   t.Day(),
+		},
+	}
+	return db.Cypher(&cq)
+}
+
+// NodesInDateRange returns the nodes attached to the time tree on dates from
+// the date of from to the date of to, inclusive, ordered by date.
+func (db *Database) NodesInDateRange(from, to time.Time) ([]*Node, error) {
+	stmt := `
+		MATCH (y:` + YearLabel + `)-[:` + TimeTreeRel + `]->(m:` + MonthLabel + `)
+			-[:` + TimeTreeRel + `]->(d:` + DayLabel + `)<-[:` + OccurredOnRel + `]-(e)
+		WHERE y.value >= {fromYear} AND y.value <= {toYear}
+		WITH e, y.value * 10000 + m.value * 100 + d.value AS date
+		WHERE date >= {from} AND date <= {to}
+		RETURN e ORDER BY date
+	`
+	params := Props{
+		"fromYear": from.Year(),
+		"toYear":   to.Year(),
+		"from":     dateKey(from),
+		"to":       dateKey(to),
+	}
+	return db.cypherNodes(stmt, params)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+	"time"
+)
+
+func TestDateKey(t *testing.T) {
+	assert.Equal(t, 20131231, dateKey(time.Date(2013, 12, 31, 23, 59, 0, 0, time.UTC)))
+	assert.Equal(t, 20140101, dateKey(time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestTimeTree(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	dates := []time.Time{
+		time.Date(2013, 12, 31, 12, 0, 0, 0, time.UTC),
+		time.Date(2014, 1, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2013, 11, 5, 12, 0, 0, 0, time.UTC),
+		time.Date(2014, 1, 1, 18, 0, 0, 0, time.UTC),
+	}
+	for i, d := range dates {
+		n, _ := db.CreateNode(Props{"i": i})
+		err := db.AttachToDate(n, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Attaching twice is harmless
+		db.AttachToDate(n, d)
+	}
+	days, _ := db.NodesByLabel(DayLabel)
+	assert.Equal(t, 3, len(days))
+	years, _ := db.NodesByLabel(YearLabel)
+	assert.Equal(t, 2, len(years))
+	nodes, err := db.NodesInDateRange(dates[2], dates[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, len(nodes))
+	assert.Equal(t, float64(2), nodes[0].Data["i"])
+	nodes, _ = db.NodesInDateRange(dates[0], dates[0])
+	assert.Equal(t, 1, len(nodes))
+	assert.Equal(t, float64(0), nodes[0].Data["i"])
+}
@@ -45,10 +45,12 @@ type txResponse struct {
 
 // unmarshal populates a slice of CypherQuery object with result data returned
 // from the server.
-func (tr *txResponse) unmarshal(qs []*CypherQuery) error {
+func (tr *txResponse) unmarshal(db *Database, qs []*CypherQuery) error {
 	for i, res := range tr.Results {
 		q := qs[i]
 		q.cr = res
+		q.codec = db.Codec
+		q.db = db
 		if q.Result != nil {
 			err := q.Unmarshal(q.Result)
 			if err != nil {
@@ -62,6 +64,10 @@ func (tr *txResponse) unmarshal(qs []*CypherQuery) error {
 // Begin opens a new transaction, executing zero or more cypher queries
 // inside the transaction.
 func (db *Database) Begin(qs []*CypherQuery) (*Tx, error) {
+	err := db.lint(qs...)
+	if err != nil {
+		return nil, err
+	}
 	ne := NeoError{}
 	payload := txRequest{Statements: qs}
 	res := txResponse{}
@@ -71,8 +77,9 @@ func (db *Database) Begin(qs []*CypherQuery) (*Tx, error) {
 		Data:   payload,
 		Result: &res,
 		Error:  &ne,
+		Header: timeoutHeader(qs),
 	}
-	status, err := db.Rc.Do(&rr)
+	status, err := db.do(&rr)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +93,7 @@ func (db *Database) Begin(qs []*CypherQuery) (*Tx, error) {
 		Errors:     res.Errors,
 		Expires:    res.Transaction.Expires,
 	}
-	err = res.unmarshal(qs)
+	err = res.unmarshal(db, qs)
 	if err != nil {
 		return &t, err
 	}
@@ -107,7 +114,7 @@ func (t *Tx) Commit() error {
 		Method: "POST",
 		Error:  &ne,
 	}
-	status, err := t.db.Rc.Do(&rr)
+	status, err := t.db.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -119,6 +126,10 @@ func (t *Tx) Commit() error {
 
 // Query executes statements in an open transaction.
 func (t *Tx) Query(qs []*CypherQuery) error {
+	err := t.db.lint(qs...)
+	if err != nil {
+		return err
+	}
 	ne := NeoError{}
 	payload := txRequest{Statements: qs}
 	res := txResponse{}
@@ -128,8 +139,9 @@ func (t *Tx) Query(qs []*CypherQuery) error {
 		Data:   payload,
 		Result: &res,
 		Error:  &ne,
+		Header: timeoutHeader(qs),
 	}
-	status, err := t.db.Rc.Do(&rr)
+	status, err := t.db.do(&rr)
 	if err != nil {
 		return err
 	}
@@ -141,7 +153,7 @@ func (t *Tx) Query(qs []*CypherQuery) error {
 	}
 	t.Expires = res.Transaction.Expires
 	t.Errors = append(t.Errors, res.Errors...)
-	err = res.unmarshal(qs)
+	err = res.unmarshal(t.db, qs)
 	if err != nil {
 		return err
 	}
@@ -159,7 +171,7 @@ func (t *Tx) Rollback() error {
 		Method: "DELETE",
 		Error:  &ne,
 	}
-	status, err := t.db.Rc.Do(&rr)
+	status, err := t.db.do(&rr)
 	if err != nil {
 		return err
 	}
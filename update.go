@@ -0,0 +1,156 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UpdateProperties changes the node's properties to match p.  Only keys whose
+// values differ from the node's current properties are sent, and keys absent
+// from p or whose value is nil are deleted, all in a single batch request.
+// If the node has been hydrated, its Data is used as the current properties;
+// otherwise they are fetched first.  On success the node's Data is updated to
+// match p.
+func (n *Node) UpdateProperties(p Props) error {
+	current := Props(n.Data)
+	if !n.Hydrated() {
+		var err error
+		current, err = n.Properties()
+		if err != nil {
+			return err
+		}
+	}
+	updated, err := n.updateProperties(current, p)
+	if err != nil {
+		return err
+	}
+	n.Data = updated
+	return nil
+}
+
+// SetPropertiesIfUnchanged replaces the node's properties with p, as
+// SetProperties does, but only if each property in expected still has the
+// value given there - or, where that value is nil, is still absent.
+// Otherwise nothing is changed and Conflict is returned.  The check and the
+// update are made by a single Cypher statement, which write-locks the node
+// first, so no concurrent change can slip in between them.  On success the
+// node's Data is updated to match p.
+func (n *Node) SetPropertiesIfUnchanged(expected, p Props) error {
+	stmt, params := guardedSet(expected)
+	params["id"] = n.Id()
+	normalized, err := normalizeProps(p)
+	if err != nil {
+		return err
+	}
+	params["props"] = normalized
+	count, err := n.Db.cypherCount(stmt, params)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return Conflict
+	}
+	n.Data = normalized
+	return nil
+}
+
+// guardedSet returns a statement, and its parameters other than "id" and
+// "props", replacing the properties of node {id} with {props} only if its
+// current properties match expected.  Setting and removing a dummy property
+// takes the node's write lock before the properties are compared.
+func guardedSet(expected Props) (string, Props) {
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := Props{}
+	where := []string{}
+	for i, k := range keys {
+		prop := "n." + quoteIdent(k)
+		if expected[k] == nil {
+			where = append(where, prop+" IS NULL")
+			continue
+		}
+		param := "e" + strconv.Itoa(i)
+		where = append(where, prop+" = {"+param+"}")
+		params[param] = expected[k]
+	}
+	stmt := "START n=node({id}) " + writeLock("n") + "WITH n "
+	if len(where) > 0 {
+		stmt += "WHERE " + strings.Join(where, " AND ") + " "
+	}
+	stmt += "SET n = {props} RETURN count(n) AS count"
+	return stmt, params
+}
+
+// updateProperties sends the changes needed to turn properties current into
+// p, returning p normalized to the form in which the server returns it.
+func (e *entity) updateProperties(current, p Props) (Props, error) {
+	normal, err := normalizeProps(p)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(normal))
+	for k := range normal {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	jobs := []*batchJob{}
+	for _, k := range keys {
+		old, ok := current[k]
+		if ok && reflect.DeepEqual(old, normal[k]) {
+			continue
+		}
+		jobs = append(jobs, &batchJob{
+			Method: "PUT",
+			To:     e.Db.relPath(join(e.HrefProperties, PathEscape(k))),
+			Id:     len(jobs),
+			Body:   normal[k],
+		})
+	}
+	removed := []string{}
+	for k := range current {
+		if _, ok := normal[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	for _, k := range removed {
+		jobs = append(jobs, &batchJob{
+			Method: "DELETE",
+			To:     e.Db.relPath(join(e.HrefProperties, PathEscape(k))),
+			Id:     len(jobs),
+		})
+	}
+	if len(jobs) == 0 {
+		return normal, nil
+	}
+	_, err = e.Db.sendBatch(jobs)
+	return normal, err
+}
+
+// normalizeProps round-trips p through JSON, so that its values have the same
+// types - float64, []interface{} etc - as properties returned by the server.
+// Keys with nil values are dropped, since Neo4j cannot store a null property.
+func normalizeProps(p Props) (Props, error) {
+	normal := map[string]interface{}{}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &normal)
+	for k, v := range normal {
+		if v == nil {
+			delete(normal, k)
+		}
+	}
+	return Props(normal), err
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestNormalizeProps(t *testing.T) {
+	p, err := normalizeProps(Props{"i": 1, "s": "x", "l": []string{"a"}, "n": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := Props{"i": float64(1), "s": "x", "l": []interface{}{"a"}}
+	assert.Equal(t, exp, p)
+}
+
+func TestUpdateProperties(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n0, _ := db.CreateNode(Props{"keep": 1, "change": "old", "drop": true})
+	db.ResetStatistics()
+	err := n0.UpdateProperties(Props{"keep": 1, "change": "new", "add": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ := n0.Properties()
+	assert.Equal(t, Props{"keep": float64(1), "change": "new", "add": "x"}, props)
+	assert.Equal(t, 1, db.Statistics()["POST /db/data/batch"].Calls)
+	//
+	// No changes, no batch
+	//
+	db.ResetStatistics()
+	err = n0.UpdateProperties(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, db.Statistics()["POST /db/data/batch"].Calls)
+	//
+	// Nil values delete
+	//
+	err = n0.UpdateProperties(Props{"keep": 1, "change": nil, "add": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ = n0.Properties()
+	assert.Equal(t, Props{"keep": float64(1), "add": "x"}, props)
+}
+
+func TestGuardedSet(t *testing.T) {
+	stmt, params := guardedSet(Props{"rev": 3, "owner": nil})
+	assert.Equal(t, "START n=node({id}) SET n.`__neo4j_write_lock` = true REMOVE n.`__neo4j_write_lock` WITH n "+
+		"WHERE n.`owner` IS NULL AND n.`rev` = {e1} SET n = {props} RETURN count(n) AS count", stmt)
+	assert.Equal(t, Props{"e1": 3}, params)
+	stmt, _ = guardedSet(nil)
+	assert.Equal(t, "START n=node({id}) SET n.`__neo4j_write_lock` = true REMOVE n.`__neo4j_write_lock` WITH n SET n = {props} RETURN count(n) AS count", stmt)
+}
+
+func TestSetPropertiesIfUnchanged(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	n, _ := db.CreateNode(Props{"rev": 1, "name": "a"})
+	err := n.SetPropertiesIfUnchanged(Props{"rev": 1}, Props{"rev": 2, "name": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "b", n.Data["name"])
+	err = n.SetPropertiesIfUnchanged(Props{"rev": 1}, Props{"rev": 2, "name": "c"})
+	assert.Equal(t, Conflict, err)
+	err = n.SetPropertiesIfUnchanged(Props{"rev": 2, "owner": nil}, Props{"rev": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ := n.Properties()
+	assert.Equal(t, Props{"rev": float64(3)}, props)
+	// The write lock leaves an application's own _lock property alone
+	n, _ = db.CreateNode(Props{"_lock": "mine", "rev": 1})
+	err = n.SetPropertiesIfUnchanged(Props{"_lock": "mine"}, Props{"_lock": "mine", "rev": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -25,6 +25,54 @@ func join(fragments ...string) string {
 	return strings.Join(parts, "/")
 }
 
+// PathEscape percent-encodes s for use as a single segment of a URL path.
+// Every byte other than ASCII letters, digits, '-', '.', '_' and '~' is
+// escaped, so slashes, spaces, question marks and non-ASCII characters in
+// index keys, values and labels cannot change the meaning of a URL.
+func PathEscape(s string) string {
+	const hex = "0123456789ABCDEF"
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b = append(b, c)
+		default:
+			b = append(b, '%', hex[c>>4], hex[c&15])
+		}
+	}
+	return string(b)
+}
+
+// idFromHref parses the ID number of an entity from the last segment of its
+// href.
+func idFromHref(href string) (int, error) {
+	parts := strings.Split(strings.TrimRight(href, "/"), "/")
+	return strconv.Atoi(parts[len(parts)-1])
+}
+
+// quoteIdent quotes s for use as an identifier - e.g. a label, relationship
+// type or property key - in a Cypher statement.  Identifiers cannot be passed
+// as query parameters, so they must be quoted to be safely interpolated.
+func quoteIdent(s string) string {
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// lockMarker is the property set and removed by writeLock.  It is namespaced
+// so as not to clobber an application's own property; applications must not
+// use it.
+const lockMarker = "__neo4j_write_lock"
+
+// writeLock returns a Cypher clause taking the write lock of the node or
+// relationship named v, so that it is held until the transaction ends,
+// leaving v unchanged: a marker property is set, which takes the lock, then
+// removed again.
+func writeLock(v string) string {
+	marker := v + "." + quoteIdent(lockMarker)
+	return "SET " + marker + " = true REMOVE " + marker + " "
+}
+
 func logPretty(x interface{}) {
 	_, file, line, _ := runtime.Caller(1)
 	lineNo := strconv.Itoa(line)
@@ -0,0 +1,52 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"net/url"
+	"testing"
+)
+
+func TestPathEscape(t *testing.T) {
+	cases := map[string]string{
+		"simple":        "simple",
+		"with space":    "with%20space",
+		"a/b":           "a%2Fb",
+		"what?#":        "what%3F%23",
+		"100%":          "100%25",
+		"naïve":         "na%C3%AFve",
+		"a&b":           "a%26b",
+		"dots.and~tils": "dots.and~tils",
+	}
+	for in, exp := range cases {
+		out := PathEscape(in)
+		assert.Equal(t, exp, out)
+		u, err := url.Parse("http://localhost/" + out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "/"+in, u.Path)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	assert.Equal(t, "`Person`", quoteIdent("Person"))
+	assert.Equal(t, "`odd``name`", quoteIdent("odd`name"))
+}
+
+func TestWriteLock(t *testing.T) {
+	assert.Equal(t, "SET n.`__neo4j_write_lock` = true REMOVE n.`__neo4j_write_lock` ", writeLock("n"))
+}
+
+func TestIdFromHref(t *testing.T) {
+	id, err := idFromHref("http://localhost:7474/db/data/node/123")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 123, id)
+	id, _ = idFromHref("http://localhost:7474/db/data/relationship/7/")
+	assert.Equal(t, 7, id)
+	_, err = idFromHref("http://localhost:7474/db/data/node")
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+// A Check is a consistency check run against the database by Validate.
+type Check interface {
+	// Name describes the check in violation reports.
+	Name() string
+	// Cypher returns a statement whose result rows are violations.  Each row
+	// must have the columns "ids" - a collection of the IDs of the offending
+	// nodes or relationships - and "value", which may be null.
+	Cypher() string
+}
+
+// A Violation is a single failure of a Check.
+type Violation struct {
+	Check string      // Name of the failed check
+	Ids   []int       // IDs of the offending nodes or relationships
+	Value interface{} // Offending value, for checks where one applies
+}
+
+// Validate runs checks in a single batch request, returning every violation
+// found.
+func (db *Database) Validate(checks ...Check) ([]Violation, error) {
+	vs := []Violation{}
+	if len(checks) == 0 {
+		return vs, nil
+	}
+	type row struct {
+		Ids   []int       `json:"ids"`
+		Value interface{} `json:"value"`
+	}
+	results := make([][]row, len(checks))
+	qs := make([]*CypherQuery, len(checks))
+	for i, c := range checks {
+		qs[i] = &CypherQuery{
+			Statement: c.Cypher(),
+			Result:    &results[i],
+		}
+	}
+	err := db.CypherBatch(qs)
+	if err != nil {
+		return vs, err
+	}
+	for i, c := range checks {
+		for _, r := range results[i] {
+			vs = append(vs, Violation{Check: c.Name(), Ids: r.Ids, Value: r.Value})
+		}
+	}
+	return vs, nil
+}
+
+// An OrphanCheck is violated by nodes with the given label which have no
+// relationships.
+type OrphanCheck struct {
+	Label string
+}
+
+// Name returns "orphan :Label".
+func (c OrphanCheck) Name() string {
+	return "orphan :" + c.Label
+}
+
+// Cypher returns a statement finding the label's nodes without relationships.
+func (c OrphanCheck) Cypher() string {
+	return orphanMatch(c.Label) + "RETURN [id(n)] AS ids, null AS value"
+}
+
+// A RequiredRelPropertyCheck is violated by relationships of the given type
+// lacking the given property.
+type RequiredRelPropertyCheck struct {
+	RelType  string
+	Property string
+}
+
+// Name returns "required [:TYPE].property".
+func (c RequiredRelPropertyCheck) Name() string {
+	return "required [:" + c.RelType + "]." + c.Property
+}
+
+// Cypher returns a statement finding relationships lacking the property.
+func (c RequiredRelPropertyCheck) Cypher() string {
+	return "MATCH ()-[r:" + quoteIdent(c.RelType) + "]->() WHERE NOT has(r." + quoteIdent(c.Property) + ") " +
+		"RETURN [id(r)] AS ids, null AS value"
+}
+
+// A UniqueCheck is violated by each value of the given property shared by
+// more than one node with the given label.
+type UniqueCheck struct {
+	Label    string
+	Property string
+}
+
+// Name returns "unique :Label.property".
+func (c UniqueCheck) Name() string {
+	return "unique :" + c.Label + "." + c.Property
+}
+
+// Cypher returns a statement finding each shared value, with the IDs of the
+// nodes sharing it.
+func (c UniqueCheck) Cypher() string {
+	p := "n." + quoteIdent(c.Property)
+	return "MATCH (n:" + quoteIdent(c.Label) + ") WHERE has(" + p + ") " +
+		"WITH " + p + " AS value, collect(id(n)) AS ids WHERE length(ids) > 1 " +
+		"RETURN ids, value"
+}
+
+// A PropertyCoverage counts the nodes with a label by whether they have a
+// property.
+type PropertyCoverage struct {
+	Total   int `json:"total"`   // Nodes with the label
+	Present int `json:"present"` // Nodes having the property
+	Empty   int `json:"empty"`   // Nodes whose property is an empty string or collection
+}
+
+// Missing returns the number of nodes lacking the property.
+func (pc *PropertyCoverage) Missing() int {
+	return pc.Total - pc.Present
+}
+
+// PropertyCoverage counts how many nodes with label have, lack or have an
+// empty value of prop - worth knowing before adding a constraint on prop or
+// migrating it.
+func (db *Database) PropertyCoverage(label, prop string) (*PropertyCoverage, error) {
+	p := "n." + quoteIdent(prop)
+	res := []PropertyCoverage{}
+	cq := CypherQuery{
+		Statement: "MATCH (n:" + quoteIdent(label) + ") " +
+			"RETURN count(*) AS total, count(" + p + ") AS present, " +
+			"sum(CASE WHEN " + p + " = '' OR " + p + " = [] THEN 1 ELSE 0 END) AS empty",
+		Result: &res,
+	}
+	err := db.Cypher(&cq)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return &PropertyCoverage{}, nil
+	}
+	return &res[0], nil
+}
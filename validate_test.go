@@ -0,0 +1,56 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"sort"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	n0, _ := db.CreateNode(Props{"email": "kirk@enterprise"})
+	n1, _ := db.CreateNode(Props{"email": "kirk@enterprise"})
+	n2, _ := db.CreateNode(Props{"email": "spock@enterprise"})
+	for _, n := range []*Node{n0, n1, n2} {
+		n.AddLabel(label)
+	}
+	n0.Relate("knows", n1.Id(), Props{"since": 2260})
+	r1, _ := n1.Relate("knows", n0.Id(), nil)
+	vs, err := db.Validate(
+		OrphanCheck{Label: label},
+		RequiredRelPropertyCheck{RelType: "knows", Property: "since"},
+		UniqueCheck{Label: label, Property: "email"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(vs))
+	assert.Equal(t, []int{n2.Id()}, vs[0].Ids)
+	assert.Equal(t, []int{r1.Id()}, vs[1].Ids)
+	ids := vs[2].Ids
+	sort.Ints(ids)
+	assert.Equal(t, []int{n0.Id(), n1.Id()}, ids)
+	assert.Equal(t, "kirk@enterprise", vs[2].Value)
+}
+
+func TestPropertyCoverage(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	label := rndStr(t)
+	for _, p := range []Props{{"email": "kirk@enterprise"}, {"email": ""}, {}} {
+		n, _ := db.CreateNode(p)
+		n.AddLabel(label)
+	}
+	pc, err := db.PropertyCoverage(label, "email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, PropertyCoverage{Total: 3, Present: 2, Empty: 1}, *pc)
+	assert.Equal(t, 1, pc.Missing())
+}
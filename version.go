@@ -0,0 +1,98 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"time"
+)
+
+// A node's history is kept as a chain of Version nodes, newest first:
+//
+//	(n)-[:PREVIOUS]->(v3:Version)-[:PREVIOUS]->(v2:Version)-[:PREVIOUS]->(v1:Version)
+//
+// Each Version holds a copy of the properties the node had before an update
+// made with SetPropertiesVersioned, plus the time of the update.
+const (
+	VersionLabel    = "Version"
+	VersionRel      = "PREVIOUS"
+	VersionTimeProp = "versioned_at" // Epoch milliseconds
+)
+
+// A Version is a snapshot of a node's properties.
+type Version struct {
+	Node  *Node     // The Version node
+	Time  time.Time // When the snapshot was taken
+	Props Props     // The node's properties at that time
+}
+
+// SetPropertiesVersioned replaces the properties of n with p, after saving
+// its current properties as a new Version at the head of its history.  The
+// snapshot and update are made by a single Cypher statement, and so happen
+// in one transaction.  On success the node's Data is updated to match p.
+func (n *Node) SetPropertiesVersioned(p Props) error {
+	normal, err := normalizeProps(p)
+	if err != nil {
+		return err
+	}
+	stmt := `
+		START n=node({id})
+		OPTIONAL MATCH (n)-[old:` + VersionRel + `]->(prev)
+		CREATE (n)-[:` + VersionRel + `]->(v:` + VersionLabel + `)
+		SET v = n, v.` + VersionTimeProp + ` = timestamp()
+		FOREACH (p IN CASE WHEN prev IS NULL THEN [] ELSE [prev] END |
+			CREATE (v)-[:` + VersionRel + `]->(p))
+		DELETE old
+		SET n = {props}
+		RETURN n
+	`
+	params := Props{
+		"id":    n.Id(),
+		"props": normal,
+	}
+	nodes, err := n.Db.cypherNodes(stmt, params)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return NotFound
+	}
+	n.Data = nodes[0].Data
+	return nil
+}
+
+// Versions returns the saved versions of n, newest first.
+func (n *Node) Versions() ([]*Version, error) {
+	stmt := `
+		START n=node({id})
+		MATCH p=(n)-[:` + VersionRel + `*]->(v:` + VersionLabel + `)
+		RETURN v ORDER BY length(p)
+	`
+	nodes, err := n.Db.cypherNodes(stmt, Props{"id": n.Id()})
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*Version, len(nodes))
+	for i, vn := range nodes {
+		v := &Version{Node: vn, Props: Props{}}
+		for k, val := range vn.Data {
+			if k == VersionTimeProp {
+				if ms, ok := val.(float64); ok {
+					v.Time = FromEpochMillis(int64(ms))
+				}
+				continue
+			}
+			v.Props[k] = val
+		}
+		versions[i] = v
+	}
+	return versions, nil
+}
+
+// Restore sets the properties of n back to those saved in v.  The restore is
+// itself versioned, so the properties it replaces are saved as the newest
+// Version.
+func (n *Node) Restore(v *Version) error {
+	return n.SetPropertiesVersioned(v.Props)
+}
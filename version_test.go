@@ -0,0 +1,47 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+	"time"
+)
+
+func TestVersions(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	start := time.Now().Add(-time.Minute)
+	n, _ := db.CreateNode(Props{"title": "draft"})
+	versions, err := n.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(versions))
+	err = n.SetPropertiesVersioned(Props{"title": "first", "words": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Props{"title": "first", "words": float64(10)}, Props(n.Data))
+	n.SetPropertiesVersioned(Props{"title": "second"})
+	versions, err = n.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(versions))
+	assert.Equal(t, Props{"title": "first", "words": float64(10)}, versions[0].Props)
+	assert.Equal(t, Props{"title": "draft"}, versions[1].Props)
+	assert.T(t, versions[1].Time.After(start))
+	// Restoring saves the current state too
+	err = n.Restore(versions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	props, _ := n.Properties()
+	assert.Equal(t, Props{"title": "draft"}, props)
+	versions, _ = n.Versions()
+	assert.Equal(t, 3, len(versions))
+	assert.Equal(t, Props{"title": "second"}, versions[0].Props)
+}
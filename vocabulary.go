@@ -0,0 +1,286 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"log"
+	"sort"
+	"sync"
+)
+
+// A Vocabulary is a registry of the labels and relationship types an
+// application expects to use.  When a Vocabulary is assigned to a Database,
+// calls which create relationships or add labels are checked against it,
+// catching typos before they pollute the graph.  A strict Vocabulary rejects
+// unregistered names; otherwise they are logged but allowed.
+//
+// A Vocabulary is safe for concurrent use.
+type Vocabulary struct {
+	strict   bool
+	mu       sync.RWMutex
+	labels   map[string]bool
+	relTypes map[string]bool
+	groups   map[string][]string // Labels of each label group
+	groupOf  map[string]string   // Label group of each label
+	parents  map[string]string   // Parent of each label in the taxonomy
+}
+
+// NewVocabulary returns an empty Vocabulary.
+func NewVocabulary(strict bool) *Vocabulary {
+	return &Vocabulary{
+		strict:   strict,
+		labels:   make(map[string]bool),
+		relTypes: make(map[string]bool),
+		groups:   make(map[string][]string),
+		groupOf:  make(map[string]string),
+		parents:  make(map[string]string),
+	}
+}
+
+// Strict reports whether the vocabulary rejects unregistered names.
+func (v *Vocabulary) Strict() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.strict
+}
+
+// SetStrict sets whether the vocabulary rejects unregistered names, or merely
+// logs them.
+func (v *Vocabulary) SetStrict(strict bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.strict = strict
+}
+
+// RegisterLabels adds labels to the vocabulary.
+func (v *Vocabulary) RegisterLabels(labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, l := range labels {
+		v.labels[l] = true
+	}
+}
+
+// RegisterLabelGroup declares the named group of mutually exclusive labels,
+// and registers the labels.  A label may belong to only one group; labels
+// registered in an earlier group are moved to this one.
+func (v *Vocabulary) RegisterLabelGroup(group string, labels ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, l := range v.groups[group] {
+		delete(v.groupOf, l)
+	}
+	members := []string{}
+	for _, l := range labels {
+		if old, ok := v.groupOf[l]; ok && old != group {
+			v.groups[old] = removeString(v.groups[old], l)
+		}
+		v.labels[l] = true
+		v.groupOf[l] = group
+		members = append(members, l)
+	}
+	v.groups[group] = members
+}
+
+// LabelGroup returns the labels of the named group, or UnknownLabelGroup if
+// there is no such group.
+func (v *Vocabulary) LabelGroup(group string) ([]string, error) {
+	if v == nil {
+		return nil, UnknownLabelGroup
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	members, ok := v.groups[group]
+	if !ok {
+		return nil, UnknownLabelGroup
+	}
+	return append([]string{}, members...), nil
+}
+
+// RegisterSubLabel places child beneath parent in the taxonomy, replacing
+// any parent it had, and registers both labels.  It fails with LabelCycle if
+// parent is child or one of its descendants.
+func (v *Vocabulary) RegisterSubLabel(child, parent string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for l := parent; l != ""; l = v.parents[l] {
+		if l == child {
+			return LabelCycle
+		}
+	}
+	v.labels[child] = true
+	v.labels[parent] = true
+	v.parents[child] = parent
+	return nil
+}
+
+// Ancestors returns the ancestors of label in the taxonomy, nearest first.
+func (v *Vocabulary) Ancestors(label string) []string {
+	res := []string{}
+	if v == nil {
+		return res
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for l := v.parents[label]; l != ""; l = v.parents[l] {
+		res = append(res, l)
+	}
+	return res
+}
+
+// Descendants returns the descendants of label in the taxonomy, in sorted
+// order.
+func (v *Vocabulary) Descendants(label string) []string {
+	res := []string{}
+	if v == nil {
+		return res
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for l := range v.parents {
+		for a := v.parents[l]; a != ""; a = v.parents[a] {
+			if a == label {
+				res = append(res, l)
+				break
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// withAncestors returns labels followed by those of their ancestors not
+// already among them.
+func (v *Vocabulary) withAncestors(labels []string) []string {
+	res := append([]string{}, labels...)
+	seen := map[string]bool{}
+	for _, l := range labels {
+		seen[l] = true
+	}
+	for _, l := range labels {
+		for _, a := range v.Ancestors(l) {
+			if !seen[a] {
+				seen[a] = true
+				res = append(res, a)
+			}
+		}
+	}
+	return res
+}
+
+// expandLabels returns labels followed by their ancestors, checked by
+// CheckLabels.  Every call which adds labels to nodes goes through it, so that
+// the taxonomy and the vocabulary's checks apply uniformly.
+func (v *Vocabulary) expandLabels(labels ...string) ([]string, error) {
+	labels = v.withAncestors(labels)
+	return labels, v.CheckLabels(labels...)
+}
+
+// labelExpr returns the Cypher label expression - e.g. :`A`:`B` - for labels.
+func labelExpr(labels []string) string {
+	s := ""
+	for _, l := range labels {
+		s += ":" + quoteIdent(l)
+	}
+	return s
+}
+
+// removeString returns ss without s.
+func removeString(ss []string, s string) []string {
+	res := []string{}
+	for _, x := range ss {
+		if x != s {
+			res = append(res, x)
+		}
+	}
+	return res
+}
+
+// RegisterRelTypes adds relationship types to the vocabulary.
+func (v *Vocabulary) RegisterRelTypes(types ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, t := range types {
+		v.relTypes[t] = true
+	}
+}
+
+// CheckLabels returns UnknownLabel if any of labels is not registered and the
+// vocabulary is strict, and ExclusiveLabels if two of them belong to the same
+// label group.  A nil Vocabulary permits everything.
+func (v *Vocabulary) CheckLabels(labels ...string) error {
+	if v == nil {
+		return nil
+	}
+	err := v.checkExclusive(labels)
+	if err != nil {
+		return err
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, l := range labels {
+		if !v.labels[l] {
+			if v.strict {
+				return UnknownLabel
+			}
+			log.Printf("neo4j: label %q is not registered in the vocabulary", l)
+		}
+	}
+	return nil
+}
+
+// checkExclusive returns ExclusiveLabels if two of labels belong to the same
+// label group.
+func (v *Vocabulary) checkExclusive(labels []string) error {
+	if v == nil {
+		return nil
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	seen := map[string]string{}
+	for _, l := range labels {
+		if g, ok := v.groupOf[l]; ok {
+			if other, ok := seen[g]; ok && other != l {
+				return ExclusiveLabels
+			}
+			seen[g] = l
+		}
+	}
+	return nil
+}
+
+// grouped reports whether any of labels belongs to a label group.
+func (v *Vocabulary) grouped(labels []string) bool {
+	if v == nil {
+		return false
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, l := range labels {
+		if _, ok := v.groupOf[l]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRelTypes returns UnknownRelType if any of types is not registered and
+// the vocabulary is strict.  A nil Vocabulary permits everything.
+func (v *Vocabulary) CheckRelTypes(types ...string) error {
+	if v == nil {
+		return nil
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, t := range types {
+		if !v.relTypes[t] {
+			if v.strict {
+				return UnknownRelType
+			}
+			log.Printf("neo4j: relationship type %q is not registered in the vocabulary", t)
+		}
+	}
+	return nil
+}
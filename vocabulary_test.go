@@ -0,0 +1,143 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestVocabularyCheck(t *testing.T) {
+	var nilVocab *Vocabulary
+	assert.Equal(t, nil, nilVocab.CheckLabels("Anything"))
+	v := NewVocabulary(true)
+	v.RegisterLabels("Person")
+	v.RegisterRelTypes("KNOWS")
+	assert.Equal(t, nil, v.CheckLabels("Person"))
+	assert.Equal(t, UnknownLabel, v.CheckLabels("Person", "Persn"))
+	assert.Equal(t, nil, v.CheckRelTypes("KNOWS"))
+	assert.Equal(t, UnknownRelType, v.CheckRelTypes("KNWS"))
+	assert.T(t, v.Strict())
+	v.SetStrict(false)
+	assert.Equal(t, nil, v.CheckRelTypes("KNWS"))
+}
+
+func TestVocabularyRelate(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	db.Vocabulary = NewVocabulary(true)
+	defer func() { db.Vocabulary = nil }()
+	db.Vocabulary.RegisterRelTypes("KNOWS")
+	db.Vocabulary.RegisterLabels("Person")
+	n0, _ := db.CreateNode(Props{})
+	n1, _ := db.CreateNode(Props{})
+	_, err := n0.Relate("KNOWS", n1.Id(), nil)
+	assert.Equal(t, nil, err)
+	_, err = n0.Relate("KNWS", n1.Id(), nil)
+	assert.Equal(t, UnknownRelType, err)
+	_, err = db.CreateRelationships([]RelSpec{RelSpec{Start: n0.Id(), End: n1.Id(), Type: "KNWS"}})
+	assert.Equal(t, UnknownRelType, err)
+	assert.Equal(t, nil, n0.AddLabel("Person"))
+	assert.Equal(t, UnknownLabel, n0.AddLabel("Persn"))
+	assert.Equal(t, UnknownLabel, n0.SetLabels([]string{"Persn"}))
+	_, err = db.Import("Persn", "key", []ImportNode{ImportNode{Key: "a"}}, nil)
+	assert.Equal(t, UnknownLabel, err)
+}
+
+func TestLabelGroups(t *testing.T) {
+	v := NewVocabulary(true)
+	v.RegisterLabelGroup("state", "Active", "Archived")
+	v.RegisterLabels("Person")
+	assert.Equal(t, nil, v.CheckLabels("Person", "Active"))
+	assert.Equal(t, ExclusiveLabels, v.CheckLabels("Active", "Person", "Archived"))
+	assert.Equal(t, ExclusiveLabels, v.checkExclusive([]string{"Unregistered", "Active", "Archived"}))
+	assert.T(t, v.grouped([]string{"Person", "Active"}))
+	assert.T(t, !v.grouped([]string{"Person"}))
+	members, err := v.LabelGroup("state")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"Active", "Archived"}, members)
+	_, err = v.LabelGroup("colour")
+	assert.Equal(t, UnknownLabelGroup, err)
+	v.RegisterLabelGroup("review", "Archived", "Pending")
+	members, _ = v.LabelGroup("state")
+	assert.Equal(t, []string{"Active"}, members)
+	assert.Equal(t, nil, v.CheckLabels("Active", "Archived"))
+}
+
+func TestSetStateLabel(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	db.Vocabulary = NewVocabulary(true)
+	defer func() { db.Vocabulary = nil }()
+	db.Vocabulary.RegisterLabelGroup("state", "Active", "Archived")
+	db.Vocabulary.RegisterLabels("Person")
+	n0, _ := db.CreateNode(Props{})
+	n0.AddLabel("Person", "Active")
+	assert.Equal(t, nil, n0.SetStateLabel("state", "Archived"))
+	labels, _ := n0.Labels()
+	sort.Strings(labels)
+	assert.Equal(t, []string{"Archived", "Person"}, labels)
+	assert.Equal(t, NotInLabelGroup, n0.SetStateLabel("state", "Person"))
+	assert.Equal(t, UnknownLabelGroup, n0.SetStateLabel("colour", "Red"))
+	assert.Equal(t, ExclusiveLabels, n0.SetLabels([]string{"Active", "Archived"}))
+	// AddLabel checks against the labels the node already has
+	assert.Equal(t, ExclusiveLabels, n0.AddLabel("Active"))
+	assert.Equal(t, nil, n0.AddLabel("Archived"))
+}
+
+func TestTaxonomy(t *testing.T) {
+	v := NewVocabulary(true)
+	assert.Equal(t, nil, v.RegisterSubLabel("Mammal", "Animal"))
+	assert.Equal(t, nil, v.RegisterSubLabel("Dog", "Mammal"))
+	assert.Equal(t, nil, v.RegisterSubLabel("Cat", "Mammal"))
+	assert.Equal(t, LabelCycle, v.RegisterSubLabel("Animal", "Dog"))
+	assert.Equal(t, LabelCycle, v.RegisterSubLabel("Dog", "Dog"))
+	assert.Equal(t, []string{"Mammal", "Animal"}, v.Ancestors("Dog"))
+	assert.Equal(t, []string{"Cat", "Dog", "Mammal"}, v.Descendants("Animal"))
+	assert.Equal(t, []string{"Dog", "Pet", "Animal", "Mammal"}, v.withAncestors([]string{"Dog", "Pet", "Animal"}))
+	assert.Equal(t, nil, v.CheckLabels("Dog", "Animal"))
+	labels, err := v.expandLabels("Dog")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"Dog", "Mammal", "Animal"}, labels)
+	_, err = v.expandLabels("Fish")
+	assert.Equal(t, UnknownLabel, err)
+	assert.Equal(t, ":`Mammal`:`Animal`", labelExpr(labels[1:]))
+	// Every way of labelling nodes adds the ancestors
+	db := &Database{Url: "http://localhost:7474/db/data", HrefNode: "http://localhost:7474/db/data/node", Vocabulary: v}
+	b := db.NewBatch()
+	b.AddLabel(b.CreateNode(nil), "Cat")
+	assert.Equal(t, []string{"Cat", "Mammal", "Animal"}, b.jobs[1].Body)
+	stmt := bulkMergeStatement(labels, "name")
+	assert.T(t, strings.Contains(stmt, "SET n += row SET n:`Mammal`:`Animal` RETURN"))
+	var nilVocab *Vocabulary
+	assert.Equal(t, []string{"Dog"}, nilVocab.withAncestors([]string{"Dog"}))
+}
+
+func TestNodesByLabelTree(t *testing.T) {
+	db := connectTest(t)
+	defer cleanup(t, db)
+	db.Vocabulary = NewVocabulary(false)
+	defer func() { db.Vocabulary = nil }()
+	animal, dog := rndStr(t), rndStr(t)
+	n0, _ := db.CreateNode(Props{})
+	n0.AddLabel(dog) // Before the taxonomy
+	db.Vocabulary.RegisterSubLabel(dog, animal)
+	n1, _ := db.CreateNode(Props{})
+	n1.AddLabel(dog)
+	labels, _ := n1.Labels()
+	sort.Strings(labels)
+	exp := []string{animal, dog}
+	sort.Strings(exp)
+	assert.Equal(t, exp, labels)
+	nodes, _ := db.NodesByLabel(animal)
+	assert.Equal(t, 1, len(nodes))
+	nodes, err := db.NodesByLabelTree(animal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(nodes))
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// A WriteCoordinator serializes, within this process, writes touching the same
+// node or relationship.  Concurrent transactions updating a hotspot otherwise
+// deadlock on the server and must be retried; queueing them client-side is
+// cheaper.  When set as Database.Writes, it serializes the property and label
+// writes made through Node and Relationship methods; other writes, such as
+// Cypher statements, can be wrapped in Do.  Locks are reentrant: Node and
+// Relationship methods may be called within Do for the keys it holds, but
+// only from the goroutine which called Do.  Writers in other processes are
+// not coordinated.
+type WriteCoordinator struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// A keyLock is the lock on one key, freed once nobody holds or awaits it.
+// Its owner and depth are guarded by the WriteCoordinator's mutex.
+type keyLock struct {
+	sync.Mutex
+	refs  int
+	owner int64 // Goroutine holding the lock, or 0
+	depth int   // Times the owner has locked it
+}
+
+// NewWriteCoordinator returns a WriteCoordinator.
+func NewWriteCoordinator() *WriteCoordinator {
+	return &WriteCoordinator{locks: map[string]*keyLock{}}
+}
+
+// NodeKey returns the key under which writes to the node with the given ID
+// are serialized.
+func NodeKey(id int) string {
+	return "/node/" + strconv.Itoa(id)
+}
+
+// RelationshipKey returns the key under which writes to the relationship with
+// the given ID are serialized.
+func RelationshipKey(id int) string {
+	return "/relationship/" + strconv.Itoa(id)
+}
+
+// ExternalKey returns a key for the node with label identified by the given
+// value of property, for writes to nodes which may not yet exist.
+func ExternalKey(label, property string, value interface{}) string {
+	return fmt.Sprintf(":%s.%s=%v", label, property, value)
+}
+
+// Do calls f while holding the locks for keys.  Keys are locked in sorted
+// order, so that callers locking several keys cannot deadlock one another.
+// On a nil WriteCoordinator, f is called at once.
+func (wc *WriteCoordinator) Do(keys []string, f func() error) error {
+	if wc == nil {
+		return f()
+	}
+	sorted := make([]string, 0, len(keys))
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		wc.lock(k)
+		defer wc.unlock(k)
+	}
+	return f()
+}
+
+func (wc *WriteCoordinator) lock(key string) {
+	g := goid()
+	wc.mu.Lock()
+	l := wc.locks[key]
+	if l == nil {
+		l = &keyLock{}
+		wc.locks[key] = l
+	}
+	if l.owner == g {
+		l.depth++
+		wc.mu.Unlock()
+		return
+	}
+	l.refs++
+	wc.mu.Unlock()
+	l.Lock()
+	wc.mu.Lock()
+	l.owner = g
+	l.depth = 1
+	wc.mu.Unlock()
+}
+
+func (wc *WriteCoordinator) unlock(key string) {
+	wc.mu.Lock()
+	l := wc.locks[key]
+	l.depth--
+	if l.depth > 0 {
+		wc.mu.Unlock()
+		return
+	}
+	l.owner = 0
+	l.refs--
+	if l.refs == 0 {
+		delete(wc.locks, key)
+	}
+	wc.mu.Unlock()
+	l.Unlock()
+}
+
+// goid returns the ID of the calling goroutine, as printed at the head of its
+// stack trace: "goroutine 42 [running]:".
+func goid() int64 {
+	b := make([]byte, 32)
+	b = b[:runtime.Stack(b, false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
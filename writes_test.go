@@ -0,0 +1,134 @@
+// Copyright (c) 2012-2013 Jason McVetta.  This is Free Software, released under
+// the terms of the GPL v3.  See http://www.gnu.org/copyleft/gpl.html for details.
+// Resist intellectual serfdom - the ownership of ideas is akin to slavery.
+
+package neo4j
+
+import (
+	"github.com/bmizerany/assert"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteCoordinator(t *testing.T) {
+	wc := NewWriteCoordinator()
+	var wg sync.WaitGroup
+	active := map[string]int{}
+	var mu sync.Mutex
+	overlap := false
+	for i := 0; i < 50; i++ {
+		keys := []string{NodeKey(i % 3), NodeKey((i + 1) % 3)}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wc.Do(keys, func() error {
+				mu.Lock()
+				for _, k := range keys {
+					active[k]++
+					overlap = overlap || active[k] > 1
+				}
+				mu.Unlock()
+				runtime.Gosched()
+				mu.Lock()
+				for _, k := range keys {
+					active[k]--
+				}
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	assert.T(t, !overlap)
+	assert.Equal(t, 0, len(wc.locks))
+	var nilWc *WriteCoordinator
+	called := false
+	nilWc.Do([]string{"x"}, func() error { called = true; return nil })
+	assert.T(t, called)
+	assert.Equal(t, ":Person.email=a@b", ExternalKey("Person", "email", "a@b"))
+}
+
+// writeServer serves property writes, recording the greatest number in
+// flight at once.
+func writeServer() (*httptest.Server, *int) {
+	var mu sync.Mutex
+	active, max := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > max {
+			max = active
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.WriteHeader(204)
+	}))
+	return srv, &max
+}
+
+// writeNode returns a node bound to db, served by srv.
+func writeNode(db *Database, srv *httptest.Server) *Node {
+	n := &Node{}
+	n.Db = db
+	n.HrefSelf = srv.URL + "/db/data/node/5"
+	n.HrefProperties = n.HrefSelf + "/properties"
+	return n
+}
+
+func TestEntityWritesCoordinated(t *testing.T) {
+	srv, max := writeServer()
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{SkipDiscovery: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Writes = NewWriteCoordinator()
+	n := writeNode(db, srv)
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- n.SetProperty("touched", "yes")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Equal(t, nil, err)
+	}
+	assert.Equal(t, 1, *max)
+	assert.Equal(t, 0, len(db.Writes.locks))
+}
+
+func TestWriteCoordinatorReentrant(t *testing.T) {
+	srv, _ := writeServer()
+	defer srv.Close()
+	db, err := ConnectWithOptions(srv.URL+"/db/data", &ConnectOptions{SkipDiscovery: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Writes = NewWriteCoordinator()
+	n := writeNode(db, srv)
+	done := make(chan error)
+	go func() {
+		done <- db.Writes.Do([]string{NodeKey(5)}, func() error {
+			return n.SetProperty("touched", "yes")
+		})
+	}()
+	select {
+	case err := <-done:
+		assert.Equal(t, nil, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write within Do deadlocked")
+	}
+	assert.Equal(t, 0, len(db.Writes.locks))
+}